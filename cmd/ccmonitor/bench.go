@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/demo"
+	"github.com/martinwickman/ccmonitor/internal/monitor"
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// bench runs "ccmonitor bench", a hidden command that measures the work the
+// monitor does every second, loading the session files and rendering them,
+// for a given number of synthetic sessions. It doesn't touch the real
+// sessions directory.
+func bench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	n := fs.Int("sessions", 100, "number of synthetic sessions")
+	iterations := fs.Int("iterations", 200, "times to load and render them")
+	width := fs.Int("width", 120, "width to render at")
+	fs.Parse(args)
+
+	dir, err := os.MkdirTemp("", "ccmonitor-bench-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	for _, s := range demo.Many(*n, time.Now()) {
+		data, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, s.SessionID+".json"), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	var sessions []session.Session
+	load := measure(*iterations, func() {
+		sessions, err = session.LoadAll(dir)
+	})
+	if err != nil {
+		return err
+	}
+	render := measure(*iterations, func() {
+		monitor.RenderOnce(session.SortByAttention(sessions), *width, false, nil)
+	})
+	fmt.Printf("%d sessions, %d iterations\n", len(sessions), *iterations)
+	fmt.Printf("LoadAll  %12v/op\n", load)
+	fmt.Printf("render   %12v/op\n", render)
+	return nil
+}
+
+// measure runs fn iterations times and returns the mean time per run.
+func measure(iterations int, fn func()) time.Duration {
+	if iterations < 1 {
+		iterations = 1
+	}
+	start := time.Now()
+	for range iterations {
+		fn()
+	}
+	return time.Since(start) / time.Duration(iterations)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/martinwickman/ccmonitor/internal/install"
+)
+
+// installHooks runs "ccmonitor install", which registers the hook in Claude
+// Code's user settings, or removes it again with --uninstall.
+func installHooks(args []string) error {
+	fs := flag.NewFlagSet("install", flag.ExitOnError)
+	uninstall := fs.Bool("uninstall", false, "remove the ccmonitor hooks instead")
+	dryRun := fs.Bool("dry-run", false, "only list the hook events that would change")
+	path := fs.String("settings", install.SettingsPath(), "Claude Code settings file")
+	fs.Parse(args)
+
+	changed, err := install.Apply(*path, *uninstall, *dryRun)
+	if errors.Is(err, install.ErrPlugin) {
+		return fmt.Errorf("%w; keep using the plugin, or disable it with /plugin before installing", err)
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(changed) == 0 && *uninstall:
+		fmt.Printf("No ccmonitor hooks in %s\n", *path)
+		return nil
+	case len(changed) == 0:
+		fmt.Printf("The ccmonitor hooks are already in %s\n", *path)
+		return nil
+	}
+	verb := "Added"
+	switch {
+	case *dryRun && *uninstall:
+		verb = "Would remove"
+	case *dryRun:
+		verb = "Would add"
+	case *uninstall:
+		verb = "Removed"
+	}
+	fmt.Printf("%s hooks for %s in %s\n", verb, strings.Join(changed, ", "), *path)
+	if !*dryRun {
+		fmt.Println("Restart running Claude Code sessions to pick them up")
+	}
+	return nil
+}
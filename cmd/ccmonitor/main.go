@@ -3,30 +3,197 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // for the "timezone" setting on Windows, which has no zoneinfo
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/martinwickman/ccmonitor/internal/bundle"
+	"github.com/martinwickman/ccmonitor/internal/config"
+	"github.com/martinwickman/ccmonitor/internal/demo"
+	"github.com/martinwickman/ccmonitor/internal/doctor"
 	"github.com/martinwickman/ccmonitor/internal/hook"
+	"github.com/martinwickman/ccmonitor/internal/install"
+	"github.com/martinwickman/ccmonitor/internal/logging"
 	"github.com/martinwickman/ccmonitor/internal/monitor"
 	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/switcher"
+	"github.com/martinwickman/ccmonitor/internal/transcript"
+	"github.com/martinwickman/ccmonitor/internal/tray"
+	"github.com/muesli/termenv"
 	"golang.org/x/term"
 )
 
 func main() {
 	if len(os.Args) > 1 && os.Args[1] == "hook" {
-		if err := hook.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "ccmonitor hook: %v\n", err)
+		runHook("hook", hook.Run)
+		return
+	}
+
+	// Not in the usage: started by the hook when finding a terminal is slow.
+	if len(os.Args) > 1 && os.Args[1] == "hook-lookup" {
+		runHook("hook-lookup", func() error { return hook.Lookup(os.Args[2:]) })
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "ingest" {
+		runHook("ingest", hook.Ingest)
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "install" {
+		session.MigrateLegacy() // best-effort, moves Windows sessions out of ~/.ccmonitor
+		if err := installHooks(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 		return
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		ok := doctor.Write(os.Stdout, doctor.Run(doctor.Options{
+			SettingsPath: install.SettingsPath(),
+			SessionsDir:  session.Dir(),
+			Now:          time.Now(),
+		}))
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Not in the usage: "ccmonitor bench" is for working on ccmonitor.
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		if err := bench(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "statusline-hook" {
+		runHook("statusline-hook", func() error { return hook.Statusline(os.Args[2:]) })
+		return
+	}
+
+	// "ccmonitor tray" takes the same flags as the monitor.
+	trayMode := len(os.Args) > 1 && os.Args[1] == "tray"
+	// So does "ccmonitor snapshot [file]", plus --width and --plain.
+	snapshotMode := len(os.Args) > 1 && os.Args[1] == "snapshot"
+	// "ccmonitor clean" (or "prune") removes the session files selected by
+	// --status, --project and --older-than, unlike --clean which removes
+	// them all.
+	cleanMode := len(os.Args) > 1 && (os.Args[1] == "clean" || os.Args[1] == "prune")
+	// "ccmonitor debug-bundle [file]" collects what a bug report needs.
+	bundleMode := len(os.Args) > 1 && os.Args[1] == "debug-bundle"
+	// "ccmonitor status <id|project>" prints one session's state and exits
+	// with a code per status (see monitor.StatusExitCodes).
+	statusMode := len(os.Args) > 1 && os.Args[1] == "status"
+	// "ccmonitor switch <id|project>" switches to a session's terminal.
+	switchMode := len(os.Args) > 1 && os.Args[1] == "switch"
+	// "ccmonitor watch" is another name for --follow.
+	watchMode := len(os.Args) > 1 && os.Args[1] == "watch"
+	if trayMode || snapshotMode || cleanMode || bundleMode || statusMode || switchMode || watchMode {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	once := flag.Bool("once", false, "print current state and exit")
-	clean := flag.Bool("clean", false, "remove all session files and exit")
-	debug := flag.Bool("debug", false, "show session IDs and PIDs")
+	jsonOut := flag.Bool("json", false, "print the sessions as a JSON array and exit, like --once")
+	waitingExit := flag.Int("waiting-exit", 0, "with --once or --json, exit with this code when a session is waiting for you (0: always exit 0)")
+	follow := flag.Bool("follow", false, "print a line per status change and keep running (for logs and pipes)")
+	accessible := flag.Bool("accessible", false, "screen-reader friendly output: plain sentences, changes announced on one line")
+	clean := flag.Bool("clean", false, "remove all session files and exit (see also: ccmonitor clean)")
+	status := flag.String("status", "", "with --once, --json or clean, only sessions with these statuses, comma-separated (e.g. exited,idle)")
+	dryRun := flag.Bool("dry-run", false, "clean: list the session files that would be removed without removing them")
+	olderThan := flag.Duration("older-than", 0, "clean only sessions with no activity for this long (e.g. 2h)")
+	since := flag.Duration("since", 0, "with --once or --json, only sessions with activity in this long (e.g. 30m)")
+	debug := flag.Bool("debug", false, "show session IDs, PIDs and models")
+	project := flag.String("project", "", "only show sessions in this directory or below (e.g. . for the current repo)")
+	stallAfter := flag.Duration("stall-after", monitor.DefaultStallAfter, "show working sessions with no hook events for this long as stalled (0 disables)")
+	collapseAfter := flag.Duration("collapse-after", monitor.DefaultCollapseAfter, "collapse sessions idle for this long to one line (0 disables)")
+	stallBell := flag.Bool("stall-bell", false, "ring the terminal bell when a session becomes stalled")
+	configPath := flag.String("config", config.Path(), "config file (custom columns and key bindings); env: CCMONITOR_CONFIG")
+	theme := flag.String("theme", "", "color theme: default or high-contrast (no faint text); overrides the config file")
+	tmuxBorder := flag.Bool("tmux-border", false, "color each session's tmux pane border by its status (tmux 3.1+; restored on exit)")
+	cleanExited := flag.Bool("clean-exited", false, "remove the session files of sessions whose process has died, instead of showing them as exited")
+	transcripts := flag.Bool("transcripts", false, "read Claude Code's transcripts instead of hook session files (automatic until a hook has run)")
+	demoMode := flag.Bool("demo", false, "show made-up sessions that change every few seconds, for screenshots or trying it out (nothing is read or written)")
+	width := flag.Int("width", 0, "width to render --once and snapshot at (default: the terminal's, or 80)")
+	plain := flag.Bool("plain", false, "snapshot without colors and styles")
+	pprofAddr := flag.String("pprof", "", "serve Go profiling data (net/http/pprof) on this address, e.g. localhost:6060")
+	logLevel := flag.String("log-level", logging.EnvLevel(), "log level: debug, info, warn or error (env: CCMONITOR_LOG_LEVEL)")
+	logFile := flag.String("log-file", logging.EnvFile(), "append log records to this file; the TUI and tray only log to a file (env: CCMONITOR_LOG_FILE)")
+	launchCmd := flag.String("launch-cmd", launchCmdDefault(), "command run in new tabs opened with L, with {dir} and {project} expanded (env: CCMONITOR_LAUNCH_CMD)")
 	flag.Parse()
+	if watchMode {
+		*follow = true
+	}
+
+	// The TUI owns the terminal and the tray has none, so without a log file
+	// they don't log at all.
+	var logFallback io.Writer = os.Stderr
+	if trayMode || !(*once || *jsonOut || *follow || *accessible || *clean || cleanMode || snapshotMode || bundleMode || statusMode || switchMode) {
+		logFallback = io.Discard
+	}
+	closeLog, err := logging.Setup(*logLevel, *logFile, logFallback)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer closeLog()
+
+	if *pprofAddr != "" {
+		if err := servePprof(*pprofAddr); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	session.SetTimeFormat(session.TimeFormat{
+		Clock24:   cfg.Time.Clock == "24h",
+		Location:  cfg.Time.Location,
+		LongUnits: cfg.Time.Durations == "long",
+	})
+	if *theme == "" {
+		*theme = cfg.Theme
+	}
+	if err := monitor.SetTheme(*theme); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// Hooks write to the first directory; the others are only read.
 	dir := session.Dir()
+	dirs := append(session.Dirs(), cfg.SessionsDirs...)
+	// Without hooks there are no session files, so fall back to
+	// approximating sessions from Claude Code's own transcripts.
+	var transcriptDir string
+	if _, err := os.Stat(dir); *transcripts || os.IsNotExist(err) {
+		transcriptDir = transcript.Dir()
+	}
+
+	if *project != "" {
+		abs, err := filepath.Abs(*project)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		*project = abs
+	}
 
 	if *clean {
 		removed, err := session.CleanAll(dir)
@@ -38,24 +205,317 @@ func main() {
 		return
 	}
 
-	if *once {
-		sessions, err := session.LoadAll(dir)
+	if bundleMode {
+		path := flag.Arg(0)
+		if path == "" {
+			path = "ccmonitor-debug-" + time.Now().Format("20060102-150405") + ".tar.gz"
+		}
+		err := writeBundle(path, bundle.Options{
+			Dirs:       dirs,
+			ConfigPath: *configPath,
+			LogFile:    *logFile,
+			Aliases:    cfg.Aliases,
+			Now:        time.Now(),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s; prompts and other session text are redacted, but check it before attaching it to an issue\n", path)
+		return
+	}
+
+	var statuses []string
+	if *status != "" {
+		statuses = strings.Split(*status, ",")
+	}
+
+	if cleanMode {
+		err := cleanSessions(dir, monitor.CleanOptions{
+			Statuses:   statuses,
+			Project:    *project,
+			StallAfter: *stallAfter,
+			OlderThan:  *olderThan,
+			DryRun:     *dryRun,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if (statusMode || switchMode) && flag.NArg() != 1 {
+		command := "status"
+		if switchMode {
+			command = "switch"
+		}
+		fmt.Fprintf(os.Stderr, "Usage: ccmonitor %s <session ID prefix, project name or directory>\n", command)
+		os.Exit(monitor.ExitFailed)
+	}
+
+	if *once || *jsonOut || statusMode || switchMode {
+		var sessions []session.Session
+		switch {
+		case *demoMode:
+			sessions = demo.Sessions(time.Now())
+		case transcriptDir != "":
+			sessions, err = transcript.LoadAll(transcriptDir, time.Now())
+		default:
+			sessions, err = session.LoadAll(dirs...)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		if transcriptDir == "" && !*demoMode {
+			sessions = append(sessions, monitor.FindUntracked(sessions)...)
+		}
+		sessions = session.FilterProject(sessions, *project)
+		sessions = session.FilterIgnored(sessions, cfg.Ignore)
 		monitor.CheckPIDLiveness(sessions)
-		width := 80
-		if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
-			width = w
+		monitor.MarkStalled(sessions, *stallAfter, time.Now())
+		if switchMode {
+			s, ok := monitor.FindSession(sessions, sessionQuery(flag.Arg(0)), cfg.Aliases)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "No session %q\n", flag.Arg(0))
+				os.Exit(monitor.ExitNoSession)
+			}
+			if err := switcher.Switch(s); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(monitor.ExitFailed)
+			}
+			return
+		}
+		if statusMode {
+			line, code := monitor.SessionStatus(sessions, sessionQuery(flag.Arg(0)), cfg.Aliases)
+			out := os.Stdout
+			if code == monitor.ExitNoSession {
+				out = os.Stderr
+			}
+			fmt.Fprintln(out, line)
+			os.Exit(code)
+		}
+		if err := monitor.CheckStatuses(statuses); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		sessions = monitor.FilterSnapshot(sessions, statuses, *since, time.Now())
+		if *jsonOut {
+			data, err := monitor.RenderJSON(sessions, time.Now())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		} else if *accessible {
+			fmt.Println(monitor.RenderAccessible(sessions, time.Now()))
+		} else {
+			fmt.Println(monitor.RenderOnce(sessions, renderWidth(*width), *debug, cfg.Aliases))
+		}
+		if *waitingExit != 0 && slices.ContainsFunc(sessions, func(s session.Session) bool { return s.Status == session.StatusWaiting }) {
+			os.Exit(*waitingExit)
 		}
-		fmt.Println(monitor.RenderOnce(sessions, width, *debug))
 		return
 	}
 
-	p := tea.NewProgram(monitor.New(dir, *debug), tea.WithAltScreen(), tea.WithMouseAllMotion())
-	if _, err := p.Run(); err != nil {
+	if trayMode {
+		session.MigrateLegacy()
+		err := tray.Run(dirs, tray.Options{
+			Project:     *project,
+			Ignore:      cfg.Ignore,
+			StallAfter:  *stallAfter,
+			Transcripts: transcriptDir,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *follow || *accessible {
+		run := monitor.Follow
+		if *accessible {
+			run = monitor.Accessible
+		}
+		err := run(os.Stdout, dirs, monitor.FollowOptions{
+			Project:     *project,
+			Ignore:      cfg.Ignore,
+			StallAfter:  *stallAfter,
+			Terminal:    term.IsTerminal(int(os.Stdout.Fd())),
+			Transcripts: transcriptDir,
+			Demo:        *demoMode,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	opts := monitor.Options{
+		Debug:       *debug,
+		Project:     *project,
+		LaunchCmd:   *launchCmd,
+		StallAfter:  *stallAfter,
+		StallBell:   *stallBell,
+		Collapse:    *collapseAfter,
+		Columns:     cfg.Columns,
+		Keys:        cfg.Keys,
+		Actions:     cfg.Actions,
+		EditorCmd:   cfg.Editor,
+		Aliases:     cfg.Aliases,
+		Ignore:      cfg.Ignore,
+		MaxRows:     cfg.MaxRows,
+		Transcripts: transcriptDir,
+		Demo:        *demoMode,
+	}
+
+	if snapshotMode {
+		if err := snapshot(dirs, opts, renderWidth(*width), *plain, flag.Arg(0)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	opts.TmuxBorder = *tmuxBorder
+	opts.CleanExited = *cleanExited
+	session.MigrateLegacy()
+	m, err := monitor.New(dirs, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseAllMotion())
+	_, err = p.Run()
+	m.Close()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// runHook runs one of the subcommands Claude Code invokes and exits non-zero
+// when it fails. They take no flags, so they log as $CCMONITOR_LOG_LEVEL and
+// $CCMONITOR_LOG_FILE say; a broken log setup never fails the hook.
+func runHook(name string, fn func() error) {
+	closeLog, err := logging.Setup(logging.EnvLevel(), logging.EnvFile(), os.Stderr)
+	if err != nil {
+		closeLog = func() error { return nil }
+	}
+	err = fn()
+	if err != nil {
+		slog.Error("ccmonitor "+name+" failed", "err", err)
+	}
+	closeLog()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// servePprof serves the profiling endpoints under /debug/pprof/ on addr in
+// the background. Only failing to listen is an error.
+func servePprof(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("pprof: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			slog.Error("pprof server stopped", "err", err)
+		}
+	}()
+	slog.Info("serving pprof", "addr", ln.Addr().String())
+	return nil
+}
+
+// renderWidth returns width, or else the terminal's width, or 80 when
+// stdout isn't a terminal.
+func renderWidth(width int) int {
+	if width > 0 {
+		return width
+	}
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	return 80
+}
+
+// snapshot writes the monitor's full view to path, or to stdout when path is
+// empty. Colors are kept even when not writing to a terminal, unless plain.
+func snapshot(dirs []string, opts monitor.Options, width int, plain bool, path string) error {
+	m, err := monitor.New(dirs, opts)
+	if err != nil {
+		return err
+	}
+	lipgloss.SetColorProfile(termenv.ANSI)
+	out := m.Snapshot(width) + "\n"
+	if plain {
+		out = ansi.Strip(out)
+	}
+	if path == "" {
+		_, err = os.Stdout.WriteString(out)
+		return err
+	}
+	return os.WriteFile(path, []byte(out), 0644)
+}
+
+// cleanSessions removes the session files opts selects from dir and lists
+// them, or only lists them for a dry run.
+func cleanSessions(dir string, opts monitor.CleanOptions) error {
+	cleaned, err := monitor.Clean(dir, opts)
+	if err != nil {
+		return err
+	}
+	verb := "Removed"
+	if opts.DryRun {
+		verb = "Would remove"
+	}
+	for _, s := range cleaned {
+		fmt.Printf("  %s  %-8s  %s\n", s.SessionID, s.Status, s.Project)
+	}
+	fmt.Printf("%s %d session file(s) from %s\n", verb, len(cleaned), dir)
+	return nil
+}
+
+// writeBundle writes the debug bundle to path.
+func writeBundle(path string, opts bundle.Options) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Write(f, opts); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// sessionQuery turns the argument of "ccmonitor status" and "switch" into a
+// query for monitor.FindSession. A path, like ".", names a project
+// directory; a bare word a project name, even where a directory has it.
+func sessionQuery(arg string) string {
+	if arg == "." || arg == ".." || strings.ContainsRune(arg, filepath.Separator) || strings.ContainsRune(arg, '/') {
+		if abs, err := filepath.Abs(arg); err == nil {
+			return abs
+		}
+	}
+	return arg
+}
+
+// launchCmdDefault returns $CCMONITOR_LAUNCH_CMD, or plain "claude".
+func launchCmdDefault() string {
+	if cmd := os.Getenv("CCMONITOR_LAUNCH_CMD"); cmd != "" {
+		return cmd
+	}
+	return switcher.DefaultLaunchCommand
+}
@@ -0,0 +1,203 @@
+// Package bundle writes the bug-report bundle of "ccmonitor debug-bundle": a
+// gzipped tarball with what's needed to diagnose a problem report, so users
+// don't have to describe their setup. Everything a user typed or that
+// Claude wrote about their code is redacted from the session files and the
+// rendered view before they go in.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/martinwickman/ccmonitor/internal/monitor"
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// maxLog is how much of the end of the log file goes into the bundle.
+const maxLog = 1 << 20
+
+// Options says what to collect.
+type Options struct {
+	Dirs       []string          // sessions directories
+	ConfigPath string            // config file, included when it exists
+	LogFile    string            // log file, whose end is included when set
+	Aliases    map[string]string // for the rendered view
+	Now        time.Time
+}
+
+// Write writes the bundle to w.
+func Write(w io.Writer, opts Options) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	b := &builder{tw: tw, now: opts.Now}
+
+	var sessions []session.Session
+	var files strings.Builder
+	for i, dir := range opts.Dirs {
+		fmt.Fprintf(&files, "%s\n", dir)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			fmt.Fprintf(&files, "  %v\n", err)
+			continue
+		}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil || e.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			line := fmt.Sprintf("  %-44s %8d  %s", e.Name(), info.Size(), info.ModTime().UTC().Format(time.RFC3339))
+			if filepath.Ext(e.Name()) == ".json" {
+				s, err := session.LoadFile(path)
+				if err != nil {
+					line += "  unreadable: " + err.Error()
+				} else {
+					r := redact(*s)
+					sessions = append(sessions, r)
+					b.json(fmt.Sprintf("sessions/%d/%s", i, e.Name()), r)
+				}
+			}
+			files.WriteString(line + "\n")
+		}
+	}
+	b.file("files.txt", files.String())
+	b.file("info.txt", info(opts))
+
+	if data, err := os.ReadFile(opts.ConfigPath); err == nil {
+		b.file("config.json", string(data))
+	}
+	if opts.LogFile != "" {
+		if data, err := tail(opts.LogFile, maxLog); err == nil {
+			b.file("ccmonitor.log", data)
+		}
+	}
+
+	monitor.CheckPIDLiveness(sessions)
+	sessions = session.SortByAttention(sessions)
+	b.file("view.txt", ansi.Strip(monitor.RenderOnce(sessions, 120, true, opts.Aliases))+"\n")
+
+	if b.err != nil {
+		return b.err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// builder adds files to the tarball, keeping the first error.
+type builder struct {
+	tw  *tar.Writer
+	now time.Time
+	err error
+}
+
+func (b *builder) file(name, content string) {
+	if b.err != nil {
+		return
+	}
+	hdr := &tar.Header{Name: "ccmonitor-debug/" + name, Mode: 0644, Size: int64(len(content)), ModTime: b.now}
+	if b.err = b.tw.WriteHeader(hdr); b.err == nil {
+		_, b.err = io.WriteString(b.tw, content)
+	}
+}
+
+func (b *builder) json(name string, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		b.err = err
+		return
+	}
+	b.file(name, string(data)+"\n")
+}
+
+// redact replaces the free text of a session, its prompt, notes and what
+// Claude is doing, with its length. IDs, statuses, times, paths and
+// terminals are kept, since they are what bugs are usually about.
+func redact(s session.Session) session.Session {
+	for _, f := range []*string{&s.Detail, &s.LastPrompt, &s.Summary, &s.Note, &s.LastError, &s.WaitingCommand} {
+		*f = redacted(*f)
+	}
+	subagents := make([]session.Subagent, len(s.Subagents))
+	for i, a := range s.Subagents {
+		a.Description = redacted(a.Description)
+		subagents[i] = a
+	}
+	if s.Subagents != nil {
+		s.Subagents = subagents
+	}
+	return s
+}
+
+func redacted(text string) string {
+	if text == "" {
+		return ""
+	}
+	return fmt.Sprintf("[redacted, %d chars]", len([]rune(text)))
+}
+
+// info describes the build and the environment ccmonitor runs in.
+func info(opts Options) string {
+	var b strings.Builder
+	version, revision := "unknown", ""
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		version = bi.Main.Version
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" {
+				revision = s.Value
+			}
+		}
+	}
+	fmt.Fprintf(&b, "ccmonitor %s %s\n", version, revision)
+	fmt.Fprintf(&b, "%s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "created %s\n\n", opts.Now.UTC().Format(time.RFC3339))
+
+	// Which of these are set says which terminal backends are active.
+	var env []string
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		switch {
+		case strings.HasPrefix(k, "CCMONITOR_"), k == "TERM", k == "TERM_PROGRAM", k == "WSL_DISTRO_NAME":
+			env = append(env, k+"="+v)
+		case k == "TMUX", k == "TMUX_PANE", k == "WT_SESSION":
+			env = append(env, k+" is set")
+		}
+	}
+	sort.Strings(env)
+	for _, e := range env {
+		b.WriteString(e + "\n")
+	}
+	return b.String()
+}
+
+// tail returns the last n bytes of the file at path.
+func tail(path string, n int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if st.Size() > n {
+		if _, err := f.Seek(-n, io.SeekEnd); err != nil {
+			return "", err
+		}
+	}
+	data, err := io.ReadAll(f)
+	return string(data), err
+}
@@ -0,0 +1,61 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "abc.json"), []byte(`{"session_id":"abc","project":"/home/user/api","status":"working","detail":"Bash: deploy secret-host","last_prompt":"the secret plan"}`), 0644)
+	os.WriteFile(filepath.Join(dir, "abc.note"), []byte("secret note\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "bad.json"), []byte("{"), 0644)
+	config := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(config, []byte(`{"theme": "high-contrast"}`), 0644)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, Options{Dirs: []string{dir}, ConfigPath: config, Now: time.Now()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("not gzipped: %v", err)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading tarball: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		files[strings.TrimPrefix(hdr.Name, "ccmonitor-debug/")] = string(data)
+	}
+
+	for _, name := range []string{"sessions/0/abc.json", "files.txt", "info.txt", "config.json", "view.txt"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("bundle is missing %s", name)
+		}
+	}
+	for name, content := range files {
+		if strings.Contains(content, "secret") {
+			t.Errorf("%s leaks session text: %q", name, content)
+		}
+	}
+	if !strings.Contains(files["sessions/0/abc.json"], `"last_prompt": "[redacted, 15 chars]"`) {
+		t.Errorf("session file = %s, want the prompt redacted", files["sessions/0/abc.json"])
+	}
+	if !strings.Contains(files["files.txt"], "bad.json") || !strings.Contains(files["files.txt"], "unreadable") {
+		t.Errorf("files.txt = %q, want the unreadable file listed", files["files.txt"])
+	}
+}
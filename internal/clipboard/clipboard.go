@@ -0,0 +1,33 @@
+// Package clipboard copies text to the system clipboard.
+package clipboard
+
+import (
+	"io"
+	"os"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// Copy puts text on the system clipboard. It uses the platform tools first
+// (pbcopy, xclip/xsel/wl-copy, the Windows API). When none are available —
+// typical over SSH or in WSL without X — it falls back to an OSC 52 escape
+// sequence, which most terminals (including WT and tmux with set-clipboard)
+// turn into a clipboard write.
+func Copy(text string) error {
+	if err := clipboard.WriteAll(text); err == nil {
+		return nil
+	}
+	return copyOSC52(os.Stdout, text)
+}
+
+// copyOSC52 writes text as an OSC 52 sequence, wrapped for tmux when running
+// inside it so the sequence reaches the outer terminal.
+func copyOSC52(w io.Writer, text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	}
+	_, err := seq.WriteTo(w)
+	return err
+}
@@ -0,0 +1,31 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestCopyOSC52(t *testing.T) {
+	t.Run("text should be base64-encoded in an OSC 52 sequence", func(t *testing.T) {
+		t.Setenv("TMUX", "")
+		var buf bytes.Buffer
+		if err := copyOSC52(&buf, "fix the bug"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := base64.StdEncoding.EncodeToString([]byte("fix the bug"))
+		if !strings.HasPrefix(buf.String(), "\x1b]52;c;") || !strings.Contains(buf.String(), want) {
+			t.Errorf("unexpected sequence %q", buf.String())
+		}
+	})
+
+	t.Run("inside tmux the sequence should be wrapped for passthrough", func(t *testing.T) {
+		t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+		var buf bytes.Buffer
+		copyOSC52(&buf, "fix the bug")
+		if !strings.HasPrefix(buf.String(), "\x1bPtmux;") {
+			t.Errorf("expected tmux passthrough, got %q", buf.String())
+		}
+	})
+}
@@ -0,0 +1,168 @@
+// Package config loads the optional user configuration file.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Config is the contents of config.json. Every field is optional; a missing
+// file is the same as an empty one.
+type Config struct {
+	Columns []Column `json:"columns"`
+	// Keys rebinds built-in actions: action name → keys, replacing that
+	// action's default keys (e.g. "quit": ["x", "ctrl+c"]).
+	Keys    map[string][]string `json:"keys"`
+	Actions []Action            `json:"actions"`
+	// Editor is the shell command that opens a project, run in the project
+	// directory with the CCMONITOR_* variables set (e.g. "code .").
+	Editor string `json:"editor"`
+	// SessionsDirs are more sessions directories the monitor reads, after
+	// the ones from CCMONITOR_SESSIONS_DIR or the default. Hooks never
+	// write to them.
+	SessionsDirs []string `json:"sessions_dirs"`
+	// Aliases maps a project directory, or a path.Match pattern over
+	// directories, to the name shown for it (e.g. "~/src/acme-api" → "API").
+	Aliases map[string]string `json:"aliases"`
+	// Ignore hides sessions whose project directory, or a directory above
+	// it, matches one of these directories or path.Match patterns.
+	Ignore []string `json:"ignore"`
+	// MaxRows is how many sessions a project box shows before folding the
+	// rest into an "… and N more" line (0 = the default, negative = all).
+	MaxRows int `json:"max_rows"`
+	// Theme is a built-in color theme: "default" or "high-contrast".
+	Theme string `json:"theme"`
+	Time  Time   `json:"time"`
+}
+
+// Time is how times are shown.
+type Time struct {
+	Clock     string         `json:"clock"`     // "12h" (default) or "24h"
+	Timezone  string         `json:"timezone"`  // IANA name such as "UTC" (default: local time)
+	Durations string         `json:"durations"` // "short" ("5m ago", default) or "long" ("5 minutes ago")
+	Location  *time.Location `json:"-"`         // Timezone, loaded
+}
+
+// Action is a custom keybinding that runs Command through the shell for the
+// session under the mouse, with its fields in CCMONITOR_* environment
+// variables (the same as command columns).
+type Action struct {
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+// Column is a user-defined extra column. Exactly one of Template and Command
+// is set. Template is a Go text/template over session.Session, evaluated on
+// every render. Command is run through the shell with the session's fields in
+// CCMONITOR_* environment variables; its first line of output is shown and
+// cached for Every.
+type Column struct {
+	Name     string        `json:"name"`
+	Template string        `json:"template,omitempty"`
+	Command  string        `json:"command,omitempty"`
+	Every    time.Duration `json:"-"`
+	RawEvery string        `json:"every,omitempty"` // e.g. "30s"; default DefaultEvery
+}
+
+// DefaultEvery is how long a command column's output is reused.
+const DefaultEvery = 10 * time.Second
+
+// Path returns the config file location, respecting CCMONITOR_CONFIG:
+// %LOCALAPPDATA%\ccmonitor\config.json on Windows, ~/.ccmonitor/config.json
+// elsewhere.
+func Path() string {
+	if p := os.Getenv("CCMONITOR_CONFIG"); p != "" {
+		return p
+	}
+	if runtime.GOOS == "windows" {
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			return filepath.Join(local, "ccmonitor", "config.json")
+		}
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ccmonitor", "config.json")
+}
+
+// Load reads and validates the config file at path. A missing file yields an
+// empty Config.
+func Load(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for i := range c.Columns {
+		if err := c.Columns[i].validate(); err != nil {
+			return c, fmt.Errorf("%s: column %d: %w", path, i+1, err)
+		}
+	}
+	for i, a := range c.Actions {
+		if a.Key == "" || a.Command == "" {
+			return c, fmt.Errorf("%s: action %d: key and command are required", path, i+1)
+		}
+		if a.Name == "" {
+			c.Actions[i].Name = a.Command
+		}
+	}
+	for dir, name := range c.Aliases {
+		if _, err := filepath.Match(strings.ReplaceAll(dir, "\\", "/"), ""); err != nil || name == "" {
+			return c, fmt.Errorf("%s: alias %q: want a directory or pattern and a non-empty name", path, dir)
+		}
+	}
+	for _, dir := range c.Ignore {
+		if _, err := filepath.Match(strings.ReplaceAll(dir, "\\", "/"), ""); err != nil {
+			return c, fmt.Errorf("%s: ignore %q: %w", path, dir, err)
+		}
+	}
+	if err := c.Time.validate(); err != nil {
+		return c, fmt.Errorf("%s: time: %w", path, err)
+	}
+	return c, nil
+}
+
+func (t *Time) validate() error {
+	if t.Clock != "" && t.Clock != "12h" && t.Clock != "24h" {
+		return fmt.Errorf("clock %q: want 12h or 24h", t.Clock)
+	}
+	if t.Durations != "" && t.Durations != "short" && t.Durations != "long" {
+		return fmt.Errorf("durations %q: want short or long", t.Durations)
+	}
+	if t.Timezone != "" {
+		loc, err := time.LoadLocation(t.Timezone)
+		if err != nil {
+			return fmt.Errorf("timezone %q: %w", t.Timezone, err)
+		}
+		t.Location = loc
+	}
+	return nil
+}
+
+func (col *Column) validate() error {
+	if col.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if (col.Template == "") == (col.Command == "") {
+		return fmt.Errorf("%s: set exactly one of template and command", col.Name)
+	}
+	col.Every = DefaultEvery
+	if col.RawEvery != "" {
+		d, err := time.ParseDuration(col.RawEvery)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%s: invalid every %q", col.Name, col.RawEvery)
+		}
+		col.Every = d
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("missing file should give an empty config", func(t *testing.T) {
+		c, err := Load(filepath.Join(t.TempDir(), "nope.json"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(c.Columns) != 0 {
+			t.Errorf("got %d columns, want 0", len(c.Columns))
+		}
+	})
+
+	t.Run("columns should be parsed with their refresh interval", func(t *testing.T) {
+		path := writeConfig(t, `{"columns":[
+			{"name":"branch","template":"{{.Git.Branch}}"},
+			{"name":"container","command":"docker ps -q","every":"30s"}
+		]}`)
+		c, err := Load(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(c.Columns) != 2 {
+			t.Fatalf("got %d columns, want 2", len(c.Columns))
+		}
+		if c.Columns[0].Every != DefaultEvery {
+			t.Errorf("default every = %v, want %v", c.Columns[0].Every, DefaultEvery)
+		}
+		if c.Columns[1].Every != 30*time.Second {
+			t.Errorf("every = %v, want 30s", c.Columns[1].Every)
+		}
+	})
+
+	t.Run("invalid columns should be rejected", func(t *testing.T) {
+		for _, content := range []string{
+			`{"columns":[{"template":"x"}]}`,
+			`{"columns":[{"name":"a"}]}`,
+			`{"columns":[{"name":"a","template":"x","command":"y"}]}`,
+			`{"columns":[{"name":"a","command":"y","every":"soon"}]}`,
+			`{"columns":`,
+		} {
+			if _, err := Load(writeConfig(t, content)); err == nil {
+				t.Errorf("expected error for %s", content)
+			}
+		}
+	})
+
+	t.Run("actions should default their name to the command", func(t *testing.T) {
+		c, err := Load(writeConfig(t, `{"keys":{"quit":["x"]},"actions":[{"key":"o","command":"code ."}]}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := c.Keys["quit"]; len(got) != 1 || got[0] != "x" {
+			t.Errorf("quit keys = %v, want [x]", got)
+		}
+		if len(c.Actions) != 1 || c.Actions[0].Name != "code ." {
+			t.Errorf("actions = %+v, want one named %q", c.Actions, "code .")
+		}
+	})
+
+	t.Run("actions without key or command should be rejected", func(t *testing.T) {
+		for _, content := range []string{
+			`{"actions":[{"command":"x"}]}`,
+			`{"actions":[{"key":"o"}]}`,
+		} {
+			if _, err := Load(writeConfig(t, content)); err == nil {
+				t.Errorf("expected error for %s", content)
+			}
+		}
+	})
+
+	t.Run("aliases with a bad pattern or no name should be rejected", func(t *testing.T) {
+		for _, content := range []string{
+			`{"aliases":{"/src/[acme":"API"}}`,
+			`{"aliases":{"/src/acme":""}}`,
+		} {
+			if _, err := Load(writeConfig(t, content)); err == nil {
+				t.Errorf("expected error for %s", content)
+			}
+		}
+	})
+
+	t.Run("time settings should be validated and the zone loaded", func(t *testing.T) {
+		c, err := Load(writeConfig(t, `{"time":{"clock":"24h","timezone":"UTC","durations":"long"}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if c.Time.Location != time.UTC {
+			t.Errorf("location = %v, want UTC", c.Time.Location)
+		}
+		for _, content := range []string{
+			`{"time":{"clock":"13h"}}`,
+			`{"time":{"durations":"medium"}}`,
+			`{"time":{"timezone":"Mars/Olympus_Mons"}}`,
+		} {
+			if _, err := Load(writeConfig(t, content)); err == nil {
+				t.Errorf("expected error for %s", content)
+			}
+		}
+	})
+}
+
+func TestPath(t *testing.T) {
+	t.Run("env var should override the default", func(t *testing.T) {
+		t.Setenv("CCMONITOR_CONFIG", "/etc/ccmonitor.json")
+		if got := Path(); got != "/etc/ccmonitor.json" {
+			t.Errorf("got %q", got)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+// Package demo makes up sessions for "ccmonitor --demo": for screenshots,
+// theme work, and trying the monitor before installing the hooks. Nothing
+// is read from or written to the sessions directory.
+package demo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// step is one stretch of a demo session's life.
+type step struct {
+	status  string
+	detail  string
+	seconds int64
+	// age is how long before the step began its last activity was, for
+	// sessions that have been sitting idle or stuck.
+	age      time.Duration
+	notice   bool // permission prompt
+	subagent string
+	failed   string // last_error
+}
+
+// script is a demo session that loops through its steps.
+type script struct {
+	id, project, repo, branch string
+	agent                     string
+	prompt                    string
+	contextLeft               int
+	compactedAgo              time.Duration // 0 = never compacted
+	cost                      float64       // estimated cost so far; 0 = not counted yet
+	steps                     []step
+}
+
+const root = "/home/demo/src/"
+
+var scripts = []script{
+	{
+		id: "3f1c9a2e-demo-api", project: root + "api", branch: "main",
+		prompt:      "Add rate limiting to the public endpoints",
+		contextLeft: 64,
+		cost:        1.84,
+		steps: []step{
+			{status: session.StatusWorking, detail: "Read internal/http/router.go", seconds: 4},
+			{status: session.StatusWorking, detail: "Edit internal/http/ratelimit.go", seconds: 5},
+			{status: session.StatusWaiting, detail: "Claude needs your permission to use Bash", seconds: 8, notice: true},
+			{status: session.StatusWorking, detail: "Bash: go test ./internal/http/...", seconds: 6},
+			{status: session.StatusIdle, detail: "Finished responding", seconds: 10},
+		},
+	},
+	{
+		id: "8b27d410-demo-api-auth", project: root + "api-auth", repo: root + "api", branch: "feature/oauth",
+		prompt:       "Why does the token refresh fail after an hour?",
+		contextLeft:  18,
+		compactedAgo: 6 * time.Minute,
+		cost:         4.12,
+		steps: []step{
+			{status: session.StatusWorking, detail: "Task: Find token refresh callers", seconds: 9, subagent: "Find token refresh callers"},
+			{status: session.StatusWorking, detail: "Bash: go test ./auth", seconds: 5, failed: "Bash: --- FAIL: TestRefresh (0.02s)"},
+			{status: session.StatusWorking, detail: "Edit auth/refresh.go", seconds: 6},
+			{status: session.StatusIdle, detail: "Finished responding", seconds: 8},
+		},
+	},
+	{
+		id: "c04e77b1-demo-web", project: root + "web", branch: "main",
+		prompt:      "Make the settings page work on mobile",
+		contextLeft: 81,
+		cost:        0.37,
+		steps: []step{
+			{status: session.StatusIdle, detail: "Finished responding", seconds: 12},
+			{status: session.StatusWorking, detail: "Processing prompt...", seconds: 3},
+			{status: session.StatusWorking, detail: "Edit src/pages/Settings.tsx", seconds: 7},
+			{status: session.StatusWaiting, detail: "Which breakpoint should the sidebar collapse at?", seconds: 9},
+		},
+	},
+	{
+		id: "5d9a0e3c-demo-infra", project: root + "infra", branch: "main",
+		prompt: "Upgrade the cluster to the new node pool",
+		steps: []step{
+			{status: session.StatusWorking, detail: "Bash: terraform apply", seconds: 3600, age: 14 * time.Minute},
+		},
+	},
+	{
+		id: "e61b2f88-demo-infra-2", project: root + "infra", branch: "main",
+		prompt: "Write the runbook for the failover drill",
+		steps: []step{
+			{status: session.StatusLimited, detail: "Usage limit reached", seconds: 3600},
+		},
+	},
+	{
+		id: "a7730c5d-demo-docs", project: root + "docs", branch: "main",
+		prompt: "Proofread the getting started guide",
+		steps: []step{
+			{status: session.StatusIdle, detail: "Finished responding", seconds: 3600, age: 47 * time.Minute},
+		},
+	},
+	{
+		id: "aider-demo-cli", project: root + "cli", branch: "main", agent: "aider",
+		prompt: "add a --json flag to the list command",
+		steps: []step{
+			{status: session.StatusWorking, detail: "Editing cmd/list.go", seconds: 8},
+			{status: session.StatusIdle, detail: "Waiting for input", seconds: 8},
+		},
+	},
+}
+
+// Sessions returns the demo sessions as they are at now. Each loops through
+// its steps, so the statuses change every few seconds.
+func Sessions(now time.Time) []session.Session {
+	var sessions []session.Session
+	for i, sc := range scripts {
+		var total int64
+		for _, st := range sc.steps {
+			total += st.seconds
+		}
+		// Offset each session so they don't all change at once.
+		t := (now.Unix() + int64(i)*7) % total
+		var st step
+		var history []string
+		for _, st = range sc.steps {
+			if n := len(history); n == 0 || history[n-1] != st.status {
+				history = append(history, st.status)
+			}
+			if t < st.seconds {
+				break
+			}
+			t -= st.seconds
+		}
+		since := now.Add(-time.Duration(t)*time.Second - st.age)
+
+		repo := sc.repo
+		if repo == "" {
+			repo = sc.project
+		}
+		s := session.Session{
+			SessionID:    sc.id,
+			Agent:        sc.agent,
+			Project:      sc.project,
+			Status:       st.status,
+			Detail:       st.detail,
+			LastPrompt:   sc.prompt,
+			LastActivity: since.UTC().Format(time.RFC3339),
+			Git:          &session.Git{Repo: repo, Worktree: sc.project, Branch: sc.branch},
+			LastError:    st.failed,
+			History:      history,
+		}
+		if st.notice {
+			notif := "permission_prompt"
+			s.NotificationType = &notif
+		}
+		if st.subagent != "" {
+			s.Subagents = []session.Subagent{{ID: "demo-task", Description: st.subagent, Type: "Explore", Started: s.LastActivity}}
+		}
+		if st.status == session.StatusLimited {
+			s.LimitResetsAt = now.Add(83 * time.Minute).UTC().Format(time.RFC3339)
+		}
+		if sc.contextLeft > 0 {
+			left := sc.contextLeft
+			s.ContextLeft = &left
+		}
+		if sc.compactedAgo > 0 {
+			s.Compacted = now.Add(-sc.compactedAgo).UTC().Format(time.RFC3339)
+		}
+		if sc.cost > 0 {
+			// Roughly what a Sonnet session with that cost reads and writes.
+			s.Usage = &session.Usage{
+				InputTokens:         int(sc.cost * 2_000),
+				OutputTokens:        int(sc.cost * 30_000),
+				CacheCreationTokens: int(sc.cost * 90_000),
+				CacheReadTokens:     int(sc.cost * 1_400_000),
+				CostUSD:             sc.cost,
+			}
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// Many returns n sessions made from the demo sessions at now, spread over
+// n/4 projects, for measuring how the monitor copes with lots of sessions.
+func Many(n int, now time.Time) []session.Session {
+	base := Sessions(now)
+	sessions := make([]session.Session, 0, n)
+	for i := range n {
+		s := base[i%len(base)]
+		s.SessionID = fmt.Sprintf("%s-%04d", s.SessionID, i)
+		s.Project = fmt.Sprintf("%s-%d", base[i/4%len(base)].Project, i/4)
+		s.Git = &session.Git{Repo: s.Project, Worktree: s.Project, Branch: "main"}
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
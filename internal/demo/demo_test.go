@@ -0,0 +1,50 @@
+package demo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	now := time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC)
+
+	t.Run("every script should yield a session with a project and status", func(t *testing.T) {
+		sessions := Sessions(now)
+		if len(sessions) != len(scripts) {
+			t.Fatalf("got %d sessions, want %d", len(sessions), len(scripts))
+		}
+		for _, s := range sessions {
+			if s.SessionID == "" || s.Project == "" || s.Status == "" {
+				t.Errorf("incomplete session %+v", s)
+			}
+			if last, err := time.Parse(time.RFC3339, s.LastActivity); err != nil || last.After(now) {
+				t.Errorf("%s: last activity %q should be a time before now", s.SessionID, s.LastActivity)
+			}
+		}
+	})
+
+	t.Run("statuses should change over time", func(t *testing.T) {
+		seen := map[string]bool{}
+		for sec := range 60 {
+			seen[Sessions(now.Add(time.Duration(sec) * time.Second))[0].Status] = true
+		}
+		if len(seen) < 3 {
+			t.Errorf("first session only went through %v in a minute", seen)
+		}
+	})
+}
+
+func TestMany(t *testing.T) {
+	sessions := Many(10, time.Now())
+	if len(sessions) != 10 {
+		t.Fatalf("got %d sessions, want 10", len(sessions))
+	}
+	ids, projects := map[string]bool{}, map[string]bool{}
+	for _, s := range sessions {
+		ids[s.SessionID] = true
+		projects[s.Project] = true
+	}
+	if len(ids) != 10 || len(projects) != 3 {
+		t.Errorf("got %d IDs in %d projects, want 10 in 3", len(ids), len(projects))
+	}
+}
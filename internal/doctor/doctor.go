@@ -0,0 +1,185 @@
+// Package doctor checks the setup ccmonitor depends on, for "ccmonitor
+// doctor": the hooks in Claude Code's settings, the sessions directory, the
+// terminal tools switching uses and the process liveness checks. Each
+// failed check says how to fix it.
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/install"
+	"github.com/martinwickman/ccmonitor/internal/proc"
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// Check is the outcome of one check.
+type Check struct {
+	Name   string
+	OK     bool
+	Detail string
+	Fix    string // what to do about a failed check
+}
+
+// Options says what to check. Getenv and LookPath default to os.Getenv and
+// exec.LookPath.
+type Options struct {
+	SettingsPath string
+	SessionsDir  string
+	Getenv       func(string) string
+	LookPath     func(string) (string, error)
+	Now          time.Time
+}
+
+// Run runs the checks.
+func Run(opts Options) []Check {
+	if opts.Getenv == nil {
+		opts.Getenv = os.Getenv
+	}
+	if opts.LookPath == nil {
+		opts.LookPath = exec.LookPath
+	}
+	checks := []Check{hooks(opts.SettingsPath), onPath(opts.LookPath), sessionsDir(opts.SessionsDir, opts.Now), liveness()}
+	return append(checks, tools(opts.Getenv, opts.LookPath)...)
+}
+
+// Write prints checks to w, with the fix under each failed one, and reports
+// whether they all passed.
+func Write(w io.Writer, checks []Check) bool {
+	ok := true
+	for _, c := range checks {
+		mark := "✓"
+		if !c.OK {
+			mark, ok = "✗", false
+		}
+		fmt.Fprintf(w, "%s %s: %s\n", mark, c.Name, c.Detail)
+		if !c.OK && c.Fix != "" {
+			fmt.Fprintf(w, "    %s\n", c.Fix)
+		}
+	}
+	return ok
+}
+
+func hooks(settingsPath string) Check {
+	c := Check{Name: "hooks"}
+	missing, plugin, err := install.Missing(settingsPath)
+	switch {
+	case err != nil:
+		c.Detail = err.Error()
+		c.Fix = "Fix the JSON in " + settingsPath
+	case plugin:
+		c.OK, c.Detail = true, "registered by the ccmonitor plugin"
+	case len(missing) == 0:
+		c.OK, c.Detail = true, "registered in "+settingsPath
+	case len(missing) == len(install.Events):
+		c.Detail = "not registered in " + settingsPath
+		c.Fix = "Run ccmonitor install, or install the plugin (see the README)"
+	default:
+		c.Detail = "missing for " + strings.Join(missing, ", ") + " in " + settingsPath
+		c.Fix = "Run ccmonitor install to add them; older setups lack the newer events"
+	}
+	return c
+}
+
+// onPath checks the hooks can run ccmonitor: Claude Code runs "ccmonitor
+// hook", not this binary.
+func onPath(lookPath func(string) (string, error)) Check {
+	c := Check{Name: "ccmonitor on PATH"}
+	path, err := lookPath("ccmonitor")
+	if err != nil {
+		c.Detail = "not found"
+		c.Fix = "Put the ccmonitor binary in a directory on $PATH; the hooks run it by name"
+		return c
+	}
+	c.OK, c.Detail = true, path
+	return c
+}
+
+func sessionsDir(dir string, now time.Time) Check {
+	c := Check{Name: "sessions directory"}
+	// Creating it would turn off the monitor's fallback to transcripts.
+	if _, err := os.Stat(dir); err != nil {
+		c.Detail = err.Error()
+		c.Fix = "Register the hooks and start a Claude Code session; the first hook creates it"
+		return c
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		c.Detail = dir + " is not writable: " + err.Error()
+		c.Fix = "Make " + dir + " writable, or point CCMONITOR_SESSIONS_DIR somewhere that is"
+		return c
+	}
+	f.Close()
+	os.Remove(f.Name())
+	c.OK, c.Detail = true, dir+" is writable"
+
+	var latest time.Time
+	session.ForEachSessionFile(dir, func(path string, _ *session.Session) {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	})
+	if latest.IsZero() {
+		c.Detail += "; no session files yet, so no hook has run since the last cleanup"
+	} else {
+		c.Detail += fmt.Sprintf("; a hook last wrote %s ago", now.Sub(latest).Round(time.Second))
+	}
+	return c
+}
+
+// liveness checks ccmonitor can tell a running process from an exited one,
+// which marks sessions exited, on this process.
+func liveness() Check {
+	c := Check{Name: "process liveness"}
+	pid := os.Getpid()
+	start := proc.StartTime(pid)
+	running, err := proc.Running(pid, start)
+	switch {
+	case err != nil:
+		c.Detail = err.Error()
+	case !running:
+		c.Detail = "this process doesn't look like it is running"
+	default:
+		c.OK, c.Detail = true, "works"
+		if start == "" {
+			c.Detail += ", without start times, so a reused PID can keep a dead session alive"
+		}
+		return c
+	}
+	c.Fix = "Sessions won't be marked exited when Claude Code dies; please report this with ccmonitor debug-bundle"
+	return c
+}
+
+// tools checks the programs switching and the tab titles use, failing only
+// for the terminal ccmonitor runs in.
+func tools(getenv func(string) string, lookPath func(string) (string, error)) []Check {
+	inWT := getenv("WT_SESSION") != "" || runtime.GOOS == "windows"
+	var checks []Check
+	for _, t := range []struct {
+		name, use string
+		needed    bool
+	}{
+		{"tmux", "switching to tmux panes", getenv("TMUX") != ""},
+		{"wt.exe", "opening Windows Terminal tabs", inWT},
+		{"powershell.exe", "switching to Windows Terminal tabs", inWT},
+	} {
+		c := Check{Name: t.name, OK: true}
+		path, err := lookPath(t.name)
+		switch {
+		case err == nil:
+			c.Detail = path
+		case t.needed:
+			c.OK, c.Detail = false, "not found, but needed for "+t.use
+			c.Fix = "Make sure " + t.name + " is on $PATH"
+		default:
+			c.Detail = "not found; only needed for " + t.use
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
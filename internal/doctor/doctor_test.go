@@ -0,0 +1,70 @@
+package doctor
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	sessions := filepath.Join(dir, "sessions")
+	os.Mkdir(sessions, 0755)
+	settings := filepath.Join(dir, "settings.json")
+	os.WriteFile(settings, []byte(`{"enabledPlugins": {"ccmonitor@cc-plugins": true}}`), 0644)
+	env := map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"}
+	found := map[string]bool{"ccmonitor": true}
+	opts := Options{
+		SettingsPath: settings,
+		SessionsDir:  sessions,
+		Getenv:       func(k string) string { return env[k] },
+		LookPath: func(name string) (string, error) {
+			if found[name] {
+				return "/usr/bin/" + name, nil
+			}
+			return "", errors.New("not found")
+		},
+		Now: time.Now(),
+	}
+	byName := func(checks []Check) map[string]Check {
+		m := map[string]Check{}
+		for _, c := range checks {
+			m[c.Name] = c
+		}
+		return m
+	}
+
+	checks := byName(Run(opts))
+	for _, name := range []string{"hooks", "ccmonitor on PATH", "sessions directory", "process liveness"} {
+		if !checks[name].OK {
+			t.Errorf("%s: %s, want OK", name, checks[name].Detail)
+		}
+	}
+	if c := checks["tmux"]; c.OK || c.Fix == "" {
+		t.Errorf("tmux inside tmux should fail with a fix, got %+v", c)
+	}
+	if c := checks["wt.exe"]; !c.OK && runtime.GOOS != "windows" {
+		t.Errorf("wt.exe outside Windows Terminal should not fail, got %+v", c)
+	}
+
+	t.Run("missing hooks and directory should fail with fixes", func(t *testing.T) {
+		opts := opts
+		opts.SettingsPath = filepath.Join(dir, "none.json")
+		opts.SessionsDir = filepath.Join(dir, "none")
+		var buf bytes.Buffer
+		if Write(&buf, Run(opts)) {
+			t.Fatal("checks should fail")
+		}
+		if !strings.Contains(buf.String(), "Run ccmonitor install") {
+			t.Errorf("output should say how to add the hooks:\n%s", buf.String())
+		}
+		if _, err := os.Stat(opts.SessionsDir); !os.IsNotExist(err) {
+			t.Error("the sessions directory should not be created")
+		}
+	})
+}
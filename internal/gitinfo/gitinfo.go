@@ -0,0 +1,102 @@
+// Package gitinfo reads repository, worktree and branch information straight
+// from the .git files on disk, without running git.
+package gitinfo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Info describes the git working tree containing a directory.
+type Info struct {
+	Repo     string // top-level dir of the main working tree, shared by all worktrees
+	Worktree string // top-level dir of the working tree containing the directory
+	Branch   string // current branch, or "" when HEAD is detached
+}
+
+// Lookup walks up from dir to the nearest .git entry and returns the
+// repository it belongs to. Linked worktrees (created by `git worktree add`)
+// report the main working tree as Repo, so all worktrees of one repository
+// share the same Repo. Returns false if dir is not inside a git working tree.
+func Lookup(dir string) (Info, bool) {
+	worktree, gitDir, ok := findGitDir(dir)
+	if !ok {
+		return Info{}, false
+	}
+
+	info := Info{Repo: worktree, Worktree: worktree, Branch: readBranch(gitDir)}
+
+	// Linked worktrees have a commondir file pointing at the main .git dir.
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		common = filepath.Clean(common)
+		if filepath.Base(common) == ".git" {
+			info.Repo = filepath.Dir(common)
+		} else {
+			info.Repo = common // bare repository
+		}
+	}
+	return info, true
+}
+
+// findGitDir walks up from dir looking for a .git directory or file, and
+// returns the working tree top-level and the resolved git dir.
+func findGitDir(dir string) (worktree, gitDir string, ok bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", false
+	}
+	for {
+		candidate := filepath.Join(dir, ".git")
+		fi, err := os.Stat(candidate)
+		if err == nil {
+			if fi.IsDir() {
+				return dir, candidate, true
+			}
+			if gd := readGitFile(candidate); gd != "" {
+				if !filepath.IsAbs(gd) {
+					gd = filepath.Join(dir, gd)
+				}
+				return dir, filepath.Clean(gd), true
+			}
+			return "", "", false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", false
+		}
+		dir = parent
+	}
+}
+
+// readGitFile parses a .git file ("gitdir: <path>") as used by linked
+// worktrees and submodules.
+func readGitFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	line := strings.TrimSpace(string(data))
+	gd, ok := strings.CutPrefix(line, "gitdir:")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(gd)
+}
+
+// readBranch returns the branch HEAD points at, or "" when detached.
+func readBranch(gitDir string) string {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "ref: ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
@@ -0,0 +1,78 @@
+package gitinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mustWrite(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	wt := filepath.Join(root, "repo-feature")
+
+	// Main working tree on main.
+	mustWrite(t, filepath.Join(repo, ".git", "HEAD"), "ref: refs/heads/main\n")
+	// Linked worktree on feature/x.
+	wtGitDir := filepath.Join(repo, ".git", "worktrees", "repo-feature")
+	mustWrite(t, filepath.Join(wtGitDir, "HEAD"), "ref: refs/heads/feature/x\n")
+	mustWrite(t, filepath.Join(wtGitDir, "commondir"), "../..\n")
+	mustWrite(t, filepath.Join(wt, ".git"), "gitdir: "+wtGitDir+"\n")
+	os.MkdirAll(filepath.Join(wt, "src", "pkg"), 0755)
+
+	t.Run("main working tree", func(t *testing.T) {
+		info, ok := Lookup(repo)
+		if !ok {
+			t.Fatal("expected repo to be found")
+		}
+		if info.Repo != repo || info.Worktree != repo || info.Branch != "main" {
+			t.Errorf("got %+v", info)
+		}
+	})
+
+	t.Run("linked worktree subdirectory reports main repo", func(t *testing.T) {
+		info, ok := Lookup(filepath.Join(wt, "src", "pkg"))
+		if !ok {
+			t.Fatal("expected worktree to be found")
+		}
+		if info.Repo != repo {
+			t.Errorf("repo = %q, want %q", info.Repo, repo)
+		}
+		if info.Worktree != wt {
+			t.Errorf("worktree = %q, want %q", info.Worktree, wt)
+		}
+		if info.Branch != "feature/x" {
+			t.Errorf("branch = %q, want %q", info.Branch, "feature/x")
+		}
+	})
+
+	t.Run("detached HEAD has no branch", func(t *testing.T) {
+		detached := filepath.Join(root, "detached")
+		mustWrite(t, filepath.Join(detached, ".git", "HEAD"), "3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a\n")
+		info, ok := Lookup(detached)
+		if !ok {
+			t.Fatal("expected repo to be found")
+		}
+		if info.Branch != "" {
+			t.Errorf("branch = %q, want empty", info.Branch)
+		}
+	})
+
+	t.Run("directory outside any repo", func(t *testing.T) {
+		plain := filepath.Join(root, "plain")
+		os.MkdirAll(plain, 0755)
+		if _, ok := Lookup(plain); ok {
+			t.Error("expected no repo")
+		}
+	})
+}
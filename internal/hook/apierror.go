@@ -0,0 +1,21 @@
+package hook
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// "API Error: 529 Overloaded", "API Error: Connection error.", "Request timed out."
+var apiErrorRe = regexp.MustCompile(`(?i)\bapi error\b|\boverloaded\b|\bconnection error\b|\brequest timed out\b`)
+
+// parseAPIError recognizes a notification message about an API or network
+// failure and returns its first line, or "" for anything else.
+func parseAPIError(message string) string {
+	if !apiErrorRe.MatchString(message) {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return session.Truncate(line, 120)
+}
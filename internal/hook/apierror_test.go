@@ -0,0 +1,24 @@
+package hook
+
+import "testing"
+
+func TestParseAPIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"overloaded", "API Error: 529 Overloaded\n{\"type\":\"error\"}", "API Error: 529 Overloaded"},
+		{"network", "API Error: Connection error.", "API Error: Connection error."},
+		{"timeout", "Request timed out.", "Request timed out."},
+		{"permission prompt", "Claude needs your permission to use Bash", ""},
+		{"waiting for input", "Claude is waiting for your input", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAPIError(tt.message); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
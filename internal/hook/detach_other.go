@@ -0,0 +1,14 @@
+//go:build !windows
+
+package hook
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach makes cmd outlive the hook in a session of its own, so Claude Code
+// doesn't wait for it along with the hook.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,62 @@
+package hook
+
+import (
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// terminalVars are environment variables that identify a terminal emulator,
+// checked in order after $TERM_PROGRAM. Those mapped to "" are reported by
+// their value.
+var terminalVars = []struct{ name, terminal string }{
+	{"WT_SESSION", "Windows Terminal"},
+	{"TERMINAL_EMULATOR", ""}, // JetBrains IDEs
+	{"KITTY_WINDOW_ID", "kitty"},
+	{"ALACRITTY_WINDOW_ID", "Alacritty"},
+	{"KONSOLE_VERSION", "Konsole"},
+	{"VTE_VERSION", "VTE"}, // GNOME Terminal, Tilix, ...
+}
+
+// environment describes the terminal emulator and shell Claude Code runs in,
+// from the environment the hook inherits. The terminal falls back to $TERM
+// when the emulator doesn't identify itself. It returns nil when neither is
+// known.
+func environment(getenv func(string) string) *session.Env {
+	var env session.Env
+	if p := getenv("TERM_PROGRAM"); p != "" {
+		env.Terminal = p
+		if v := getenv("TERM_PROGRAM_VERSION"); v != "" {
+			env.Terminal += " " + v
+		}
+	}
+	for _, tv := range terminalVars {
+		if env.Terminal != "" {
+			break
+		}
+		if v := getenv(tv.name); v != "" {
+			env.Terminal = tv.terminal
+			if env.Terminal == "" {
+				env.Terminal = v
+			}
+		}
+	}
+	if env.Terminal == "" {
+		env.Terminal = getenv("TERM")
+	}
+
+	shell := getenv("SHELL")
+	if shell == "" && runtime.GOOS == "windows" {
+		shell = getenv("ComSpec")
+	}
+	if shell != "" {
+		env.Shell = strings.TrimSuffix(path.Base(strings.ReplaceAll(shell, `\`, "/")), ".exe")
+	}
+
+	if env == (session.Env{}) {
+		return nil
+	}
+	return &env
+}
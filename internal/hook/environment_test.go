@@ -0,0 +1,37 @@
+package hook
+
+import (
+	"testing"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestEnvironment(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want *session.Env
+	}{
+		{"TERM_PROGRAM should name the terminal, with its version",
+			map[string]string{"TERM_PROGRAM": "vscode", "TERM_PROGRAM_VERSION": "1.95.0", "SHELL": "/bin/zsh", "TERM": "xterm-256color"},
+			&session.Env{Terminal: "vscode 1.95.0", Shell: "zsh"}},
+		{"Windows Terminal should be recognized by WT_SESSION",
+			map[string]string{"WT_SESSION": "a1b2", "SHELL": `C:\Program Files\Git\usr\bin\bash.exe`},
+			&session.Env{Terminal: "Windows Terminal", Shell: "bash"}},
+		{"TERMINAL_EMULATOR should be reported by value",
+			map[string]string{"TERMINAL_EMULATOR": "JetBrains-JediTerm"},
+			&session.Env{Terminal: "JetBrains-JediTerm"}},
+		{"an unidentified emulator should fall back to TERM",
+			map[string]string{"TERM": "xterm-256color", "SHELL": "/usr/bin/fish"},
+			&session.Env{Terminal: "xterm-256color", Shell: "fish"}},
+		{"nothing known should be nil", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := environment(func(k string) string { return tt.vars[k] })
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("environment() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,20 +1,28 @@
 package hook
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	ps "github.com/mitchellh/go-ps"
 
+	"github.com/martinwickman/ccmonitor/internal/gitinfo"
+	"github.com/martinwickman/ccmonitor/internal/otlp"
+	"github.com/martinwickman/ccmonitor/internal/proc"
 	"github.com/martinwickman/ccmonitor/internal/session"
 	"github.com/martinwickman/ccmonitor/internal/terminal"
 	"github.com/martinwickman/ccmonitor/internal/tmux"
+	"github.com/martinwickman/ccmonitor/internal/transcript"
 	"github.com/martinwickman/ccmonitor/internal/wt"
 )
 
@@ -25,10 +33,18 @@ const (
 	EventUserPromptSubmit = "UserPromptSubmit"
 	EventPreToolUse       = "PreToolUse"
 	EventPostToolUse      = "PostToolUse"
+	EventPostToolFailure  = "PostToolUseFailure"
 	EventNotification     = "Notification"
 	EventStop             = "Stop"
+	EventPreCompact       = "PreCompact"
+	EventSubagentStart    = "SubagentStart"
+	EventSubagentStop     = "SubagentStop"
 )
 
+// autoCompacting is the detail while Claude compacts the conversation on its
+// own, mid-turn; it keeps working afterwards.
+const autoCompacting = "Auto-compacting conversation..."
+
 // Actionable notification types.
 const (
 	NotifPermissionPrompt  = "permission_prompt"
@@ -46,6 +62,14 @@ type hookInput struct {
 	Message          string          `json:"message"`
 	Title            string          `json:"title"`
 	Source           string          `json:"source"`
+	ToolUseID        string          `json:"tool_use_id"`
+	AgentID          string          `json:"agent_id"`   // SubagentStart, SubagentStop
+	AgentType        string          `json:"agent_type"` // SubagentStart, SubagentStop
+	ToolResponse     json.RawMessage `json:"tool_response"`
+	Error            string          `json:"error"`
+	Trigger          string          `json:"trigger"` // PreCompact: "manual" or "auto"
+	TranscriptPath   string          `json:"transcript_path"`
+	Model            string          `json:"model"` // SessionStart, in newer versions
 }
 
 func mapEvent(event, toolDetail, notifType, title, message string) (status, detail string) {
@@ -56,17 +80,43 @@ func mapEvent(event, toolDetail, notifType, title, message string) (status, deta
 		return session.StatusWorking, "Processing prompt..."
 	case EventPreToolUse:
 		return session.StatusWorking, toolDetail
-	case EventPostToolUse:
+	case EventPostToolUse, EventPostToolFailure:
 		return session.StatusWorking, toolDetail
 	case EventNotification:
 		return session.StatusWaiting, notificationDetail(notifType, title, message)
 	case EventStop:
 		return session.StatusIdle, "Finished responding"
+	case EventPreCompact:
+		return session.StatusCompacting, "Compacting conversation..."
 	default:
 		return "", ""
 	}
 }
 
+// activity returns what a working session is doing after event.
+func activity(event string) string {
+	switch event {
+	case EventUserPromptSubmit, EventPostToolUse, EventPostToolFailure:
+		return session.ActivityThinking
+	case EventPreToolUse:
+		return session.ActivityTool
+	}
+	return ""
+}
+
+// historyLen is how many statuses a session's history keeps.
+const historyLen = 10
+
+// appendHistory adds status to a status history unless it is already the
+// latest, dropping the oldest beyond historyLen.
+func appendHistory(history []string, status string) []string {
+	if n := len(history); n > 0 && history[n-1] == status {
+		return history
+	}
+	history = append(append([]string(nil), history...), status)
+	return history[max(0, len(history)-historyLen):]
+}
+
 func buildToolDetail(event, toolName string, toolInput json.RawMessage) string {
 	if toolName == "" {
 		return ""
@@ -75,6 +125,9 @@ func buildToolDetail(event, toolName string, toolInput json.RawMessage) string {
 	if event == EventPostToolUse {
 		return fmt.Sprintf("Finished %s, continuing...", toolName)
 	}
+	if event == EventPostToolFailure {
+		return fmt.Sprintf("%s failed, continuing...", toolName)
+	}
 
 	var input map[string]any
 	if len(toolInput) > 0 {
@@ -98,10 +151,7 @@ func buildToolDetail(event, toolName string, toolInput json.RawMessage) string {
 
 	switch toolName {
 	case "Bash":
-		cmd := getString("command")
-		if len(cmd) > 80 {
-			cmd = cmd[:80]
-		}
+		cmd := session.Truncate(getString("command"), 80)
 		if cmd != "" {
 			return "Bash: " + cmd
 		}
@@ -135,6 +185,90 @@ func buildToolDetail(event, toolName string, toolInput json.RawMessage) string {
 	}
 }
 
+// updateSubagents tracks running Task tool calls. A PreToolUse for Task adds
+// a subagent and the matching PostToolUse (same tool_use_id) removes it. Stop
+// and new prompts clear the list, since the main agent only gets there once
+// its subagents are done or interrupted. SubagentStart and SubagentStop are
+// handled by updateAgents.
+func updateSubagents(input hookInput, existing []session.Subagent, now time.Time) []session.Subagent {
+	switch input.HookEventName {
+	case EventSessionStart, EventUserPromptSubmit, EventStop:
+		return nil
+	case EventSubagentStart, EventSubagentStop:
+		return updateAgents(input, existing, now)
+	}
+	if input.ToolName != "Task" || input.ToolUseID == "" {
+		return existing
+	}
+
+	var out []session.Subagent
+	for _, a := range existing {
+		if a.ID != input.ToolUseID {
+			out = append(out, a)
+		}
+	}
+	if input.HookEventName == EventPreToolUse {
+		var ti struct {
+			Description  string `json:"description"`
+			SubagentType string `json:"subagent_type"`
+		}
+		json.Unmarshal(input.ToolInput, &ti) // best-effort
+		out = append(out, session.Subagent{
+			ID:          input.ToolUseID,
+			Description: ti.Description,
+			Type:        ti.SubagentType,
+			Started:     now.UTC().Format(time.RFC3339),
+		})
+	}
+	return out
+}
+
+// updateAgents tracks subagents by the agent_id of SubagentStart and
+// SubagentStop. A start is matched to the Task call that launched it, the
+// first of the same type without an agent ID, so a subagent isn't counted
+// twice; one without a Task call is added on its own. A stop removes it.
+func updateAgents(input hookInput, existing []session.Subagent, now time.Time) []session.Subagent {
+	if input.AgentID == "" {
+		return existing
+	}
+	var out []session.Subagent
+	for _, a := range existing {
+		if a.AgentID != input.AgentID {
+			out = append(out, a)
+		}
+	}
+	if input.HookEventName == EventSubagentStop {
+		return out
+	}
+	for i, a := range out {
+		if a.AgentID == "" && (a.Type == "" || a.Type == input.AgentType) {
+			out[i].AgentID = input.AgentID
+			return out
+		}
+	}
+	return append(out, session.Subagent{
+		ID:      input.AgentID,
+		AgentID: input.AgentID,
+		Type:    input.AgentType,
+		Started: now.UTC().Format(time.RFC3339),
+	})
+}
+
+// recordSubagent applies a SubagentStart or SubagentStop to the session file
+// at path. Subagents run within the main agent's turn, so only the running
+// subagents and the activity time change, not the status.
+func recordSubagent(path string, input hookInput) error {
+	s := loadExistingSession(path)
+	if s.SessionID == "" {
+		return nil // no session to attach it to yet
+	}
+	now := time.Now()
+	s.Subagents = updateSubagents(input, s.Subagents, now)
+	s.LastActivity = now.UTC().Format(time.RFC3339)
+	slog.Debug("subagents updated", "event", input.HookEventName, "session", input.SessionID, "running", len(s.Subagents))
+	return writeSessionFile(path, s)
+}
+
 func notificationDetail(notifType, title, message string) string {
 	if title != "" {
 		return title
@@ -161,34 +295,101 @@ func findID(terminals []session.Terminal, backend string) string {
 	return ""
 }
 
+// termBackends are the terminal backends the hook looks up, outer first
+// (replaced in tests).
+var termBackends = []terminal.Backend{wt.Backend{}, tmux.Backend{}}
+
+// lookupBudget is how long the hook waits for terminal lookups before going
+// on without them, since Claude Code waits for the hook on every tool call.
+// A title refresh that takes longer is abandoned and the session keeps its
+// previous summary; the monitor refreshes titles on its own. Finding a tab
+// or pane that takes longer is finished by a background process (see
+// lookUpLater).
+const lookupBudget = 200 * time.Millisecond
+
 // defaultTermInfo returns terminal info based on the current environment.
 // Iterates over available backends (WT first, then tmux). When both are
 // present, tmux title wins since it's more specific (inner pane vs outer tab).
+// The backends are asked concurrently, and waited for at most lookupBudget.
 func defaultTermInfo(hookEvent, sessionID string, existingTerminals []session.Terminal) termInfo {
-	backends := []terminal.Backend{wt.Backend{}, tmux.Backend{}}
-	var ti termInfo
-	for _, b := range backends {
+	type lookup struct {
+		id, title string
+	}
+	type pending struct {
+		name     string
+		id       string // known ID, or "" while discovering
+		result   chan lookup
+		discover bool
+	}
+	var lookups []pending
+	for _, b := range termBackends {
 		if !b.Available() {
 			continue
 		}
-		existingID := findID(existingTerminals, b.Name())
-		var id, title string
-		if hookEvent == EventSessionStart || existingID == "" {
-			id, title = b.Info()
+		p := pending{name: b.Name(), id: findID(existingTerminals, b.Name()), result: make(chan lookup, 1)}
+		if hookEvent == EventSessionStart || p.id == "" {
+			p.discover = true
+			go func() {
+				id, title := b.Info()
+				p.result <- lookup{id, title}
+			}()
 		} else {
-			id = existingID
-			title = b.Title(existingID)
+			go func() { p.result <- lookup{p.id, b.Title(p.id)} }()
 		}
-		if id != "" {
-			ti.terminals = append(ti.terminals, session.Terminal{Backend: b.Name(), ID: id})
+		lookups = append(lookups, p)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupBudget)
+	defer cancel()
+	var ti termInfo
+	for _, p := range lookups {
+		var r lookup
+		select {
+		case r = <-p.result:
+		case <-ctx.Done():
+			// Lookups that finished while an earlier one was awaited
+			// still count.
+			select {
+			case r = <-p.result:
+			default:
+				r = lookup{id: p.id}
+				if p.discover {
+					slog.Debug("terminal lookup moved to the background", "backend", p.name, "after", lookupBudget)
+					lookUpLater(sessionID, p.name)
+				} else {
+					slog.Debug("title lookup abandoned", "backend", p.name, "after", lookupBudget)
+				}
+			}
 		}
-		if title != "" {
-			ti.summary = title // last wins — tmux after WT, so tmux preferred
+		if r.id != "" {
+			ti.terminals = append(ti.terminals, session.Terminal{Backend: p.name, ID: r.id})
+		}
+		if r.title != "" {
+			ti.summary = r.title // last wins — tmux after WT, so tmux preferred
 		}
 	}
 	return ti
 }
 
+// gitInfo returns the git repository info for the project dir, or nil when
+// it is not inside a git working tree.
+func gitInfo(dir string) *session.Git {
+	if dir == "" {
+		return nil
+	}
+	info, ok := gitinfo.Lookup(dir)
+	if !ok {
+		return nil
+	}
+	return &session.Git{Repo: info.Repo, Worktree: info.Worktree, Branch: info.Branch}
+}
+
+// hostname returns the machine name, or "" if it can't be determined.
+func hostname() string {
+	h, _ := os.Hostname()
+	return h
+}
+
 // loadExistingSession reads the existing session file and returns it.
 // Returns a zero-value Session if the file doesn't exist or is corrupt.
 func loadExistingSession(path string) session.Session {
@@ -199,12 +400,78 @@ func loadExistingSession(path string) session.Session {
 	return *s
 }
 
+// writeSessionFile writes s to path. The write is skipped when the file
+// already holds identical content, so repeated events within the same second
+// don't touch the file (and don't wake up the monitor). The file is replaced
+// in one step, through a temporary file next to it, so monitors, sync
+// clients and other hosts sharing the directory never read half of it.
 func writeSessionFile(path string, s session.Session) error {
 	data, err := json.MarshalIndent(s, "", "    ")
 	if err != nil {
 		return fmt.Errorf("marshaling session: %w", err)
 	}
-	return os.WriteFile(path, data, 0644)
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	// The temporary name doesn't end in .json, so nothing reads it.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// coalesceWindow is how recent the previous write must be for a PostToolUse
+// event to be coalesced into it. last_activity only has one-second
+// resolution, so this covers writes from the current or previous second.
+const coalesceWindow = 2 * time.Second
+
+// shouldCoalesce reports whether the write for event can be skipped. During
+// tool-call storms every PostToolUse is immediately followed by the next
+// PreToolUse, so a PostToolUse that arrives shortly after the previous write
+// and doesn't change anything but the detail text is dropped. Status
+// transitions (e.g. waiting → working after a permission prompt) are always
+// written.
+func shouldCoalesce(event string, existing, next session.Session, now time.Time) bool {
+	if event != EventPostToolUse {
+		return false
+	}
+	if existing.SessionID == "" || existing.Status != next.Status {
+		return false
+	}
+	if existing.Activity != next.Activity {
+		return false // the tool finished and Claude is thinking again
+	}
+	if existing.LastPrompt != next.LastPrompt || existing.PID != next.PID {
+		return false
+	}
+	if len(existing.Subagents) != len(next.Subagents) {
+		return false // a subagent finished
+	}
+	if existing.LastError != next.LastError || existing.ToolFailed != next.ToolFailed {
+		return false // an earlier failure was cleared, or this call failed
+	}
+	if existing.Queued != next.Queued {
+		return false
+	}
+	last, err := time.Parse(time.RFC3339, existing.LastActivity)
+	if err != nil {
+		return false
+	}
+	return now.Sub(last) < coalesceWindow
 }
 
 // isShellProcess returns true if the process name is a known shell.
@@ -231,14 +498,14 @@ func findParentPID() int {
 		return 0
 	}
 	for range 20 { // safety limit
-		proc, err := ps.FindProcess(pid)
-		if err != nil || proc == nil {
+		p, err := ps.FindProcess(pid)
+		if err != nil || p == nil {
 			return 0
 		}
-		if !isShellProcess(proc.Executable()) {
+		if !isShellProcess(p.Executable()) {
 			return pid
 		}
-		ppid := proc.PPid()
+		ppid := p.PPid()
 		if ppid <= 0 || ppid == pid {
 			return pid // can't go higher, return what we have
 		}
@@ -252,19 +519,27 @@ func findParentPID() int {
 // a new session (e.g. via /clear) without firing SessionEnd for the old one.
 // Only removes sessions from the same OS, since PIDs are only meaningful within
 // the same OS (a Linux PID 1234 is unrelated to Windows PID 1234).
-func cleanupSamePID(dir, currentSessionID string, currentPID int) {
+// It returns the ID of the most recently active session it removed, which the
+// current session took over from, or "".
+func cleanupSamePID(dir, currentSessionID string, currentPID int) string {
 	if currentPID <= 0 {
-		return
+		return ""
 	}
+	var predecessor, lastActivity string
 	session.ForEachSessionFile(dir, func(path string, s *session.Session) {
 		if s.SessionID != currentSessionID && s.PID == currentPID &&
-			(s.OS == "" || s.OS == runtime.GOOS) {
-			os.Remove(path) // best-effort
+			(s.OS == "" || s.OS == runtime.GOOS) && !s.OnOtherHost(hostname()) {
+			session.Remove(path)
+			if predecessor == "" || s.LastActivity > lastActivity {
+				predecessor, lastActivity = s.SessionID, s.LastActivity
+			}
 		}
 	})
+	return predecessor
 }
 
-// cleanupDead removes session files whose PID is no longer alive.
+// cleanupDead removes session files whose PID is no longer alive, or whose
+// PID has been reused by another process (start time mismatch).
 // Files with PID 0 (legacy or unknown) and corrupt files are skipped.
 // Only checks sessions from the same OS, since go-ps can only see native PIDs
 // (a WSL hook can't check Windows PIDs and vice versa).
@@ -276,16 +551,58 @@ func cleanupDead(dir string) error {
 		if s.OS != "" && s.OS != runtime.GOOS {
 			return // different OS, can't check from here
 		}
-		proc, err := ps.FindProcess(s.PID)
+		if s.OnOtherHost(hostname()) {
+			return // another machine's, through a synced dir
+		}
+		alive, err := pidAlive(*s)
 		if err != nil {
 			return // can't check, leave it
 		}
-		if proc == nil {
-			os.Remove(path) // best-effort
+		if !alive {
+			session.Remove(path)
 		}
 	})
 }
 
+// pidAlive checks a session's PID. Only Claude sessions also require the
+// process to look like Claude Code.
+func pidAlive(s session.Session) (bool, error) {
+	if s.IsClaude() {
+		return proc.Alive(s.PID, s.PIDStart)
+	}
+	return proc.Running(s.PID, s.PIDStart)
+}
+
+// exportEvent sends the hook event as a span when OpenTelemetry export is
+// configured (see package otlp). Failures are only logged; telemetry must
+// never fail the hook.
+func exportEvent(input hookInput, s session.Session) {
+	e := otlp.FromEnv()
+	if e == nil {
+		return
+	}
+	ev := otlp.Event{
+		Name:      input.HookEventName,
+		Tool:      input.ToolName,
+		SessionID: s.SessionID,
+		Project:   s.Project,
+		Status:    s.Status,
+		Detail:    s.Detail,
+		Time:      time.Now(),
+	}
+	switch {
+	case input.HookEventName == EventPostToolFailure && input.Error != "":
+		ev.Error = input.Error
+	case s.ToolFailed:
+		ev.Error = s.LastError
+	case s.Status == session.StatusError:
+		ev.Error = s.Detail
+	}
+	if err := e.Export(ev); err != nil {
+		slog.Warn("exporting span", "endpoint", e.Endpoint, "err", err)
+	}
+}
+
 // Run is the entry point called from main.go. It reads hook input from stdin.
 func Run() error {
 	return run(os.Stdin, defaultTermInfo, findParentPID)
@@ -311,25 +628,56 @@ func run(stdin io.Reader, termInfoFn func(string, string, []session.Terminal) te
 
 	// SessionEnd: cleanup dead sessions, delete own file, return
 	if input.HookEventName == EventSessionEnd {
-		cleanupDead(dir)
-		os.Remove(sessionFile)
+		if err := cleanupDead(dir); err != nil {
+			slog.Warn("cleaning up dead sessions", "err", err)
+		}
+		session.Remove(sessionFile)
+		setTabStatus(session.StatusEnded)
+		exportEvent(input, session.Session{SessionID: input.SessionID, Project: input.CWD, Status: session.StatusEnded})
 		return nil
 	}
 
-	// SessionStart: cleanup dead sessions
-	if input.HookEventName == EventSessionStart {
-		cleanupDead(dir)
+	// SessionStart: cleanup dead sessions (not after a compaction, which
+	// only restarts the conversation)
+	if input.HookEventName == EventSessionStart && input.Source != "compact" {
+		if err := cleanupDead(dir); err != nil {
+			slog.Warn("cleaning up dead sessions", "err", err)
+		}
+	}
+
+	// Usage-limit messages arrive as notifications of any type.
+	var resetAt time.Time
+	limited := false
+	if input.HookEventName == EventNotification {
+		resetAt, limited = parseUsageLimit(input.Message, time.Now())
+	}
+
+	// API and network failures arrive as a notification, or end the turn
+	// with a Stop, leaving the error as the transcript's last entry.
+	var apiError string
+	if input.HookEventName == EventNotification && !limited {
+		apiError = parseAPIError(input.Message)
 	}
 
 	// Skip non-actionable notifications (e.g. idle_prompt after ~60s inactivity).
 	// The session file already has status "idle" from the prior Stop event.
-	if input.HookEventName == EventNotification &&
+	if input.HookEventName == EventNotification && !limited && apiError == "" &&
 		input.NotificationType != NotifPermissionPrompt &&
 		input.NotificationType != NotifElicitationDialog {
 		return nil
 	}
 
-	toolDetail := buildToolDetail(input.HookEventName, input.ToolName, input.ToolInput)
+	if input.HookEventName == EventSubagentStart || input.HookEventName == EventSubagentStop {
+		return recordSubagent(sessionFile, input)
+	}
+
+	// A PostToolUse can report a failure too; it reads the same.
+	detailEvent := input.HookEventName
+	_, toolFailed := toolFailure(input)
+	if toolFailed {
+		detailEvent = EventPostToolFailure
+	}
+	toolDetail := buildToolDetail(detailEvent, input.ToolName, input.ToolInput)
 	status, detail := mapEvent(input.HookEventName, toolDetail, input.NotificationType, input.Title, input.Message)
 	if status == "" {
 		return nil // unknown event, no-op
@@ -338,6 +686,76 @@ func run(stdin io.Reader, termInfoFn func(string, string, []session.Terminal) te
 	// Read existing session for preserved fields (last_prompt, runtime_id)
 	existing := loadExistingSession(sessionFile)
 
+	// The end of a turn reads what the turn wrote to the transcript, once,
+	// from where the last turn's read stopped: its usage, an API error that
+	// ended it and the model that answered.
+	var turn transcript.Turn
+	if input.HookEventName == EventStop {
+		turn = transcript.ReadTurn(input.TranscriptPath, existing.Usage)
+		apiError = turn.APIError
+	}
+
+	// A limited session stays limited through the Stop that ends the turn;
+	// the next prompt (or any tool activity) clears it.
+	var limitResetsAt string
+	switch {
+	case limited:
+		status, detail = session.StatusLimited, "Usage limit reached"
+		limitResetsAt = resetAt.UTC().Format(time.RFC3339)
+	case input.HookEventName == EventStop && existing.Status == session.StatusLimited:
+		status, detail = existing.Status, existing.Detail
+		limitResetsAt = existing.LimitResetsAt
+	case apiError != "":
+		status, detail = session.StatusError, apiError
+	}
+
+	// A permission prompt only names the tool; what it wants to run is in
+	// the detail its PreToolUse left.
+	var waitingTool, waitingCommand string
+	if input.HookEventName == EventNotification && input.NotificationType == NotifPermissionPrompt && !limited {
+		waitingTool, waitingCommand = parsePermission(input.Message, existing.Detail)
+		if waitingTool != "" {
+			detail = permissionDetail(waitingTool, waitingCommand)
+		}
+	}
+
+	// A finished compaction is reported as a SessionStart with source
+	// "compact" under the same session ID; it is not a new session.
+	compacted := existing.Compacted
+	switch {
+	case input.HookEventName == EventPreCompact && input.Trigger == "auto":
+		detail = autoCompacting
+	case input.HookEventName == EventSessionStart && input.Source == "compact":
+		compacted = time.Now().UTC().Format(time.RFC3339)
+		status, detail = session.StatusIdle, "Conversation compacted"
+		if existing.Detail == autoCompacting {
+			status, detail = session.StatusWorking, "Conversation compacted, continuing..."
+		}
+	case input.HookEventName == EventSessionStart:
+		compacted = ""
+	}
+
+	var act string
+	if status == session.StatusWorking {
+		act = activity(input.HookEventName)
+		if input.HookEventName == EventSessionStart {
+			act = session.ActivityThinking // continuing after an auto-compaction
+		}
+	}
+
+	// A new session starts a new history; a compaction doesn't.
+	history := existing.History
+	if input.HookEventName == EventSessionStart && input.Source != "compact" {
+		history = nil
+	}
+
+	// Events that keep the status, such as tool calls while working, keep
+	// the time it started.
+	statusSince := existing.StatusSince
+	if status != existing.Status || history == nil || statusSince == "" {
+		statusSince = time.Now().UTC().Format(time.RFC3339)
+	}
+
 	// Resolve last_prompt
 	var lastPrompt string
 	if input.HookEventName == EventUserPromptSubmit {
@@ -359,6 +777,60 @@ func run(stdin io.Reader, termInfoFn func(string, string, []session.Terminal) te
 		summary = existing.Summary
 	}
 
+	// The status line reports the context; a new session starts empty.
+	var contextLeft *int
+	if input.HookEventName != EventSessionStart {
+		contextLeft = existing.ContextLeft
+	}
+
+	// The environment can't change during a session; sessions started
+	// before it was recorded pick it up on their next event.
+	env := existing.Env
+	if input.HookEventName == EventSessionStart || env == nil {
+		env = environment(os.Getenv)
+	}
+
+	// Messages typed while Claude works are queued, which only the
+	// transcript records. Reading it takes a 256KB tail, so it is only
+	// counted again when a prompt is taken or the turn ends; a new session
+	// has none.
+	queued := existing.Queued
+	switch {
+	case input.HookEventName == EventSessionStart && input.Source != "compact":
+		queued = 0
+	case input.HookEventName == EventUserPromptSubmit, input.HookEventName == EventStop:
+		queued = transcript.Queued(input.TranscriptPath)
+	}
+
+	// Token usage is counted at the end of each turn; a new session starts
+	// from zero.
+	usage := existing.Usage
+	switch {
+	case input.HookEventName == EventSessionStart && input.Source != "compact":
+		usage = nil
+	case input.HookEventName == EventStop:
+		usage = turn.Usage
+	}
+
+	// The model can change with /model; the transcript says which one
+	// answered last.
+	model := existing.Model
+	switch {
+	case input.HookEventName == EventSessionStart && input.Source != "compact":
+		model = input.Model
+	case input.Model != "":
+		model = input.Model
+	case input.HookEventName == EventStop && turn.Model != "":
+		model = turn.Model
+	}
+
+	// Every event carries the transcript path; keep the last one in case an
+	// event comes without it.
+	transcriptPath := input.TranscriptPath
+	if transcriptPath == "" {
+		transcriptPath = existing.TranscriptPath
+	}
+
 	// Build notification type pointer
 	var notifType *string
 	if input.NotificationType != "" {
@@ -366,28 +838,102 @@ func run(stdin io.Reader, termInfoFn func(string, string, []session.Terminal) te
 	}
 
 	// Capture PID: use pidFn on SessionStart, preserve from existing otherwise
-	pid := pidFn()
+	livePID := pidFn()
+	pid := livePID
 	if pid == 0 && input.HookEventName != EventSessionStart {
 		pid = existing.PID
 	}
 
+	// Record the process start time alongside the PID so liveness checks can
+	// detect PID reuse. It is cheap to read, so it is read again whenever the
+	// PID was found this time, which also replaces one recorded by an older
+	// version in another format (ps output on macOS).
+	pidStart := existing.PIDStart
+	if pid != existing.PID || pidStart == "" || pid != 0 && pid == livePID {
+		pidStart = ""
+		if pid > 0 {
+			pidStart = proc.StartTime(pid)
+		}
+	}
+
 	s := session.Session{
 		SessionID:        input.SessionID,
 		Project:          input.CWD,
 		Status:           status,
+		Activity:         act,
 		Detail:           detail,
 		LastPrompt:       lastPrompt,
 		NotificationType: notifType,
 		LastActivity:     time.Now().UTC().Format(time.RFC3339),
+		StatusSince:      statusSince,
 		Terminals:        terminals,
 		Summary:          summary,
 		PID:              pid,
+		PIDStart:         pidStart,
 		OS:               runtime.GOOS,
+		Git:              gitInfo(input.CWD),
+		Env:              env,
+		Host:             hostname(),
+		Subagents:        updateSubagents(input, existing.Subagents, time.Now()),
+		LimitResetsAt:    limitResetsAt,
+		LastError:        updateLastError(input, existing.LastError),
+		ToolFailed:       toolFailed,
+		ContextLeft:      contextLeft,
+		Compacted:        compacted,
+		ResumedFrom:      existing.ResumedFrom,
+		WaitingTool:      waitingTool,
+		WaitingCommand:   waitingCommand,
+		Queued:           queued,
+		History:          appendHistory(history, status),
+		Usage:            usage,
+		Model:            model,
+		TranscriptPath:   transcriptPath,
 	}
 
 	// Remove stale session files from the same PID (handles --continue/--resume
 	// where SessionStart fires with a new ID but events continue under the old ID)
-	cleanupSamePID(dir, input.SessionID, pid)
+	// and remember which one this session continues.
+	if predecessor := cleanupSamePID(dir, input.SessionID, pid); predecessor != "" {
+		s.ResumedFrom = predecessor
+	}
+
+	if s.Status != existing.Status {
+		setTabStatus(s.Status)
+	}
+	exportEvent(input, s)
+
+	if shouldCoalesce(input.HookEventName, existing, s, time.Now()) {
+		slog.Debug("write coalesced", "event", input.HookEventName, "session", input.SessionID)
+		return nil
+	}
+	slog.Debug("session updated", "event", input.HookEventName, "session", input.SessionID, "status", s.Status, "detail", s.Detail)
+	return updateSessionFile(sessionFile, func(current *session.Session) bool {
+		*current = keepConcurrentWrites(s, existing, *current, input.HookEventName)
+		return true
+	})
+}
+
+// keepConcurrentWrites returns s with what other processes wrote to the
+// session file since the hook read it as existing, now that it reads
+// current: the context left from the status line, and the terminals (with
+// their title) background lookups found.
+func keepConcurrentWrites(s, existing, current session.Session, event string) session.Session {
+	if !sameInt(current.ContextLeft, existing.ContextLeft) && event != EventSessionStart {
+		s.ContextLeft = current.ContextLeft
+	}
+	found := false
+	for _, t := range current.Terminals {
+		if !slices.Contains(existing.Terminals, t) && findID(s.Terminals, t.Backend) == "" {
+			s.Terminals = append(s.Terminals, t)
+			found = true
+		}
+	}
+	if found && s.Summary == "" {
+		s.Summary = current.Summary
+	}
+	return s
+}
 
-	return writeSessionFile(sessionFile, s)
+func sameInt(a, b *int) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
 }
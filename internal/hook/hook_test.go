@@ -4,11 +4,16 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/martinwickman/ccmonitor/internal/proc"
+	"github.com/martinwickman/ccmonitor/internal/proc/proctest"
 	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/terminal"
 )
 
 func TestMapEvent(t *testing.T) {
@@ -31,6 +36,7 @@ func TestMapEvent(t *testing.T) {
 		{"Notification no title or message", "Notification", "", "permission_prompt", "", "", "waiting", "Awaiting response"},
 		{"Notification elicitation_dialog", "Notification", "", "elicitation_dialog", "Pick an option", "", "waiting", "Pick an option"},
 		{"Stop", "Stop", "", "", "", "", "idle", "Finished responding"},
+		{"PreCompact", "PreCompact", "", "", "", "", "compacting", "Compacting conversation..."},
 		{"UnknownEvent", "UnknownEvent", "", "", "", "", "", ""},
 	}
 
@@ -77,6 +83,12 @@ func TestBuildToolDetail(t *testing.T) {
 			input: map[string]any{"command": strings.Repeat("x", 100)},
 			want:  "Bash: " + strings.Repeat("x", 80),
 		},
+		{
+			name: "Bash command truncated on rune boundary",
+			event: "PreToolUse", toolName: "Bash",
+			input: map[string]any{"command": "echo " + strings.Repeat("é", 100)},
+			want:  "Bash: echo " + strings.Repeat("é", 75),
+		},
 		{
 			name: "Bash without command",
 			event: "PreToolUse", toolName: "Bash",
@@ -248,6 +260,192 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("working sessions record whether a tool is running", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+
+		for _, tt := range []struct{ input, want string }{
+			{`{"session_id":"s11","cwd":"/tmp","hook_event_name":"UserPromptSubmit","prompt":"fix it"}`, session.ActivityThinking},
+			{`{"session_id":"s11","cwd":"/tmp","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"ls"}}`, session.ActivityTool},
+			{`{"session_id":"s11","cwd":"/tmp","hook_event_name":"PostToolUseFailure","tool_name":"Bash"}`, session.ActivityThinking},
+			{`{"session_id":"s11","cwd":"/tmp","hook_event_name":"Stop"}`, ""},
+		} {
+			if err := run(strings.NewReader(tt.input), stubTermInfo, stubPidFn); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data, _ := os.ReadFile(filepath.Join(dir, "s11.json"))
+			var s session.Session
+			json.Unmarshal(data, &s)
+			if s.Activity != tt.want {
+				t.Errorf("after %s: activity = %q, want %q", tt.input, s.Activity, tt.want)
+			}
+		}
+	})
+
+	t.Run("usage limit notification sets limited status with reset time", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+
+		input := `{"session_id":"s9","cwd":"/tmp","hook_event_name":"Notification","notification_type":"idle_prompt","message":"Claude AI usage limit reached|1773151200"}`
+		if err := run(strings.NewReader(input), stubTermInfo, stubPidFn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		input = `{"session_id":"s9","cwd":"/tmp","hook_event_name":"Stop"}`
+		if err := run(strings.NewReader(input), stubTermInfo, stubPidFn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, _ := os.ReadFile(filepath.Join(dir, "s9.json"))
+		var s session.Session
+		json.Unmarshal(data, &s)
+		if s.Status != session.StatusLimited {
+			t.Errorf("status = %q, want %q (Stop should not clear the limit)", s.Status, session.StatusLimited)
+		}
+		if want := time.Unix(1773151200, 0).UTC().Format(time.RFC3339); s.LimitResetsAt != want {
+			t.Errorf("limit_resets_at = %q, want %q", s.LimitResetsAt, want)
+		}
+
+		input = `{"session_id":"s9","cwd":"/tmp","hook_event_name":"UserPromptSubmit","prompt":"try again"}`
+		run(strings.NewReader(input), stubTermInfo, stubPidFn)
+		data, _ = os.ReadFile(filepath.Join(dir, "s9.json"))
+		s = session.Session{}
+		json.Unmarshal(data, &s)
+		if s.Status != session.StatusWorking || s.LimitResetsAt != "" {
+			t.Errorf("new prompt should clear the limit, got status %q reset %q", s.Status, s.LimitResetsAt)
+		}
+	})
+
+	t.Run("Stop should count the turn's token usage", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		path := filepath.Join(t.TempDir(), "s12.jsonl")
+		os.WriteFile(path, []byte(`{"type":"assistant","message":{"id":"m1","model":"claude-sonnet-4-5","usage":{"input_tokens":1000,"output_tokens":100}}}`+"\n"), 0644)
+
+		for _, input := range []string{
+			`{"session_id":"s12","cwd":"/tmp","hook_event_name":"UserPromptSubmit","prompt":"hi","transcript_path":"` + path + `"}`,
+			`{"session_id":"s12","cwd":"/tmp","hook_event_name":"Stop","transcript_path":"` + path + `"}`,
+			`{"session_id":"s12","cwd":"/tmp","hook_event_name":"UserPromptSubmit","prompt":"again","transcript_path":"` + path + `"}`,
+		} {
+			if err := run(strings.NewReader(input), stubTermInfo, stubPidFn); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		s, _ := session.LoadFile(filepath.Join(dir, "s12.json"))
+		if s.Usage == nil || s.Usage.InputTokens != 1000 || s.Usage.OutputTokens != 100 || s.Usage.CostUSD == 0 {
+			t.Errorf("usage = %+v, want the transcript's tokens kept after the next prompt", s.Usage)
+		}
+		if s.Model != "claude-sonnet-4-5" {
+			t.Errorf("model = %q, want the transcript's", s.Model)
+		}
+		if s.TranscriptPath != path {
+			t.Errorf("transcript_path = %q, want %q", s.TranscriptPath, path)
+		}
+	})
+
+	t.Run("queued messages should only be counted on a prompt or Stop", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		path := filepath.Join(t.TempDir(), "s13.jsonl")
+		enqueue := `{"type":"queue-operation","operation":"enqueue","content":"and the lint"}` + "\n"
+		os.WriteFile(path, []byte(enqueue), 0644)
+
+		send := func(event string) *session.Session {
+			t.Helper()
+			input := `{"session_id":"s13","cwd":"/tmp","hook_event_name":"` + event + `","tool_name":"Bash","transcript_path":"` + path + `"}`
+			if err := run(strings.NewReader(input), stubTermInfo, stubPidFn); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			s, _ := session.LoadFile(filepath.Join(dir, "s13.json"))
+			return s
+		}
+		if s := send("UserPromptSubmit"); s.Queued != 1 {
+			t.Errorf("after UserPromptSubmit: queued = %d, want 1", s.Queued)
+		}
+		f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		f.WriteString(enqueue)
+		f.Close()
+		if s := send("PreToolUse"); s.Queued != 1 {
+			t.Errorf("after PreToolUse: queued = %d, want 1 kept", s.Queued)
+		}
+		if s := send("Stop"); s.Queued != 2 {
+			t.Errorf("after Stop: queued = %d, want 2", s.Queued)
+		}
+	})
+
+	t.Run("a failed tool call should be flagged until the next event", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		path := filepath.Join(dir, "s14.json")
+
+		for _, input := range []string{
+			`{"session_id":"s14","cwd":"/tmp","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"go test"}}`,
+			`{"session_id":"s14","cwd":"/tmp","hook_event_name":"PostToolUse","tool_name":"Bash","tool_response":{"is_error":true,"error":"exit status 1"}}`,
+		} {
+			if err := run(strings.NewReader(input), stubTermInfo, stubPidFn); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		s, _ := session.LoadFile(path)
+		if !s.ToolFailed || s.Detail != "Bash failed, continuing..." || s.LastError != "Bash: exit status 1" {
+			t.Errorf("got tool_failed %v, detail %q, last_error %q, want the failure", s.ToolFailed, s.Detail, s.LastError)
+		}
+
+		run(strings.NewReader(`{"session_id":"s14","cwd":"/tmp","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"go test"}}`), stubTermInfo, stubPidFn)
+		if s, _ := session.LoadFile(path); s.ToolFailed || s.LastError == "" {
+			t.Errorf("got tool_failed %v, last_error %q, want the flag cleared and the error kept", s.ToolFailed, s.LastError)
+		}
+	})
+
+	t.Run("status_since should only move when the status changes", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		path := filepath.Join(dir, "s13.json")
+		since := time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339)
+		writeSessionFile(path, session.Session{SessionID: "s13", Project: "/tmp", Status: session.StatusWorking, StatusSince: since, History: []string{"working"}})
+
+		run(strings.NewReader(`{"session_id":"s13","cwd":"/tmp","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"ls"}}`), stubTermInfo, stubPidFn)
+		if s, _ := session.LoadFile(path); s.StatusSince != since {
+			t.Errorf("status_since = %q after a tool call, want %q kept", s.StatusSince, since)
+		}
+		run(strings.NewReader(`{"session_id":"s13","cwd":"/tmp","hook_event_name":"Stop"}`), stubTermInfo, stubPidFn)
+		if s, _ := session.LoadFile(path); s.StatusSince == since || s.StatusSince == "" {
+			t.Errorf("status_since = %q after Stop, want the time it went idle", s.StatusSince)
+		}
+	})
+
+	t.Run("compaction should be recorded and keep an auto-compacting session working", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+
+		for _, input := range []string{
+			`{"session_id":"s10","cwd":"/tmp","hook_event_name":"PreCompact","trigger":"auto"}`,
+			`{"session_id":"s10","cwd":"/tmp","hook_event_name":"SessionStart","source":"compact"}`,
+		} {
+			if err := run(strings.NewReader(input), stubTermInfo, stubPidFn); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s, _ := session.LoadFile(filepath.Join(dir, "s10.json")); strings.Contains(input, "PreCompact") && s.Status != session.StatusCompacting {
+				t.Errorf("got %s after PreCompact, want compacting", s.Status)
+			}
+		}
+
+		s, _ := session.LoadFile(filepath.Join(dir, "s10.json"))
+		if s.Status != session.StatusWorking || s.Detail != "Conversation compacted, continuing..." {
+			t.Errorf("got %s %q, want working after an auto-compaction", s.Status, s.Detail)
+		}
+		if s.Compacted == "" {
+			t.Error("compacted not set")
+		}
+
+		input := `{"session_id":"s10","cwd":"/tmp","hook_event_name":"SessionStart","source":"resume"}`
+		run(strings.NewReader(input), stubTermInfo, stubPidFn)
+		s, _ = session.LoadFile(filepath.Join(dir, "s10.json"))
+		if s.Compacted != "" {
+			t.Errorf("compacted = %q, want cleared by a new start", s.Compacted)
+		}
+	})
+
 	t.Run("UserPromptSubmit captures prompt", func(t *testing.T) {
 		dir := t.TempDir()
 		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
@@ -296,12 +494,34 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("Stop after an API error sets status error", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+
+		transcriptPath := filepath.Join(dir, "s-err.jsonl")
+		line := `{"type":"assistant","isApiErrorMessage":true,"message":{"content":[{"type":"text","text":"API Error: 529 Overloaded"}]}}`
+		os.WriteFile(transcriptPath, []byte(line+"\n"), 0644)
+
+		input := `{"session_id":"s-err","cwd":"/tmp","hook_event_name":"Stop","transcript_path":` + strconv.Quote(transcriptPath) + `}`
+		if err := run(strings.NewReader(input), stubTermInfo, stubPidFn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		data, _ := os.ReadFile(filepath.Join(dir, "s-err.json"))
+		var s session.Session
+		json.Unmarshal(data, &s)
+		if s.Status != session.StatusError || s.Detail != "API Error: 529 Overloaded" {
+			t.Errorf("got %s %q, want error %q", s.Status, s.Detail, "API Error: 529 Overloaded")
+		}
+	})
+
 	t.Run("SessionEnd deletes session file", func(t *testing.T) {
 		dir := t.TempDir()
 		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
 
-		// Create existing session file
+		// Create existing session file with a note
 		os.WriteFile(filepath.Join(dir, "s4.json"), []byte(`{"session_id":"s4"}`), 0644)
+		os.WriteFile(filepath.Join(dir, "s4.note"), []byte("parked\n"), 0644)
 
 		input := `{"session_id":"s4","cwd":"/tmp","hook_event_name":"SessionEnd"}`
 		err := run(strings.NewReader(input), stubTermInfo, stubPidFn)
@@ -312,6 +532,9 @@ func TestRun(t *testing.T) {
 		if _, err := os.Stat(filepath.Join(dir, "s4.json")); !os.IsNotExist(err) {
 			t.Error("session file should have been deleted")
 		}
+		if _, err := os.Stat(filepath.Join(dir, "s4.note")); !os.IsNotExist(err) {
+			t.Error("note file should have been deleted")
+		}
 	})
 
 	t.Run("SessionStart cleans up dead PID files", func(t *testing.T) {
@@ -417,6 +640,42 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("permission_prompt records the tool and command from the pending tool call", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+
+		for _, input := range []string{
+			`{"session_id":"s-perm2","cwd":"/tmp","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"rm -rf build/"}}`,
+			`{"session_id":"s-perm2","cwd":"/tmp","hook_event_name":"Notification","notification_type":"permission_prompt","message":"Claude needs your permission to use Bash"}`,
+		} {
+			if err := run(strings.NewReader(input), stubTermInfo, stubPidFn); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		data, _ := os.ReadFile(filepath.Join(dir, "s-perm2.json"))
+		var s session.Session
+		json.Unmarshal(data, &s)
+		if s.WaitingTool != "Bash" || s.WaitingCommand != "rm -rf build/" {
+			t.Errorf("waiting = %q, %q; want Bash, rm -rf build/", s.WaitingTool, s.WaitingCommand)
+		}
+		if s.Detail != "Allow Bash: rm -rf build/?" {
+			t.Errorf("detail = %q, want %q", s.Detail, "Allow Bash: rm -rf build/?")
+		}
+
+		// Answering the prompt clears them
+		input := `{"session_id":"s-perm2","cwd":"/tmp","hook_event_name":"PostToolUse","tool_name":"Bash"}`
+		if err := run(strings.NewReader(input), stubTermInfo, stubPidFn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, _ = os.ReadFile(filepath.Join(dir, "s-perm2.json"))
+		s = session.Session{}
+		json.Unmarshal(data, &s)
+		if s.WaitingTool != "" || s.WaitingCommand != "" {
+			t.Errorf("waiting = %q, %q; want them cleared", s.WaitingTool, s.WaitingCommand)
+		}
+	})
+
 	t.Run("unknown event is a no-op", func(t *testing.T) {
 		dir := t.TempDir()
 		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
@@ -688,13 +947,34 @@ func TestCleanupDead(t *testing.T) {
 		}
 	})
 
+	t.Run("keeps files from other machines", func(t *testing.T) {
+		dir := t.TempDir()
+		remote := session.Session{
+			SessionID: "remote1",
+			Project:   "/p",
+			Status:    "working",
+			PID:       99999999,
+			Host:      "some-other-box",
+		}
+		data, _ := json.Marshal(remote)
+		os.WriteFile(filepath.Join(dir, "remote1.json"), data, 0644)
+
+		if err := cleanupDead(dir); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "remote1.json")); err != nil {
+			t.Error("another machine's session file should have been kept")
+		}
+	})
+
 	t.Run("keeps files with alive PIDs", func(t *testing.T) {
 		dir := t.TempDir()
 		alive := session.Session{
 			SessionID: "alive1",
 			Project:   "/p",
 			Status:    "working",
-			PID:       os.Getpid(),
+			PID:       proctest.StartFakeClaude(t),
 		}
 		data, _ := json.Marshal(alive)
 		os.WriteFile(filepath.Join(dir, "alive1.json"), data, 0644)
@@ -708,6 +988,51 @@ func TestCleanupDead(t *testing.T) {
 		}
 	})
 
+	t.Run("removes files whose PID belongs to a non-Claude process", func(t *testing.T) {
+		dir := t.TempDir()
+		other := session.Session{
+			SessionID: "other1",
+			Project:   "/p",
+			Status:    "working",
+			PID:       os.Getpid(),
+		}
+		data, _ := json.Marshal(other)
+		os.WriteFile(filepath.Join(dir, "other1.json"), data, 0644)
+
+		if err := cleanupDead(dir); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "other1.json")); !os.IsNotExist(err) {
+			t.Error("session file whose PID is not Claude Code should have been removed")
+		}
+	})
+
+	t.Run("removes files whose PID was reused by another process", func(t *testing.T) {
+		pid := proctest.StartFakeClaude(t)
+		if proc.StartTime(pid) == "" {
+			t.Skip("start time not supported on this platform")
+		}
+		dir := t.TempDir()
+		reused := session.Session{
+			SessionID: "reused1",
+			Project:   "/p",
+			Status:    "working",
+			PID:       pid,
+			PIDStart:  "1",
+		}
+		data, _ := json.Marshal(reused)
+		os.WriteFile(filepath.Join(dir, "reused1.json"), data, 0644)
+
+		if err := cleanupDead(dir); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "reused1.json")); !os.IsNotExist(err) {
+			t.Error("session file with reused PID should have been removed")
+		}
+	})
+
 	t.Run("keeps files with zero PID", func(t *testing.T) {
 		dir := t.TempDir()
 		noPid := session.Session{
@@ -757,7 +1082,9 @@ func TestCleanupSamePID(t *testing.T) {
 		data, _ := json.Marshal(old)
 		os.WriteFile(filepath.Join(dir, "old-session.json"), data, 0644)
 
-		cleanupSamePID(dir, "new-session", 12345)
+		if got := cleanupSamePID(dir, "new-session", 12345); got != "old-session" {
+			t.Errorf("predecessor = %q, want %q", got, "old-session")
+		}
 
 		if _, err := os.Stat(filepath.Join(dir, "old-session.json")); !os.IsNotExist(err) {
 			t.Error("old session with same PID should have been removed")
@@ -845,6 +1172,21 @@ func TestContinueResumeCleansStaleSessions(t *testing.T) {
 	if s.PID != 42 {
 		t.Errorf("pid = %d, want 42", s.PID)
 	}
+	if s.ResumedFrom != "session-a" {
+		t.Errorf("resumed_from = %q, want %q", s.ResumedFrom, "session-a")
+	}
+
+	// Later events should keep the lineage
+	input = `{"session_id":"session-b","cwd":"/tmp","hook_event_name":"Stop"}`
+	if err := run(strings.NewReader(input), stubTermInfo, pidFn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "session-b.json"))
+	s = session.Session{}
+	json.Unmarshal(data, &s)
+	if s.ResumedFrom != "session-a" {
+		t.Errorf("after Stop resumed_from = %q, want %q", s.ResumedFrom, "session-a")
+	}
 }
 
 func TestSessionStartCleansSamePID(t *testing.T) {
@@ -874,3 +1216,314 @@ func TestSessionStartCleansSamePID(t *testing.T) {
 		t.Error("new session file should have been created")
 	}
 }
+
+func TestShouldCoalesce(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-200 * time.Millisecond).UTC().Format(time.RFC3339)
+	old := now.Add(-5 * time.Second).UTC().Format(time.RFC3339)
+	working := session.Session{SessionID: "s1", Status: "working", Detail: "Bash: ls", LastActivity: recent}
+
+	tests := []struct {
+		name     string
+		event    string
+		existing session.Session
+		next     session.Session
+		want     bool
+	}{
+		{"PostToolUse right after another", "PostToolUse", working, session.Session{SessionID: "s1", Status: "working", Detail: "Finished Bash, continuing..."}, true},
+		{"activity change is written", "PostToolUse", session.Session{SessionID: "s1", Status: "working", Activity: "tool", LastActivity: recent}, session.Session{SessionID: "s1", Status: "working", Activity: "thinking"}, false},
+		{"PreToolUse is never coalesced", "PreToolUse", working, session.Session{SessionID: "s1", Status: "working", Detail: "Edit main.go"}, false},
+		{"status transition is written", "PostToolUse", session.Session{SessionID: "s1", Status: "waiting", LastActivity: recent}, session.Session{SessionID: "s1", Status: "working"}, false},
+		{"previous write too old", "PostToolUse", session.Session{SessionID: "s1", Status: "working", LastActivity: old}, session.Session{SessionID: "s1", Status: "working"}, false},
+		{"no existing session", "PostToolUse", session.Session{}, session.Session{SessionID: "s1", Status: "working"}, false},
+		{"PID changed", "PostToolUse", session.Session{SessionID: "s1", Status: "working", PID: 1, LastActivity: recent}, session.Session{SessionID: "s1", Status: "working", PID: 2}, false},
+		{"subagent finished", "PostToolUse", session.Session{SessionID: "s1", Status: "working", Subagents: []session.Subagent{{ID: "t1"}}, LastActivity: recent}, session.Session{SessionID: "s1", Status: "working"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldCoalesce(tt.event, tt.existing, tt.next, now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateSubagents(t *testing.T) {
+	now := time.Now()
+	running := []session.Subagent{{ID: "toolu_1", Description: "Find usages", Type: "Explore"}}
+
+	t.Run("PreToolUse Task should add a subagent", func(t *testing.T) {
+		input := hookInput{
+			HookEventName: EventPreToolUse,
+			ToolName:      "Task",
+			ToolUseID:     "toolu_2",
+			ToolInput:     json.RawMessage(`{"description":"Review diff","subagent_type":"code-reviewer","prompt":"..."}`),
+		}
+		got := updateSubagents(input, running, now)
+		if len(got) != 2 {
+			t.Fatalf("got %d subagents, want 2", len(got))
+		}
+		if got[1].ID != "toolu_2" || got[1].Description != "Review diff" || got[1].Type != "code-reviewer" {
+			t.Errorf("unexpected subagent %+v", got[1])
+		}
+	})
+
+	t.Run("PostToolUse Task should remove the matching subagent", func(t *testing.T) {
+		input := hookInput{HookEventName: EventPostToolUse, ToolName: "Task", ToolUseID: "toolu_1"}
+		if got := updateSubagents(input, running, now); len(got) != 0 {
+			t.Errorf("got %d subagents, want 0", len(got))
+		}
+	})
+
+	t.Run("other tools should keep subagents", func(t *testing.T) {
+		input := hookInput{HookEventName: EventPreToolUse, ToolName: "Bash", ToolUseID: "toolu_3"}
+		if got := updateSubagents(input, running, now); len(got) != 1 {
+			t.Errorf("got %d subagents, want 1", len(got))
+		}
+	})
+
+	t.Run("Stop should clear subagents", func(t *testing.T) {
+		if got := updateSubagents(hookInput{HookEventName: EventStop}, running, now); got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+
+	t.Run("SubagentStart should attach to its Task call", func(t *testing.T) {
+		input := hookInput{HookEventName: EventSubagentStart, AgentID: "agent_1", AgentType: "Explore"}
+		got := updateSubagents(input, running, now)
+		if len(got) != 1 || got[0].ID != "toolu_1" || got[0].AgentID != "agent_1" {
+			t.Errorf("got %+v, want the Task call with the agent ID", got)
+		}
+		if running[0].AgentID != "" {
+			t.Error("existing subagents should not be modified")
+		}
+	})
+
+	t.Run("SubagentStart without a Task call should add a subagent", func(t *testing.T) {
+		input := hookInput{HookEventName: EventSubagentStart, AgentID: "agent_2", AgentType: "code-reviewer"}
+		got := updateSubagents(input, running, now)
+		if len(got) != 2 || got[1].ID != "agent_2" || got[1].Type != "code-reviewer" {
+			t.Errorf("got %+v, want a second subagent", got)
+		}
+	})
+
+	t.Run("SubagentStop should remove the matching subagent", func(t *testing.T) {
+		started := []session.Subagent{{ID: "toolu_1", AgentID: "agent_1"}, {ID: "agent_2", AgentID: "agent_2"}}
+		input := hookInput{HookEventName: EventSubagentStop, AgentID: "agent_1"}
+		if got := updateSubagents(input, started, now); len(got) != 1 || got[0].ID != "agent_2" {
+			t.Errorf("got %+v, want only agent_2", got)
+		}
+	})
+}
+
+func TestRunSubagentEventsKeepStatus(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+	stubTermInfo := func(string, string, []session.Terminal) termInfo { return termInfo{} }
+	pidFn := func() int { return 42 }
+
+	for _, input := range []string{
+		`{"session_id":"s1","cwd":"/tmp","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"ls"}}`,
+		`{"session_id":"s1","cwd":"/tmp","hook_event_name":"SubagentStart","agent_id":"a1","agent_type":"Explore"}`,
+		`{"session_id":"s1","cwd":"/tmp","hook_event_name":"SubagentStart","agent_id":"a2","agent_type":"Explore"}`,
+		`{"session_id":"s1","cwd":"/tmp","hook_event_name":"SubagentStop","agent_id":"a1"}`,
+	} {
+		if err := run(strings.NewReader(input), stubTermInfo, pidFn); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "s1.json"))
+	var s session.Session
+	json.Unmarshal(data, &s)
+	if s.Status != "working" || s.Detail != "Bash: ls" {
+		t.Errorf("status = %q, detail = %q; subagent events should keep them", s.Status, s.Detail)
+	}
+	if len(s.Subagents) != 1 || s.Subagents[0].AgentID != "a2" {
+		t.Errorf("subagents = %+v, want a2 only", s.Subagents)
+	}
+
+	// Without a session file there is nothing to attach a subagent to.
+	input := `{"session_id":"s2","cwd":"/tmp","hook_event_name":"SubagentStart","agent_id":"a3"}`
+	if err := run(strings.NewReader(input), stubTermInfo, pidFn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "s2.json")); !os.IsNotExist(err) {
+		t.Error("SubagentStart should not create a session file")
+	}
+}
+
+func TestWriteSessionFileSkipsIdenticalContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s1.json")
+	s := session.Session{SessionID: "s1", Project: "/p", Status: "working"}
+
+	if err := writeSessionFile(path, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(path, past, past)
+
+	if err := writeSessionFile(path, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Error("identical content should not have been rewritten")
+	}
+}
+
+func TestWriteSessionFileReplacesTheFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s1.json")
+	for _, status := range []string{"working", "idle"} {
+		if err := writeSessionFile(path, session.Session{SessionID: "s1", Project: "/p", Status: status}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if s, err := session.LoadFile(path); err != nil || s.Status != "idle" {
+		t.Errorf("got %+v, %v, want the second write", s, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir holds %d files, want only the session file", len(entries))
+	}
+	if info, _ := os.Stat(path); runtime.GOOS != "windows" && info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+}
+
+func TestRunCoalescesPostToolUse(t *testing.T) {
+	stubTermInfo := func(string, string, []session.Terminal) termInfo { return termInfo{} }
+	pidFn := func() int { return 42 }
+	runAll := func(t *testing.T, inputs ...string) session.Session {
+		t.Helper()
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		for _, input := range inputs {
+			if err := run(strings.NewReader(input), stubTermInfo, pidFn); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		s, _ := session.LoadFile(filepath.Join(dir, "s1.json"))
+		return *s
+	}
+
+	t.Run("finishing a tool should always be written", func(t *testing.T) {
+		s := runAll(t,
+			`{"session_id":"s1","cwd":"/tmp","hook_event_name":"UserPromptSubmit","prompt":"list"}`,
+			`{"session_id":"s1","cwd":"/tmp","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"ls"}}`,
+			`{"session_id":"s1","cwd":"/tmp","hook_event_name":"PostToolUse","tool_name":"Bash"}`)
+		if s.Activity != session.ActivityThinking || s.Detail != "Finished Bash, continuing..." {
+			t.Errorf("got activity %q, detail %q, want thinking after the tool finished", s.Activity, s.Detail)
+		}
+	})
+
+	t.Run("a second tool finishing right after should be coalesced", func(t *testing.T) {
+		s := runAll(t,
+			`{"session_id":"s1","cwd":"/tmp","hook_event_name":"PreToolUse","tool_name":"Read","tool_input":{"file_path":"a.go"}}`,
+			`{"session_id":"s1","cwd":"/tmp","hook_event_name":"PreToolUse","tool_name":"Bash","tool_input":{"command":"ls"}}`,
+			`{"session_id":"s1","cwd":"/tmp","hook_event_name":"PostToolUse","tool_name":"Read"}`,
+			`{"session_id":"s1","cwd":"/tmp","hook_event_name":"PostToolUse","tool_name":"Bash"}`)
+		if s.Detail != "Finished Read, continuing..." {
+			t.Errorf("detail = %q, want the first PostToolUse kept", s.Detail)
+		}
+	})
+}
+
+// fakeBackend is a terminal backend whose lookups take delay.
+type fakeBackend struct {
+	name, id, title string
+	delay           time.Duration
+}
+
+func (b fakeBackend) Name() string    { return b.name }
+func (b fakeBackend) Available() bool { return true }
+func (b fakeBackend) Info() (string, string) {
+	time.Sleep(b.delay)
+	return b.id, b.title
+}
+func (b fakeBackend) Title(string) string {
+	time.Sleep(b.delay)
+	return b.title
+}
+func (fakeBackend) Select(string) error                { return nil }
+func (fakeBackend) Launch(string, string) error        { return nil }
+func (fakeBackend) SendText(string, string) error      { return nil }
+func (fakeBackend) SendKey(string, terminal.Key) error { return nil }
+
+func TestDefaultTermInfo(t *testing.T) {
+	saved := termBackends
+	t.Cleanup(func() { termBackends = saved })
+	termBackends = []terminal.Backend{
+		fakeBackend{name: "wt", id: "1,2", title: "Tab", delay: time.Second},
+		fakeBackend{name: "tmux", id: "%1", title: "Pane"},
+	}
+	known := []session.Terminal{{Backend: "wt", ID: "1,2"}, {Backend: "tmux", ID: "%1"}}
+	savedLater := lookUpLater
+	t.Cleanup(func() { lookUpLater = savedLater })
+	var later []string
+	lookUpLater = func(sessionID, backend string) { later = append(later, sessionID+" "+backend) }
+
+	t.Run("slow title lookups should be abandoned", func(t *testing.T) {
+		start := time.Now()
+		ti := defaultTermInfo(EventPreToolUse, "abc", known)
+		if elapsed := time.Since(start); elapsed > lookupBudget+200*time.Millisecond {
+			t.Errorf("took %v, want about %v", elapsed, lookupBudget)
+		}
+		if len(ti.terminals) != 2 || ti.terminals[0].ID != "1,2" || ti.terminals[1].ID != "%1" {
+			t.Errorf("terminals = %+v, want both known IDs in order", ti.terminals)
+		}
+		if ti.summary != "Pane" {
+			t.Errorf("summary = %q, want Pane", ti.summary)
+		}
+	})
+
+	if len(later) != 0 {
+		t.Errorf("title refreshes should not be finished in the background, got %v", later)
+	}
+
+	t.Run("finding a tab should be left to the background when slow", func(t *testing.T) {
+		start := time.Now()
+		ti := defaultTermInfo(EventSessionStart, "abc", nil)
+		if elapsed := time.Since(start); elapsed > lookupBudget+200*time.Millisecond {
+			t.Errorf("took %v, want about %v", elapsed, lookupBudget)
+		}
+		if len(ti.terminals) != 1 || ti.terminals[0].ID != "%1" {
+			t.Errorf("terminals = %+v, want only the fast pane", ti.terminals)
+		}
+		if len(later) != 1 || later[0] != "abc wt" {
+			t.Errorf("background lookups = %v, want the tab", later)
+		}
+	})
+
+	t.Run("a known tab should be kept while it is found again", func(t *testing.T) {
+		ti := defaultTermInfo(EventSessionStart, "abc", known)
+		if len(ti.terminals) != 2 || ti.terminals[0].ID != "1,2" {
+			t.Errorf("terminals = %+v, want the known tab kept", ti.terminals)
+		}
+	})
+}
+
+func TestAppendHistory(t *testing.T) {
+	t.Run("repeated status should not be added", func(t *testing.T) {
+		got := appendHistory([]string{"starting", "working"}, "working")
+		if strings.Join(got, ",") != "starting,working" {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("oldest statuses should be dropped beyond the limit", func(t *testing.T) {
+		var h []string
+		for i := range historyLen + 3 {
+			h = appendHistory(h, []string{"working", "waiting"}[i%2])
+		}
+		if len(h) != historyLen || h[len(h)-1] != "working" {
+			t.Errorf("got %v, want the last %d ending in working", h, historyLen)
+		}
+	})
+}
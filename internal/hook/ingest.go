@@ -0,0 +1,121 @@
+package hook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/proc"
+	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/tmux"
+)
+
+// ingestInput is the agent-agnostic report read by "ccmonitor ingest". Only
+// session_id, agent and status are required; fields left out keep their
+// previous value, so a wrapper can send just the status on most updates.
+type ingestInput struct {
+	SessionID string             `json:"session_id"`
+	Agent     string             `json:"agent"`
+	Project   string             `json:"project"`
+	Status    string             `json:"status"`
+	Detail    *string            `json:"detail"`
+	Prompt    *string            `json:"prompt"`
+	Summary   *string            `json:"summary"`
+	PID       int                `json:"pid"`
+	Terminals []session.Terminal `json:"terminals"`
+}
+
+// ingestStatuses are the statuses another agent may report. "ended" removes
+// the session.
+var ingestStatuses = map[string]bool{
+	session.StatusStarting: true,
+	session.StatusWorking:  true,
+	session.StatusIdle:     true,
+	session.StatusWaiting:  true,
+	session.StatusEnded:    true,
+}
+
+// Ingest is the entry point for "ccmonitor ingest". It reads one JSON report
+// from stdin and writes it as a session file, so agent CLIs other than Claude
+// Code show up in the same monitor.
+func Ingest() error {
+	return ingest(os.Stdin, time.Now())
+}
+
+func ingest(stdin io.Reader, now time.Time) error {
+	var input ingestInput
+	if err := json.NewDecoder(stdin).Decode(&input); err != nil {
+		return fmt.Errorf("parsing ingest input: %w", err)
+	}
+	switch {
+	case input.SessionID == "":
+		return fmt.Errorf("session_id is required")
+	case input.SessionID != filepath.Base(input.SessionID):
+		return fmt.Errorf("session_id must not contain path separators")
+	case input.Agent == "":
+		return fmt.Errorf("agent is required")
+	case !ingestStatuses[input.Status]:
+		return fmt.Errorf("unknown status %q (want starting, working, idle, waiting or ended)", input.Status)
+	}
+
+	dir := session.Dir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating sessions dir: %w", err)
+	}
+	sessionFile := filepath.Join(dir, input.SessionID+".json")
+
+	if input.Status == session.StatusEnded {
+		session.Remove(sessionFile)
+		return nil
+	}
+
+	s := loadExistingSession(sessionFile)
+	s.SessionID = input.SessionID
+	s.Agent = input.Agent
+	// As for hooks, reports that keep the status keep the time it started.
+	if input.Status != s.Status || s.StatusSince == "" {
+		s.StatusSince = now.UTC().Format(time.RFC3339)
+	}
+	s.Status = input.Status
+	s.History = appendHistory(s.History, input.Status)
+	s.LastActivity = now.UTC().Format(time.RFC3339)
+	s.OS = runtime.GOOS
+	s.Host = hostname()
+	if input.Project != "" {
+		s.Project = input.Project
+	}
+	if s.Project == "" {
+		return fmt.Errorf("project is required for a new session")
+	}
+	s.Git = gitInfo(s.Project)
+	if input.Detail != nil {
+		s.Detail = *input.Detail
+	}
+	if input.Prompt != nil {
+		s.LastPrompt = *input.Prompt
+	}
+	if input.Summary != nil {
+		s.Summary = *input.Summary
+	}
+	if input.PID > 0 && input.PID != s.PID {
+		s.PID = input.PID
+		s.PIDStart = proc.StartTime(input.PID)
+	}
+	switch {
+	case input.Terminals != nil:
+		s.Terminals = input.Terminals
+	case len(s.Terminals) == 0:
+		// Wrappers usually run in the agent's own pane; pick it up from tmux.
+		if b := (tmux.Backend{}); b.Available() {
+			if id, _ := b.Info(); id != "" {
+				s.Terminals = []session.Terminal{{Backend: b.Name(), ID: id}}
+			}
+		}
+	}
+
+	return writeSessionFile(sessionFile, s)
+}
@@ -0,0 +1,108 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestIngest(t *testing.T) {
+	now := time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC)
+
+	t.Run("report should create a session file", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		t.Setenv("TMUX_PANE", "")
+
+		input := `{"session_id":"aider-1","agent":"aider","project":"/tmp/proj","status":"working","detail":"Editing main.go","prompt":"add tests"}`
+		if err := ingest(strings.NewReader(input), now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s, err := session.LoadFile(filepath.Join(dir, "aider-1.json"))
+		if err != nil {
+			t.Fatalf("reading session file: %v", err)
+		}
+		if s.Agent != "aider" || s.Status != "working" || s.Detail != "Editing main.go" || s.LastPrompt != "add tests" {
+			t.Errorf("unexpected session %+v", s)
+		}
+		if s.LastActivity != "2026-03-10T13:00:00Z" {
+			t.Errorf("last_activity = %q", s.LastActivity)
+		}
+	})
+
+	t.Run("omitted fields should keep their previous value", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		t.Setenv("TMUX_PANE", "")
+
+		ingest(strings.NewReader(`{"session_id":"c1","agent":"codex","project":"/tmp/proj","status":"working","prompt":"fix it","detail":"Running"}`), now)
+		if err := ingest(strings.NewReader(`{"session_id":"c1","agent":"codex","status":"idle","detail":""}`), now); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		s, _ := session.LoadFile(filepath.Join(dir, "c1.json"))
+		if s.Status != "idle" || s.Project != "/tmp/proj" || s.LastPrompt != "fix it" {
+			t.Errorf("unexpected session %+v", s)
+		}
+		if s.Detail != "" {
+			t.Errorf("explicit empty detail should clear it, got %q", s.Detail)
+		}
+	})
+
+	t.Run("status_since should only move when the status changes", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		t.Setenv("TMUX_PANE", "")
+		path := filepath.Join(dir, "c2.json")
+
+		report := func(status string, at time.Time) *session.Session {
+			t.Helper()
+			input := `{"session_id":"c2","agent":"codex","project":"/tmp/proj","status":"` + status + `"}`
+			if err := ingest(strings.NewReader(input), at); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			s, _ := session.LoadFile(path)
+			return s
+		}
+		report("working", now)
+		if s := report("working", now.Add(time.Minute)); s.StatusSince != "2026-03-10T13:00:00Z" {
+			t.Errorf("same status: status_since = %q, want the first report's", s.StatusSince)
+		}
+		if s := report("idle", now.Add(2*time.Minute)); s.StatusSince != "2026-03-10T13:02:00Z" {
+			t.Errorf("new status: status_since = %q, want the change's", s.StatusSince)
+		}
+	})
+
+	t.Run("ended should remove the session file", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		t.Setenv("TMUX_PANE", "")
+
+		ingest(strings.NewReader(`{"session_id":"c2","agent":"codex","project":"/tmp","status":"idle"}`), now)
+		ingest(strings.NewReader(`{"session_id":"c2","agent":"codex","status":"ended"}`), now)
+
+		if _, err := os.Stat(filepath.Join(dir, "c2.json")); !os.IsNotExist(err) {
+			t.Error("session file should have been removed")
+		}
+	})
+
+	t.Run("invalid reports should be rejected", func(t *testing.T) {
+		t.Setenv("CCMONITOR_SESSIONS_DIR", t.TempDir())
+		for _, input := range []string{
+			`{"agent":"codex","project":"/tmp","status":"idle"}`,
+			`{"session_id":"c3","project":"/tmp","status":"idle"}`,
+			`{"session_id":"c3","agent":"codex","project":"/tmp","status":"thinking"}`,
+			`{"session_id":"../c3","agent":"codex","project":"/tmp","status":"idle"}`,
+			`{"session_id":"c3","agent":"codex","status":"idle"}`,
+		} {
+			if err := ingest(strings.NewReader(input), now); err == nil {
+				t.Errorf("expected error for %s", input)
+			}
+		}
+	})
+}
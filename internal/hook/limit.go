@@ -0,0 +1,62 @@
+package hook
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// "Claude AI usage limit reached|1718456400"
+	limitEpochRe = regexp.MustCompile(`(?i)limit reached\|(\d{9,})`)
+	// "5-hour limit reached ∙ resets 3pm", "Your limit will reset at 3:30pm (Europe/Stockholm)"
+	limitClockRe = regexp.MustCompile(`(?i)limit.*\breset(?:s)?(?: at)? (\d{1,2})(?::(\d{2}))?\s*(am|pm)?(?: \(([\w/+-]+)\))?`)
+)
+
+// parseUsageLimit recognizes Claude's usage-limit messages and returns when
+// the limit resets. Messages give either a Unix timestamp or a wall-clock
+// time, optionally with an IANA zone; a clock time is taken as its next
+// occurrence after now. ok is false for anything that isn't a limit message.
+func parseUsageLimit(message string, now time.Time) (resetAt time.Time, ok bool) {
+	if m := limitEpochRe.FindStringSubmatch(message); m != nil {
+		sec, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(sec, 0), true
+	}
+
+	m := limitClockRe.FindStringSubmatch(message)
+	if m == nil {
+		return time.Time{}, false
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2]) // "" → 0
+	switch strings.ToLower(m[3]) {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return time.Time{}, false
+	}
+
+	loc := now.Location()
+	if m[4] != "" {
+		if l, err := time.LoadLocation(m[4]); err == nil {
+			loc = l
+		}
+	}
+	local := now.In(loc)
+	resetAt = time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if !resetAt.After(local) {
+		resetAt = resetAt.AddDate(0, 0, 1)
+	}
+	return resetAt, true
+}
@@ -0,0 +1,40 @@
+package hook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseUsageLimit(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	now := time.Date(2026, 3, 10, 13, 20, 0, 0, stockholm)
+
+	tests := []struct {
+		name    string
+		message string
+		want    time.Time
+		ok      bool
+	}{
+		{"unix timestamp", "Claude AI usage limit reached|1773151200", time.Unix(1773151200, 0), true},
+		{"clock time later today", "5-hour limit reached ∙ resets 3pm", time.Date(2026, 3, 10, 15, 0, 0, 0, stockholm), true},
+		{"clock time already passed should be tomorrow", "5-hour limit reached ∙ resets 9am", time.Date(2026, 3, 11, 9, 0, 0, 0, stockholm), true},
+		{"minutes and zone", "Claude usage limit reached. Your limit will reset at 3:30pm (Europe/Stockholm).", time.Date(2026, 3, 10, 15, 30, 0, 0, stockholm), true},
+		{"24-hour clock", "Usage limit reached, resets 18:00", time.Date(2026, 3, 10, 18, 0, 0, 0, stockholm), true},
+		{"12am should be midnight", "limit reached · resets 12am", time.Date(2026, 3, 11, 0, 0, 0, 0, stockholm), true},
+		{"unrelated message", "Claude needs your permission to use Bash", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseUsageLimit(tt.message, now)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("reset = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package hook
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// sessionLockWait is how long a writer waits for another one to finish with
+// a session file before writing anyway: losing a concurrent update is
+// better than holding up Claude.
+const sessionLockWait = time.Second
+
+// sessionLockStale is how old a session's lock file gets before it is
+// assumed to be left over from a writer that died. Writers only hold it
+// while reading and writing the file.
+const sessionLockStale = 5 * time.Second
+
+// sessionLock is the lock file of the session file at path; it doesn't end
+// in .json, so nothing else reads it.
+func sessionLock(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".lock"
+}
+
+// lockSession takes the lock of the session file at path, which every
+// process writing the file holds between reading and writing it: the hook,
+// the status line and background lookups. It returns the function that
+// releases it.
+func lockSession(path string) (unlock func()) {
+	lock := sessionLock(path)
+	deadline := time.Now().Add(sessionLockWait)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lock) }
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > sessionLockStale {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			slog.Warn("session file still locked, writing anyway", "path", path)
+			return func() {}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// updateSessionFile rereads the session file at path under its lock, lets
+// update change it, and writes it back unless update returns false. A
+// missing or corrupt file is passed to update as a zero Session.
+func updateSessionFile(path string, update func(s *session.Session) bool) error {
+	unlock := lockSession(path)
+	defer unlock()
+	s := loadExistingSession(path)
+	if !update(&s) {
+		return nil
+	}
+	return writeSessionFile(path, s)
+}
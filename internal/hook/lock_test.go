@@ -0,0 +1,85 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestLockSession(t *testing.T) {
+	t.Run("a second writer should wait for the first", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "s1.json")
+		unlock := lockSession(path)
+		released := make(chan time.Time, 1)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			released <- time.Now()
+			unlock()
+		}()
+		lockSession(path)()
+		if at := <-released; time.Now().Before(at) {
+			t.Error("the lock was taken before it was released")
+		}
+		if _, err := os.Stat(sessionLock(path)); !os.IsNotExist(err) {
+			t.Error("the lock file should be gone")
+		}
+	})
+
+	t.Run("a stale lock should be taken over", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "s1.json")
+		os.WriteFile(sessionLock(path), nil, 0644)
+		old := time.Now().Add(-sessionLockStale - time.Second)
+		os.Chtimes(sessionLock(path), old, old)
+		start := time.Now()
+		lockSession(path)()
+		if time.Since(start) >= sessionLockWait {
+			t.Error("the stale lock should not have been waited for")
+		}
+	})
+}
+
+func TestRunKeepsConcurrentWrites(t *testing.T) {
+	stubPidFn := func() int { return 42 }
+	// runWhile runs a UserPromptSubmit and then a Stop, during which write
+	// changes the session file after the hook has read it.
+	runWhile := func(t *testing.T, write func(path string)) *session.Session {
+		t.Helper()
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		path := filepath.Join(dir, "s1.json")
+		input := `{"session_id":"s1","cwd":"/tmp","hook_event_name":"UserPromptSubmit","prompt":"hi"}`
+		if err := run(strings.NewReader(input), func(string, string, []session.Terminal) termInfo { return termInfo{} }, stubPidFn); err != nil {
+			t.Fatal(err)
+		}
+		termInfo := func(string, string, []session.Terminal) termInfo {
+			write(path)
+			return termInfo{}
+		}
+		input = `{"session_id":"s1","cwd":"/tmp","hook_event_name":"Stop"}`
+		if err := run(strings.NewReader(input), termInfo, stubPidFn); err != nil {
+			t.Fatal(err)
+		}
+		s, _ := session.LoadFile(path)
+		return s
+	}
+
+	t.Run("the status line's context left should be kept", func(t *testing.T) {
+		s := runWhile(t, func(path string) { recordContextLeft(path, 40) })
+		if s.Status != session.StatusIdle || s.ContextLeft == nil || *s.ContextLeft != 40 {
+			t.Errorf("got status %q, context left %v, want idle with the status line's 40", s.Status, s.ContextLeft)
+		}
+	})
+
+	t.Run("a terminal found in the background should be kept", func(t *testing.T) {
+		s := runWhile(t, func(path string) { recordTerminal(path, "wt", "1,2", "Tab") })
+		want := []session.Terminal{{Backend: "wt", ID: "1,2"}}
+		if s.Status != session.StatusIdle || !slices.Equal(s.Terminals, want) || s.Summary != "Tab" {
+			t.Errorf("got status %q, terminals %v, summary %q, want idle in the found tab", s.Status, s.Terminals, s.Summary)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package hook
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// lookupStale is how old a background lookup's lock file gets before it is
+// assumed to be left over from a lookup that died. The slowest lookup, the
+// PowerShell one for Windows Terminal, gives up after a few seconds.
+const lookupStale = 30 * time.Second
+
+// lookUpLater finds the tab or pane of session sessionID on backend in a
+// detached "ccmonitor hook-lookup" process, which adds it to the session
+// file, so the hook doesn't wait for it. The process inherits the hook's
+// environment, which the lookup goes by. Only one runs per session and
+// backend at a time (replaced in tests).
+var lookUpLater = func(sessionID, backend string) {
+	lock := lookupLock(sessionID, backend)
+	if !claimLookup(lock) {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		os.Remove(lock)
+		slog.Warn("starting background terminal lookup", "err", err)
+		return
+	}
+	cmd := exec.Command(exe, "hook-lookup", sessionID, backend)
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		os.Remove(lock)
+		slog.Warn("starting background terminal lookup", "err", err)
+		return
+	}
+	cmd.Process.Release()
+}
+
+// lookupLock is the lock file of a background lookup, next to the session
+// file; it doesn't end in .json, so nothing else reads it.
+func lookupLock(sessionID, backend string) string {
+	return filepath.Join(session.Dir(), sessionID+".lookup-"+backend)
+}
+
+// claimLookup creates the lock file at path, reporting whether the caller
+// may start a lookup. A lock older than lookupStale is taken over.
+func claimLookup(path string) bool {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err == nil {
+		f.Close()
+		return true
+	}
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) < lookupStale {
+		return false
+	}
+	now := time.Now()
+	return os.Chtimes(path, now, now) == nil
+}
+
+// Lookup is the entry point for "ccmonitor hook-lookup <session ID>
+// <backend>", started by the hook when finding a session's tab or pane ran
+// out of lookupBudget.
+func Lookup(args []string) error {
+	if len(args) != 2 || args[0] != filepath.Base(args[0]) {
+		return fmt.Errorf("usage: ccmonitor hook-lookup <session ID> <backend>")
+	}
+	sessionID, backend := args[0], args[1]
+	defer os.Remove(lookupLock(sessionID, backend))
+	for _, b := range termBackends {
+		if b.Name() == backend {
+			id, title := b.Info()
+			recordTerminal(filepath.Join(session.Dir(), sessionID+".json"), backend, id, title)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown terminal backend %q", backend)
+}
+
+// recordTerminal adds the terminal a background lookup found to the session
+// file at path, and its title as the summary when there is none yet. A
+// session whose file is gone has ended, and is left alone.
+func recordTerminal(path, backend, id, title string) {
+	if id == "" {
+		return
+	}
+	updateSessionFile(path, func(s *session.Session) bool {
+		if s.SessionID == "" {
+			return false
+		}
+		var terminals []session.Terminal
+		for _, t := range s.Terminals {
+			if t.Backend != backend {
+				terminals = append(terminals, t)
+			}
+		}
+		s.Terminals = append(terminals, session.Terminal{Backend: backend, ID: id})
+		if s.Summary == "" {
+			s.Summary = title
+		}
+		slog.Debug("terminal found in the background", "session", s.SessionID, "backend", backend, "id", id)
+		return true
+	})
+}
@@ -0,0 +1,68 @@
+package hook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/terminal"
+)
+
+func TestClaimLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s1.lookup-wt")
+	if !claimLookup(path) {
+		t.Fatal("first claim should succeed")
+	}
+	if claimLookup(path) {
+		t.Error("a running lookup should not be started twice")
+	}
+	old := time.Now().Add(-lookupStale - time.Second)
+	os.Chtimes(path, old, old)
+	if !claimLookup(path) {
+		t.Error("a stale lock should be taken over")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+	saved := termBackends
+	t.Cleanup(func() { termBackends = saved })
+	termBackends = []terminal.Backend{fakeBackend{name: "wt", id: "1,2", title: "Tab"}}
+
+	path := filepath.Join(dir, "s1.json")
+	writeSessionFile(path, session.Session{SessionID: "s1", Project: "/p", Status: "working",
+		Terminals: []session.Terminal{{Backend: "tmux", ID: "%1"}}})
+	lock := lookupLock("s1", "wt")
+	claimLookup(lock)
+
+	if err := Lookup([]string{"s1", "wt"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s, _ := session.LoadFile(path)
+	if s.FindTerminalID("wt") != "1,2" || s.FindTerminalID("tmux") != "%1" {
+		t.Errorf("terminals = %+v, want the tab added", s.Terminals)
+	}
+	if s.Summary != "Tab" {
+		t.Errorf("summary = %q, want the tab title", s.Summary)
+	}
+	if _, err := os.Stat(lock); !os.IsNotExist(err) {
+		t.Error("the lock should be removed when done")
+	}
+
+	// A session that ended meanwhile is not written back.
+	if err := Lookup([]string{"gone", "wt"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gone.json")); !os.IsNotExist(err) {
+		t.Error("a missing session should not be created")
+	}
+
+	for _, args := range [][]string{{"s1"}, {"../s1", "wt"}, {"s1", "kitty"}} {
+		if err := Lookup(args); err == nil {
+			t.Errorf("Lookup(%q) should fail", args)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package hook
+
+import (
+	"regexp"
+	"strings"
+)
+
+// "Claude needs your permission to use Bash", "... to use mcp__github__create_issue"
+var permissionToolRe = regexp.MustCompile(`permission to use (\S+)`)
+
+// parsePermission picks the tool out of a permission_prompt message, and what
+// it would run or touch out of the detail the tool's PreToolUse left (e.g.
+// "Bash: rm -rf build/" or "Edit main.go"). Both are "" when the message
+// doesn't name a tool; command is "" when the detail is about another tool.
+func parsePermission(message, prevDetail string) (tool, command string) {
+	m := permissionToolRe.FindStringSubmatch(message)
+	if m == nil {
+		return "", ""
+	}
+	tool = strings.TrimRight(m[1], ".?!")
+	rest, ok := strings.CutPrefix(prevDetail, tool)
+	if ok && (strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, " ")) {
+		command = strings.TrimSpace(strings.TrimPrefix(rest, ":"))
+	}
+	return tool, command
+}
+
+// permissionDetail words a permission prompt: "Allow Bash: rm -rf build/?",
+// or "Allow Bash?" without a command.
+func permissionDetail(tool, command string) string {
+	if command == "" {
+		return "Allow " + tool + "?"
+	}
+	return "Allow " + tool + ": " + command + "?"
+}
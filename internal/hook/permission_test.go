@@ -0,0 +1,37 @@
+package hook
+
+import "testing"
+
+func TestParsePermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		prevDetail  string
+		wantTool    string
+		wantCommand string
+	}{
+		{"bash command", "Claude needs your permission to use Bash", "Bash: rm -rf build/", "Bash", "rm -rf build/"},
+		{"file tool", "Claude needs your permission to use Edit", "Edit main.go", "Edit", "main.go"},
+		{"mcp tool without input", "Claude needs your permission to use mcp__github__create_issue", "mcp__github__create_issue", "mcp__github__create_issue", ""},
+		{"detail of another tool", "Claude needs your permission to use Bash", "Edit main.go", "Bash", ""},
+		{"tool name prefix is not the tool", "Claude needs your permission to use Web", "WebFetch https://example.com", "Web", ""},
+		{"message without a tool", "Claude is waiting for your input", "Bash: ls", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, command := parsePermission(tt.message, tt.prevDetail)
+			if tool != tt.wantTool || command != tt.wantCommand {
+				t.Errorf("got %q, %q; want %q, %q", tool, command, tt.wantTool, tt.wantCommand)
+			}
+		})
+	}
+}
+
+func TestPermissionDetail(t *testing.T) {
+	if got, want := permissionDetail("Bash", "rm -rf build/"), "Allow Bash: rm -rf build/?"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := permissionDetail("WebFetch", ""), "Allow WebFetch?"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
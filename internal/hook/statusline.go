@@ -0,0 +1,111 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// statuslineInput is the part of Claude Code's status line input that
+// ccmonitor uses. Newer versions report the remaining percentage directly;
+// older ones only the token counts of the last request.
+type statuslineInput struct {
+	SessionID string `json:"session_id"`
+	Model     struct {
+		DisplayName string `json:"display_name"`
+	} `json:"model"`
+	ContextWindow struct {
+		Size         int      `json:"context_window_size"`
+		Remaining    *float64 `json:"remaining_percentage"`
+		CurrentUsage *struct {
+			InputTokens              int `json:"input_tokens"`
+			CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+		} `json:"current_usage"`
+	} `json:"context_window"`
+}
+
+// contextLeft returns the percentage of the context window still free, or
+// false when the input doesn't say.
+func (in statuslineInput) contextLeft() (int, bool) {
+	cw := in.ContextWindow
+	if cw.Remaining != nil {
+		return int(math.Round(*cw.Remaining)), true
+	}
+	if cw.Size <= 0 || cw.CurrentUsage == nil {
+		return 0, false
+	}
+	u := cw.CurrentUsage
+	used := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return max(0, 100-int(math.Round(float64(used)*100/float64(cw.Size)))), true
+}
+
+// Statusline is the entry point for "ccmonitor statusline-hook", configured
+// as Claude Code's status line command. It records the session's remaining
+// context in its session file and prints the status line: the output of
+// command (run with the same input) when one is given, so an existing status
+// line keeps working, else the model and the context left.
+func Statusline(command []string) error {
+	return statusline(os.Stdin, os.Stdout, command)
+}
+
+func statusline(stdin io.Reader, stdout io.Writer, command []string) error {
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return fmt.Errorf("reading stdin: %w", err)
+	}
+	var input statuslineInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return fmt.Errorf("parsing status line input: %w", err)
+	}
+
+	left, ok := input.contextLeft()
+	if ok && input.SessionID != "" && input.SessionID == filepath.Base(input.SessionID) {
+		recordContextLeft(filepath.Join(session.Dir(), input.SessionID+".json"), left)
+	}
+
+	if len(command) > 0 {
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", strings.Join(command, " "))
+		} else {
+			cmd = exec.Command("sh", "-c", strings.Join(command, " "))
+		}
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Stdout = stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+	line := input.Model.DisplayName
+	if ok {
+		if line != "" {
+			line += " · "
+		}
+		line += fmt.Sprintf("%d%% context left", left)
+	}
+	_, err = fmt.Fprintln(stdout, line)
+	return err
+}
+
+// recordContextLeft stores left in the session file at path. Only sessions
+// the hooks have already created are updated, and last_activity is left
+// alone: the status line refreshes on its own, not because Claude did
+// anything.
+func recordContextLeft(path string, left int) {
+	updateSessionFile(path, func(s *session.Session) bool {
+		if s.SessionID == "" || (s.ContextLeft != nil && *s.ContextLeft == left) {
+			return false
+		}
+		s.ContextLeft = &left
+		return true
+	})
+}
@@ -0,0 +1,95 @@
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestContextLeft(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   int
+		wantOK bool
+	}{
+		{"remaining percentage", `{"context_window":{"remaining_percentage":37.6}}`, 38, true},
+		{"from current usage", `{"context_window":{"context_window_size":200000,"current_usage":{"input_tokens":10000,"cache_creation_input_tokens":20000,"cache_read_input_tokens":160000}}}`, 5, true},
+		{"before the first request", `{"context_window":{"context_window_size":200000,"current_usage":null}}`, 0, false},
+		{"older Claude Code", `{"model":{"display_name":"Opus"}}`, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var in statuslineInput
+			if err := json.Unmarshal([]byte(tt.input), &in); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := in.contextLeft()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got %d, %v; want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestStatusline(t *testing.T) {
+	const input = `{"session_id":"abc","model":{"display_name":"Opus"},"context_window":{"remaining_percentage":12}}`
+
+	t.Run("context left should be recorded in an existing session file", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+		path := filepath.Join(dir, "abc.json")
+		writeSessionFile(path, session.Session{SessionID: "abc", Status: "working", LastActivity: "2026-03-10T13:00:00Z"})
+
+		var out bytes.Buffer
+		if err := statusline(strings.NewReader(input), &out, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := out.String(); got != "Opus · 12% context left\n" {
+			t.Errorf("status line = %q", got)
+		}
+		s, err := session.LoadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.ContextLeft == nil || *s.ContextLeft != 12 {
+			t.Errorf("context_left = %v, want 12", s.ContextLeft)
+		}
+		if s.LastActivity != "2026-03-10T13:00:00Z" {
+			t.Errorf("last_activity changed to %q", s.LastActivity)
+		}
+	})
+
+	t.Run("missing session file should not be created", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CCMONITOR_SESSIONS_DIR", dir)
+
+		if err := statusline(strings.NewReader(input), &bytes.Buffer{}, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := session.LoadFile(filepath.Join(dir, "abc.json")); err == nil {
+			t.Error("session file was created")
+		}
+	})
+
+	t.Run("command should get the same input and print the status line", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("uses sh")
+		}
+		t.Setenv("CCMONITOR_SESSIONS_DIR", t.TempDir())
+
+		var out bytes.Buffer
+		if err := statusline(strings.NewReader(input), &out, []string{"wc", "-c"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := strings.TrimSpace(out.String()); got != fmt.Sprint(len(input)) {
+			t.Errorf("command saw %s bytes, want %d", got, len(input))
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// tabColors are the iTerm2 tab colors per status. Other statuses reset the
+// tab to its default color.
+var tabColors = map[string][3]int{
+	session.StatusStarting:   {0, 170, 170},
+	session.StatusWorking:    {0, 170, 0},
+	session.StatusCompacting: {0, 170, 0},
+	session.StatusWaiting:    {230, 180, 0},
+	session.StatusLimited:    {60, 100, 230},
+	session.StatusError:      {220, 50, 50},
+}
+
+// tabProgress are the OSC 9;4 progress states per status, which Windows
+// Terminal and Ghostty show on the tab: 3 is an indeterminate spinner, 4 a
+// paused (yellow) bar and 2 an error (red) bar. Other statuses clear it.
+var tabProgress = map[string]string{
+	session.StatusWorking:    "3",
+	session.StatusCompacting: "3",
+	session.StatusWaiting:    "4;100",
+	session.StatusLimited:    "2;100",
+	session.StatusError:      "2;100",
+}
+
+// tabSequence returns the escape sequence that shows status on the current
+// terminal tab, or "" when the terminal has no known way to do that.
+// Detection uses the environment Claude Code passes on to the hook.
+func tabSequence(status string, getenv func(string) string) string {
+	var seq string
+	switch {
+	case getenv("TERM_PROGRAM") == "iTerm.app":
+		if c, ok := tabColors[status]; ok {
+			seq = fmt.Sprintf("\x1b]6;1;bg;red;brightness;%d\a\x1b]6;1;bg;green;brightness;%d\a\x1b]6;1;bg;blue;brightness;%d\a", c[0], c[1], c[2])
+		} else {
+			seq = "\x1b]6;1;bg;*;default\a"
+		}
+	case getenv("WT_SESSION") != "" || getenv("TERM_PROGRAM") == "ghostty":
+		state, ok := tabProgress[status]
+		if !ok {
+			state = "0"
+		}
+		seq = "\x1b]9;4;" + state + "\a"
+	default:
+		return ""
+	}
+	if getenv("TMUX") != "" {
+		// tmux passthrough (needs allow-passthrough on): escapes inside
+		// the DCS are doubled.
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+// setTabStatus shows status on the terminal tab the session runs in, when
+// enabled with CCMONITOR_TAB_COLOR=1. The hook's stdout belongs to Claude
+// Code, so the sequence goes straight to the controlling terminal. Errors
+// are ignored: a tab color is never worth failing the hook.
+func setTabStatus(status string) {
+	if os.Getenv("CCMONITOR_TAB_COLOR") != "1" {
+		return
+	}
+	seq := tabSequence(status, os.Getenv)
+	if seq == "" {
+		return
+	}
+	tty := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		tty = "CONOUT$"
+	}
+	f, err := os.OpenFile(tty, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(seq)
+}
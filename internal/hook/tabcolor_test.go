@@ -0,0 +1,33 @@
+package hook
+
+import (
+	"testing"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestTabSequence(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    map[string]string
+		status string
+		want   string
+	}{
+		{"unknown terminal", map[string]string{}, session.StatusWorking, ""},
+		{"iTerm2 working", map[string]string{"TERM_PROGRAM": "iTerm.app"}, session.StatusWorking,
+			"\x1b]6;1;bg;red;brightness;0\a\x1b]6;1;bg;green;brightness;170\a\x1b]6;1;bg;blue;brightness;0\a"},
+		{"iTerm2 idle resets", map[string]string{"TERM_PROGRAM": "iTerm.app"}, session.StatusIdle, "\x1b]6;1;bg;*;default\a"},
+		{"Windows Terminal waiting", map[string]string{"WT_SESSION": "abc"}, session.StatusWaiting, "\x1b]9;4;4;100\a"},
+		{"Windows Terminal ended clears", map[string]string{"WT_SESSION": "abc"}, session.StatusEnded, "\x1b]9;4;0\a"},
+		{"Ghostty working", map[string]string{"TERM_PROGRAM": "ghostty"}, session.StatusWorking, "\x1b]9;4;3\a"},
+		{"inside tmux", map[string]string{"WT_SESSION": "abc", "TMUX": "/tmp/tmux"}, session.StatusWorking, "\x1bPtmux;\x1b\x1b]9;4;3\a\x1b\\"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(k string) string { return tt.env[k] }
+			if got := tabSequence(tt.status, getenv); got != tt.want {
+				t.Errorf("tabSequence(%q) = %q, want %q", tt.status, got, tt.want)
+			}
+		})
+	}
+}
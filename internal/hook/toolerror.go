@@ -0,0 +1,64 @@
+package hook
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// maxErrorLen bounds the error text kept in the session file.
+const maxErrorLen = 200
+
+// updateLastError returns the session's last tool error after this event. A
+// failed tool call (see toolFailure) records "<tool>: <first line of the
+// error>"; a successful tool call clears it, and a new session starts
+// without one. Everything else keeps the existing error.
+func updateLastError(input hookInput, existing string) string {
+	switch input.HookEventName {
+	case EventSessionStart:
+		return ""
+	case EventPostToolUse, EventPostToolFailure:
+		if msg, failed := toolFailure(input); failed {
+			return formatToolError(input.ToolName, msg)
+		}
+		return ""
+	}
+	return existing
+}
+
+// toolFailure reports whether the event is a failed tool call, and its error
+// message when there is one. Besides PostToolUseFailure, a PostToolUse
+// response can say the tool failed: marked with is_error, with an error
+// field, with "success": false, or as text starting with "Error:".
+func toolFailure(input hookInput) (string, bool) {
+	switch input.HookEventName {
+	case EventPostToolFailure:
+		return input.Error, true
+	case EventPostToolUse:
+		var resp struct {
+			IsError bool   `json:"is_error"`
+			Error   string `json:"error"`
+			Success *bool  `json:"success"`
+		}
+		if json.Unmarshal(input.ToolResponse, &resp) == nil {
+			return resp.Error, resp.IsError || resp.Error != "" || (resp.Success != nil && !*resp.Success)
+		}
+		var text string
+		if json.Unmarshal(input.ToolResponse, &text) == nil && strings.HasPrefix(text, "Error:") {
+			return strings.TrimSpace(strings.TrimPrefix(text, "Error:")), true
+		}
+	}
+	return "", false
+}
+
+func formatToolError(tool, msg string) string {
+	msg, _, _ = strings.Cut(strings.TrimSpace(msg), "\n")
+	if msg == "" {
+		msg = "failed"
+	}
+	if tool != "" {
+		msg = tool + ": " + msg
+	}
+	return session.Truncate(msg, maxErrorLen)
+}
@@ -0,0 +1,72 @@
+package hook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUpdateLastError(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    hookInput
+		existing string
+		want     string
+	}{
+		{
+			name:  "failed tool records the first line of the error",
+			input: hookInput{HookEventName: EventPostToolFailure, ToolName: "Bash", Error: "exit status 1\nmore output"},
+			want:  "Bash: exit status 1",
+		},
+		{
+			name:  "failed tool without a message",
+			input: hookInput{HookEventName: EventPostToolFailure, ToolName: "Edit"},
+			want:  "Edit: failed",
+		},
+		{
+			name:  "tool response marked as an error",
+			input: hookInput{HookEventName: EventPostToolUse, ToolName: "Read", ToolResponse: json.RawMessage(`{"is_error":true,"error":"file not found"}`)},
+			want:  "Read: file not found",
+		},
+		{
+			name:  "tool response reporting no success",
+			input: hookInput{HookEventName: EventPostToolUse, ToolName: "Write", ToolResponse: json.RawMessage(`{"success":false}`)},
+			want:  "Write: failed",
+		},
+		{
+			name:  "tool response that is an error text",
+			input: hookInput{HookEventName: EventPostToolUse, ToolName: "Grep", ToolResponse: json.RawMessage(`"Error: path does not exist"`)},
+			want:  "Grep: path does not exist",
+		},
+		{
+			name:     "successful tool clears the error",
+			input:    hookInput{HookEventName: EventPostToolUse, ToolName: "Bash", ToolResponse: json.RawMessage(`{"stdout":"ok","success":true}`)},
+			existing: "Bash: exit status 1",
+			want:     "",
+		},
+		{
+			name:     "tool response that is not an object clears the error",
+			input:    hookInput{HookEventName: EventPostToolUse, ToolName: "Bash", ToolResponse: json.RawMessage(`"done"`)},
+			existing: "Bash: exit status 1",
+			want:     "",
+		},
+		{
+			name:     "other events keep the error",
+			input:    hookInput{HookEventName: EventPreToolUse, ToolName: "Bash"},
+			existing: "Bash: exit status 1",
+			want:     "Bash: exit status 1",
+		},
+		{
+			name:     "new session starts without an error",
+			input:    hookInput{HookEventName: EventSessionStart},
+			existing: "Bash: exit status 1",
+			want:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := updateLastError(tt.input, tt.existing); got != tt.want {
+				t.Errorf("updateLastError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+// Package install registers the ccmonitor hook in Claude Code's user
+// settings file, for "ccmonitor install", as an alternative to the plugin.
+// Only hook entries running the ccmonitor hook are added or removed; the
+// other settings are kept, though the file is written back with its keys
+// sorted and indented by two spaces.
+package install
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Command is the hook command, the same as in the plugin's hooks.json: under
+// WSL it prefers the Windows binary, so both sides share one sessions
+// directory.
+const Command = "ccmonitor.exe hook 2>/dev/null || ccmonitor hook"
+
+// Events are the hook events ccmonitor handles.
+var Events = []string{
+	"SessionStart",
+	"UserPromptSubmit",
+	"PreToolUse",
+	"PostToolUse",
+	"PostToolUseFailure",
+	"Notification",
+	"PreCompact",
+	"Stop",
+	"SessionEnd",
+	"SubagentStart",
+	"SubagentStop",
+}
+
+// ErrPlugin is returned by Apply for a settings file that enables the
+// ccmonitor plugin, which registers the hooks already: registering them again
+// would record every event twice.
+var ErrPlugin = errors.New("the ccmonitor plugin is enabled, which registers the hooks already")
+
+// SettingsPath returns Claude Code's user settings file:
+// $CLAUDE_CONFIG_DIR/settings.json, or ~/.claude/settings.json.
+func SettingsPath() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "settings.json")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".claude", "settings.json")
+}
+
+// Apply adds the ccmonitor hook to the settings file at path for every event
+// in Events that doesn't run it yet, or with uninstall removes it from all
+// events. It returns the events changed. Unless dryRun is set, a changed
+// file is written back, after saving the old one as path+".bak". A missing
+// file counts as empty settings. Adding the hook to a file that enables the
+// ccmonitor plugin fails with ErrPlugin.
+func Apply(path string, uninstall, dryRun bool) ([]string, error) {
+	data, settings, hooks, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	if !uninstall && pluginEnabled(settings) {
+		return nil, fmt.Errorf("%s: %w", path, ErrPlugin)
+	}
+
+	var changed []string
+	if uninstall {
+		changed = remove(hooks)
+	} else {
+		changed = add(hooks)
+	}
+	if len(changed) == 0 || dryRun {
+		return changed, nil
+	}
+	if len(hooks) == 0 {
+		delete(settings, "hooks")
+	} else {
+		settings["hooks"] = hooks
+	}
+	// Keep "2>/dev/null" readable rather than escaping the ">".
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(settings); err != nil {
+		return nil, err
+	}
+	if data != nil {
+		if err := os.WriteFile(path+".bak", data, 0600); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return changed, os.WriteFile(path, out.Bytes(), 0600)
+}
+
+// Missing returns the events in Events whose hooks in the settings file at
+// path don't run ccmonitor, and whether the ccmonitor plugin is enabled
+// there, which registers them all instead.
+func Missing(path string) (events []string, plugin bool, err error) {
+	_, settings, hooks, err := load(path)
+	if err != nil {
+		return nil, false, err
+	}
+	plugin = pluginEnabled(settings)
+	for _, event := range Events {
+		groups, _ := hooks[event].([]any)
+		if !hasOurs(groups) {
+			events = append(events, event)
+		}
+	}
+	return events, plugin, nil
+}
+
+// pluginEnabled reports whether settings enable the ccmonitor plugin, from
+// any marketplace.
+func pluginEnabled(settings map[string]any) bool {
+	enabled, _ := settings["enabledPlugins"].(map[string]any)
+	for name, on := range enabled {
+		if strings.HasPrefix(name, "ccmonitor@") && on == true {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads the settings file at path, returning its contents, the parsed
+// settings and their hooks object.
+func load(path string) ([]byte, map[string]any, map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, nil, err
+	}
+	settings := map[string]any{}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return nil, nil, nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	hooks, ok := settings["hooks"].(map[string]any)
+	if !ok {
+		if settings["hooks"] != nil {
+			return nil, nil, nil, fmt.Errorf("%s: \"hooks\" is not an object", path)
+		}
+		hooks = map[string]any{}
+	}
+	return data, settings, hooks, nil
+}
+
+// add appends a matcher group running Command to each event that has no
+// ccmonitor hook.
+func add(hooks map[string]any) []string {
+	var changed []string
+	for _, event := range Events {
+		groups, _ := hooks[event].([]any)
+		if hasOurs(groups) {
+			continue
+		}
+		hooks[event] = append(groups, map[string]any{
+			"matcher": "",
+			"hooks":   []any{map[string]any{"type": "command", "command": Command}},
+		})
+		changed = append(changed, event)
+	}
+	return changed
+}
+
+// remove drops the ccmonitor hooks from every event, and the matcher groups
+// and events left empty. Groups it can't make sense of are kept as they are.
+func remove(hooks map[string]any) []string {
+	var changed []string
+	for _, event := range slices.Sorted(maps.Keys(hooks)) {
+		groups, ok := hooks[event].([]any)
+		if !ok || !hasOurs(groups) {
+			continue
+		}
+		var kept []any
+		for _, g := range groups {
+			group, ok := g.(map[string]any)
+			if !ok {
+				kept = append(kept, g)
+				continue
+			}
+			list, ok := group["hooks"].([]any)
+			if !ok {
+				kept = append(kept, g) // not ours to fix
+				continue
+			}
+			var rest []any
+			for _, h := range list {
+				if !isOurs(h) {
+					rest = append(rest, h)
+				}
+			}
+			if len(rest) == 0 {
+				continue
+			}
+			group["hooks"] = rest
+			kept = append(kept, group)
+		}
+		if len(kept) == 0 {
+			delete(hooks, event)
+		} else {
+			hooks[event] = kept
+		}
+		changed = append(changed, event)
+	}
+	return changed
+}
+
+func hasOurs(groups []any) bool {
+	for _, g := range groups {
+		group, _ := g.(map[string]any)
+		list, _ := group["hooks"].([]any)
+		for _, h := range list {
+			if isOurs(h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isOurs reports whether a hook runs "ccmonitor hook", however it was
+// written: the plugin's command, a full path or ccmonitor.exe.
+func isOurs(h any) bool {
+	hook, _ := h.(map[string]any)
+	command, _ := hook["command"].(string)
+	for _, part := range strings.Split(command, "||") {
+		// The program may be a quoted path with spaces.
+		fields := strings.Fields(part)
+		i := slices.Index(fields, "hook")
+		if i < 1 {
+			continue
+		}
+		program := strings.Trim(strings.Join(fields[:i], " "), `"'`)
+		name := path.Base(strings.ReplaceAll(program, "\\", "/"))
+		if strings.TrimSuffix(name, ".exe") == "ccmonitor" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package install
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	t.Run("missing file should get every event", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".claude", "settings.json")
+		changed, err := Apply(path, false, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(changed, Events) {
+			t.Errorf("changed %v, want all events", changed)
+		}
+		data, _ := os.ReadFile(path)
+		if !strings.Contains(string(data), `"command": "`+Command+`"`) {
+			t.Errorf("settings should hold the unescaped command:\n%s", data)
+		}
+	})
+
+	existing := `{
+  "model": "opus",
+  "hooks": {
+    "Stop": [{"matcher": "", "hooks": [{"type": "command", "command": "say done"}]}],
+    "PreToolUse": [{"matcher": "", "hooks": [{"type": "command", "command": "/usr/local/bin/ccmonitor hook"}]}]
+  }
+}`
+
+	t.Run("other settings and hooks should be kept", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		os.WriteFile(path, []byte(existing), 0644)
+		changed, err := Apply(path, false, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if slices.Contains(changed, "PreToolUse") || !slices.Contains(changed, "Stop") {
+			t.Errorf("changed %v, want Stop but not the already hooked PreToolUse", changed)
+		}
+		settings := readSettings(t, path)
+		if settings["model"] != "opus" {
+			t.Error("other settings should be kept")
+		}
+		stop := settings["hooks"].(map[string]any)["Stop"].([]any)
+		if len(stop) != 2 {
+			t.Errorf("Stop has %d matcher groups, want the old one and ours", len(stop))
+		}
+		if bak, _ := os.ReadFile(path + ".bak"); string(bak) != existing {
+			t.Error("the old settings should be backed up")
+		}
+	})
+
+	t.Run("uninstall should remove only ccmonitor hooks", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		os.WriteFile(path, []byte(existing), 0644)
+		if _, err := Apply(path, false, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		changed, err := Apply(path, true, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(changed) != len(Events) {
+			t.Errorf("changed %v, want all events", changed)
+		}
+		hooks := readSettings(t, path)["hooks"].(map[string]any)
+		if len(hooks) != 1 || hooks["Stop"] == nil {
+			t.Errorf("hooks = %v, want only the say hook on Stop", hooks)
+		}
+	})
+
+	t.Run("uninstall should keep matcher groups without a hook list", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		os.WriteFile(path, []byte(`{"hooks": {"Stop": [
+  {"matcher": "odd"},
+  {"matcher": "", "hooks": "say done"},
+  {"matcher": "", "hooks": [{"type": "command", "command": "ccmonitor hook"}]}
+]}}`), 0644)
+		if _, err := Apply(path, true, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		stop := readSettings(t, path)["hooks"].(map[string]any)["Stop"].([]any)
+		if len(stop) != 2 || stop[1].(map[string]any)["hooks"] != "say done" {
+			t.Errorf("Stop = %v, want the two groups without a hook list kept", stop)
+		}
+	})
+
+	t.Run("dry run should not write", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		os.WriteFile(path, []byte(existing), 0644)
+		changed, err := Apply(path, false, true)
+		if err != nil || len(changed) == 0 {
+			t.Fatalf("changed %v, %v, want events to change", changed, err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != existing {
+			t.Error("dry run changed the file")
+		}
+	})
+
+	t.Run("an enabled plugin should stop the install", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		plugin := `{"enabledPlugins": {"ccmonitor@cc-plugins": true}}`
+		os.WriteFile(path, []byte(plugin), 0644)
+		if _, err := Apply(path, false, false); !errors.Is(err, ErrPlugin) {
+			t.Errorf("got %v, want ErrPlugin", err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != plugin {
+			t.Error("the settings should be left alone")
+		}
+		if _, err := Apply(path, true, false); err != nil {
+			t.Errorf("uninstall: unexpected error: %v", err)
+		}
+	})
+
+	t.Run("invalid JSON should be an error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		os.WriteFile(path, []byte("{oops"), 0644)
+		if _, err := Apply(path, false, false); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	os.WriteFile(path, []byte(`{"hooks": {"PreToolUse": [{"matcher": "", "hooks": [{"type": "command", "command": "ccmonitor hook"}]}]}}`), 0644)
+	missing, plugin, err := Missing(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(missing) != len(Events)-1 || slices.Contains(missing, "PreToolUse") || plugin {
+		t.Errorf("missing %v, plugin %v, want every event but PreToolUse and no plugin", missing, plugin)
+	}
+
+	os.WriteFile(path, []byte(`{"enabledPlugins": {"ccmonitor@cc-plugins": true}}`), 0644)
+	if _, plugin, _ := Missing(path); !plugin {
+		t.Error("the enabled plugin should be found")
+	}
+}
+
+func TestIsOurs(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{Command, true},
+		{"ccmonitor hook", true},
+		{`"C:\Program Files\ccmonitor\ccmonitor.exe" hook`, true},
+		{"ccmonitor statusline-hook", false},
+		{"my-ccmonitor hook", false},
+		{"say done", false},
+	}
+	for _, tt := range tests {
+		h := map[string]any{"type": "command", "command": tt.command}
+		if got := isOurs(h); got != tt.want {
+			t.Errorf("isOurs(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func readSettings(t *testing.T, path string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	return settings
+}
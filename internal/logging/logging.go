@@ -0,0 +1,87 @@
+// Package logging sets up the log/slog logger shared by the monitor and the
+// hook. Hooks run without a terminal anyone looks at, so their problems are
+// only visible in a log file; $CCMONITOR_LOG_FILE and $CCMONITOR_LOG_LEVEL
+// configure it for processes that don't take flags.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// DefaultLevel is the level used when none is configured.
+const DefaultLevel = "warn"
+
+// Levels are the accepted level names.
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// EnvLevel returns $CCMONITOR_LOG_LEVEL, or DefaultLevel.
+func EnvLevel() string {
+	if level := os.Getenv("CCMONITOR_LOG_LEVEL"); level != "" {
+		return level
+	}
+	return DefaultLevel
+}
+
+// EnvFile returns $CCMONITOR_LOG_FILE.
+func EnvFile() string {
+	return os.Getenv("CCMONITOR_LOG_FILE")
+}
+
+// ParseLevel parses a level name, case-insensitively.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (want one of %s)", name, strings.Join(Levels, ", "))
+}
+
+// Setup makes the default slog logger write records at level and above to
+// the file at path, appending, or to fallback when path is empty. The
+// returned function closes the file.
+func Setup(level, path string, fallback io.Writer) (func() error, error) {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	w, closeFn := fallback, func() error { return nil }
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("opening log file: %w", err)
+		}
+		w, closeFn = f, f.Close
+	}
+	slog.SetDefault(slog.New(newHandler(w, l, path == "")))
+	return closeFn, nil
+}
+
+// newHandler returns the handler Setup uses. Records carry the process ID,
+// since hooks of several sessions append to one file; without a file
+// (terse), they lose the timestamp, which a terminal doesn't need.
+func newHandler(w io.Writer, level slog.Level, terse bool) slog.Handler {
+	var replace func([]string, slog.Attr) slog.Attr
+	if terse {
+		replace = func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		}
+	}
+	h := slog.NewTextHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: replace})
+	if terse {
+		return h
+	}
+	return h.WithAttrs([]slog.Attr{slog.Int("pid", os.Getpid())})
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		want slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"warning", slog.LevelWarn},
+		{"error", slog.LevelError},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("ParseLevel(loud) should fail")
+	}
+}
+
+func TestSetup(t *testing.T) {
+	saved := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(saved) })
+
+	t.Run("records below the level should be dropped", func(t *testing.T) {
+		var buf bytes.Buffer
+		if _, err := Setup("warn", "", &buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		slog.Info("quiet")
+		slog.Warn("loud", "n", 1)
+		got := buf.String()
+		if strings.Contains(got, "quiet") || !strings.Contains(got, `msg=loud n=1`) {
+			t.Errorf("got %q, want only the warning", got)
+		}
+		if strings.Contains(got, "time=") {
+			t.Errorf("got %q, want no timestamp without a file", got)
+		}
+	})
+
+	t.Run("a log file should be appended to with timestamps and PIDs", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ccmonitor.log")
+		os.WriteFile(path, []byte("earlier\n"), 0644)
+		closeLog, err := Setup("debug", path, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		slog.Debug("hello")
+		closeLog()
+		data, _ := os.ReadFile(path)
+		got := string(data)
+		if !strings.HasPrefix(got, "earlier\n") || !strings.Contains(got, "time=") || !strings.Contains(got, "pid=") || !strings.Contains(got, "msg=hello") {
+			t.Errorf("got %q, want the old line and a full record", got)
+		}
+	})
+}
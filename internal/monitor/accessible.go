@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// statusWords are the spoken names of the statuses in accessible output.
+var statusWords = map[string]string{
+	session.StatusStarting:   "starting",
+	session.StatusWorking:    "working",
+	session.StatusCompacting: "compacting its context",
+	session.StatusIdle:       "idle",
+	session.StatusWaiting:    "waiting for input",
+	session.StatusLimited:    "usage limited",
+	session.StatusStalled:    "stalled",
+	session.StatusError:      "API error",
+	session.StatusExited:     "exited",
+	session.StatusEnded:      "ended",
+	session.StatusUntracked:  "untracked, no hooks",
+}
+
+// RenderAccessible renders sessions for screen readers: plain sentences with
+// explicit labels, no box drawing, glyphs or color, one project per
+// paragraph.
+func RenderAccessible(sessions []session.Session, now time.Time) string {
+	groups := session.GroupByProject(sessions, session.OrderByID)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s in %s.\n", plural(len(sessions), "session"), plural(len(groups), "project"))
+	for _, g := range groups {
+		fmt.Fprintf(&b, "\nProject %s, %s.\n", baseName(g.Project), plural(len(g.Sessions), "session"))
+		for _, s := range g.Sessions {
+			b.WriteString(accessibleSession(s, now) + "\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// accessibleSession describes one session in a single line.
+func accessibleSession(s session.Session, now time.Time) string {
+	parts := []string{"status: " + statusWord(s.Status)}
+	if s.Status == session.StatusWorking && s.Activity != "" {
+		parts[0] += ", " + activityWords[s.Activity]
+	}
+	if age, ok := statusAge(s, now); ok && age >= time.Minute {
+		parts[0] += " for " + strings.TrimSuffix(spokenAgo(age), " ago")
+	}
+	if s.Detail != "" {
+		parts = append(parts, "detail: "+s.Detail)
+	}
+	if t, err := time.Parse(time.RFC3339, s.LimitResetsAt); err == nil && s.Status == session.StatusLimited {
+		parts = append(parts, "resets at "+session.FormatTime(t, now))
+	}
+	if s.Queued > 0 {
+		parts = append(parts, plural(s.Queued, "queued message"))
+	}
+	if s.LastError != "" {
+		parts = append(parts, "last error: "+s.LastError)
+	}
+	if s.ContextLeft != nil {
+		parts = append(parts, fmt.Sprintf("context left: %d%%", *s.ContextLeft))
+	}
+	if s.Compacted != "" {
+		parts = append(parts, "compacted "+session.TimeSince(s.Compacted))
+	}
+	if s.Git != nil && s.Git.Branch != "" {
+		parts = append(parts, "branch: "+s.Git.Branch)
+	}
+	if s.LastPrompt != "" {
+		parts = append(parts, "prompt: "+session.Truncate(s.LastPrompt, 120))
+	}
+	if s.Note != "" {
+		parts = append(parts, "note: "+s.Note)
+	}
+	if t, err := time.Parse(time.RFC3339, s.LastActivity); err == nil {
+		parts = append(parts, "last activity "+spokenAgo(now.Sub(t)))
+	}
+	return strings.Join(parts, ". ") + "."
+}
+
+// announcement is the single line spoken for a status change.
+func announcement(c followChange) string {
+	text := fmt.Sprintf("%s: status: %s", baseName(c.state.project), statusWord(c.state.status))
+	if c.state.detail != "" {
+		text += ", " + c.state.detail
+	}
+	return text
+}
+
+// Accessible writes the RenderAccessible listing to w once, then announces
+// each status change on a single line. On a terminal the line is rewritten
+// in place so screen readers read only the latest change; otherwise every
+// announcement gets its own line. It runs until writing fails.
+func Accessible(w io.Writer, sessionsDirs []string, opts FollowOptions) error {
+	first := true
+	return watch(sessionsDirs, opts, func(sessions []session.Session, changes []followChange, now time.Time) error {
+		if first {
+			first = false
+			_, err := fmt.Fprintln(w, RenderAccessible(sessions, now)+"\n")
+			return err
+		}
+		for _, c := range changes {
+			var err error
+			if opts.Terminal {
+				_, err = fmt.Fprint(w, "\r\x1b[K"+announcement(c))
+			} else {
+				_, err = fmt.Fprintln(w, announcement(c))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// activityWords are the spoken forms of what a working session is doing.
+var activityWords = map[string]string{
+	session.ActivityThinking: "thinking",
+	session.ActivityTool:     "running a tool",
+}
+
+func statusWord(status string) string {
+	if w, ok := statusWords[status]; ok {
+		return w
+	}
+	return status
+}
+
+// plural formats a count with its noun, e.g. "1 session", "3 sessions".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// spokenAgo words a duration for speech ("5 minutes ago" rather than "5m").
+func spokenAgo(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d.Hours()), "hour") + " ago"
+	default:
+		return plural(int(d.Hours()/24), "day") + " ago"
+	}
+}
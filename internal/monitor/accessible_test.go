@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestRenderAccessible(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sessions := []session.Session{
+		{SessionID: "s1", Project: "/home/me/api", Status: session.StatusWaiting, Detail: "Permission: Bash",
+			LastActivity: now.Add(-5 * time.Minute).Format(time.RFC3339), Git: &session.Git{Branch: "main"}},
+		{SessionID: "s2", Project: "/home/me/web", Status: session.StatusLimited,
+			LimitResetsAt: now.Add(3 * time.Hour).Format(time.RFC3339)},
+	}
+	out := RenderAccessible(sessions, now)
+
+	t.Run("should use explicit words", func(t *testing.T) {
+		for _, want := range []string{
+			"2 sessions in 2 projects.",
+			"Project api, 1 session.",
+			"status: waiting for input. detail: Permission: Bash. branch: main. last activity 5 minutes ago.",
+			"status: usage limited. resets at 3:00 PM.",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("should not contain box drawing or status glyphs", func(t *testing.T) {
+		if strings.ContainsAny(out, "│╭╰─●◆○◌◷⧖✕") {
+			t.Errorf("output contains decorative glyphs:\n%s", out)
+		}
+	})
+}
+
+func TestAnnouncement(t *testing.T) {
+	c := followChange{sid: "s1", state: followState{status: session.StatusWaiting, detail: "Permission: Bash", project: "/home/me/api"}}
+	if got, want := announcement(c), "api: status: waiting for input, Permission: Bash"; got != want {
+		t.Errorf("announcement = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/tmux"
+)
+
+// borderStyles are the tmux border styles per status. Statuses without an
+// entry leave the pane's border alone.
+var borderStyles = map[string]string{
+	session.StatusStarting:   "fg=cyan",
+	session.StatusWorking:    "fg=green",
+	session.StatusCompacting: "fg=green",
+	session.StatusWaiting:    "fg=yellow",
+	session.StatusStalled:    "fg=brightmagenta",
+	session.StatusError:      "fg=brightred",
+	session.StatusLimited:    "fg=blue",
+	session.StatusExited:     "fg=red",
+}
+
+// paneBorders colors the tmux pane of each session by its status, so the
+// status is visible in tmux without looking at the monitor. It remembers
+// which panes it has styled so their borders can be restored.
+type paneBorders struct {
+	// mu orders tmux calls from background commands against restore, and
+	// closed stops commands that start after restore.
+	mu     sync.Mutex
+	closed bool
+	// applied maps pane ID to the style last set on it.
+	applied map[string]string
+	// set and reset are the tmux operations (replaced in tests).
+	set   func(paneID, style string) error
+	reset func(paneID string) error
+}
+
+func newPaneBorders() *paneBorders {
+	return &paneBorders{applied: map[string]string{}, set: tmux.SetBorder, reset: tmux.ResetBorder}
+}
+
+// borderChange is one pending tmux border update ("" style = reset).
+type borderChange struct {
+	pane, style string
+}
+
+// update works out which panes need a new border for the current sessions,
+// including resets for panes whose session is gone, and returns a command
+// that applies them. It returns nil when nothing changed.
+func (b *paneBorders) update(sessions []session.Session) tea.Cmd {
+	if b == nil {
+		return nil
+	}
+	want := map[string]string{}
+	for _, s := range sessions {
+		if pane := s.FindTerminalID("tmux"); pane != "" {
+			want[pane] = borderStyles[s.Status]
+		}
+	}
+	var changes []borderChange
+	for pane, style := range want {
+		if b.applied[pane] != style {
+			changes = append(changes, borderChange{pane, style})
+		}
+	}
+	for pane := range b.applied {
+		if _, ok := want[pane]; !ok {
+			changes = append(changes, borderChange{pane, ""})
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	for _, c := range changes {
+		if c.style == "" {
+			delete(b.applied, c.pane)
+		} else {
+			b.applied[c.pane] = c.style
+		}
+	}
+	return func() tea.Msg {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.closed {
+			return nil
+		}
+		// Errors are ignored: the pane may have closed since the last reload.
+		for _, c := range changes {
+			if c.style == "" {
+				b.reset(c.pane)
+			} else {
+				b.set(c.pane, c.style)
+			}
+		}
+		return nil
+	}
+}
+
+// restore resets every pane styled so far and stops further updates.
+func (b *paneBorders) restore() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for pane := range b.applied {
+		b.reset(pane)
+	}
+}
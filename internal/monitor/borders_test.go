@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestPaneBorders(t *testing.T) {
+	newFake := func() (*paneBorders, map[string]string) {
+		styles := map[string]string{}
+		b := newPaneBorders()
+		b.set = func(pane, style string) error { styles[pane] = style; return nil }
+		b.reset = func(pane string) error { delete(styles, pane); return nil }
+		return b, styles
+	}
+	inPane := func(pane, status string) session.Session {
+		return session.Session{
+			SessionID: pane,
+			Status:    status,
+			Terminals: []session.Terminal{{Backend: "tmux", ID: pane}},
+		}
+	}
+
+	t.Run("panes should be colored by status", func(t *testing.T) {
+		b, styles := newFake()
+		b.update([]session.Session{inPane("%1", session.StatusWorking), inPane("%2", session.StatusIdle)})()
+		if styles["%1"] != "fg=green" {
+			t.Errorf("%%1 style = %q, want fg=green", styles["%1"])
+		}
+		if _, ok := styles["%2"]; ok {
+			t.Error("idle pane should not be styled")
+		}
+	})
+
+	t.Run("unchanged statuses should not call tmux", func(t *testing.T) {
+		b, _ := newFake()
+		sessions := []session.Session{inPane("%1", session.StatusWaiting)}
+		b.update(sessions)()
+		if cmd := b.update(sessions); cmd != nil {
+			t.Error("expected no command when nothing changed")
+		}
+	})
+
+	t.Run("panes of idle or removed sessions should be reset", func(t *testing.T) {
+		b, styles := newFake()
+		b.update([]session.Session{inPane("%1", session.StatusWorking), inPane("%2", session.StatusWaiting)})()
+		b.update([]session.Session{inPane("%1", session.StatusIdle)})()
+		if len(styles) != 0 {
+			t.Errorf("styles = %v, want none", styles)
+		}
+	})
+
+	t.Run("restore should reset every styled pane and stop updates", func(t *testing.T) {
+		b, styles := newFake()
+		b.update([]session.Session{inPane("%1", session.StatusWorking)})()
+		pending := b.update([]session.Session{inPane("%1", session.StatusWorking), inPane("%2", session.StatusWaiting)})
+		b.restore()
+		pending()
+		if len(styles) != 0 {
+			t.Errorf("styles = %v, want none", styles)
+		}
+	})
+}
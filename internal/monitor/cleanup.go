@@ -0,0 +1,150 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// removeExitedFiles deletes the session files of exited sessions from dirs,
+// like the hook does on SessionStart, so they don't pile up until another
+// session starts. Only sessions started on this OS are removed, and only once alive
+// confirms their process is gone: a failed cross-OS check marks sessions
+// exited too. Returns the IDs of the sessions removed.
+func removeExitedFiles(dirs []string, sessions []session.Session, alive func(session.Session) bool) []string {
+	var removed []string
+	for _, s := range sessions {
+		if s.Status != session.StatusExited || s.PID <= 0 {
+			continue
+		}
+		if s.OS != "" && s.OS != runtime.GOOS {
+			continue
+		}
+		if alive(s) {
+			continue
+		}
+		if removeSessionFiles(dirs, s.SessionID) {
+			removed = append(removed, s.SessionID)
+		}
+	}
+	return removed
+}
+
+// removeSessionFiles deletes the file of the session with the given ID, and
+// its note, from each of dirs that has one.
+func removeSessionFiles(dirs []string, sessionID string) bool {
+	found := false
+	for _, dir := range dirs {
+		path := filepath.Join(dir, sessionID+".json")
+		if _, err := os.Stat(path); err == nil {
+			session.Remove(path)
+			found = true
+		}
+	}
+	return found
+}
+
+// quietFor reports whether s has had no activity for d. A session without
+// a readable last activity counts as quiet.
+func quietFor(s session.Session, d time.Duration, now time.Time) bool {
+	t, err := time.Parse(time.RFC3339, s.LastActivity)
+	return err != nil || now.Sub(t) >= d
+}
+
+// dropSessions returns sessions without those whose ID is in ids.
+func dropSessions(sessions []session.Session, ids []string) []session.Session {
+	if len(ids) == 0 {
+		return sessions
+	}
+	drop := map[string]bool{}
+	for _, id := range ids {
+		drop[id] = true
+	}
+	var kept []session.Session
+	for _, s := range sessions {
+		if !drop[s.SessionID] {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// cleanExited runs the clean action: it removes the files of the exited
+// sessions shown.
+func (m Model) cleanExited() (tea.Model, tea.Cmd) {
+	if !m.source.hooks() {
+		m.setStatus("Only the hooks' session files can be cleaned up")
+		return m, nil
+	}
+	if n := m.removeExited(); n > 0 {
+		m.setStatus(fmt.Sprintf("Removed %s", plural(n, "exited session")))
+	} else {
+		m.setStatus("No exited sessions to remove")
+	}
+	m.clickMap = buildClickTargets(m.sessions, m.renderPlain(), m.width, m.viewOptions())
+	return m, nil
+}
+
+// removeExited removes the files of the exited sessions shown and drops them
+// from the view. Returns how many were removed.
+func (m *Model) removeExited() int {
+	removed := removeExitedFiles(m.source.dirs, m.sessions, isNativePIDAlive)
+	m.sessions = dropSessions(m.sessions, removed)
+	return len(removed)
+}
+
+// CleanOptions selects the session files Clean removes. The zero value
+// selects all of them.
+type CleanOptions struct {
+	Statuses   []string      // only sessions with these statuses, as the monitor shows them (nil = all)
+	Project    string        // only sessions in this directory tree ("" = all)
+	StallAfter time.Duration // quiet period before a working session counts as stalled (0 = never)
+	OlderThan  time.Duration // only sessions with no activity for this long (0 = all)
+	DryRun     bool          // only report what would be removed
+}
+
+// Clean removes the session files in dir that opts selects, with their
+// notes, and returns their sessions (those it would remove, for a dry run).
+// Statuses are derived like the monitor does, so "exited" selects sessions
+// whose process has died and "stalled" quiet working ones.
+func Clean(dir string, opts CleanOptions) ([]session.Session, error) {
+	if err := CheckStatuses(opts.Statuses); err != nil {
+		return nil, err
+	}
+	var paths []string
+	var sessions []session.Session
+	err := session.ForEachSessionFile(dir, func(path string, s *session.Session) {
+		paths = append(paths, path)
+		sessions = append(sessions, *s)
+	})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	CheckPIDLiveness(sessions)
+	MarkStalled(sessions, opts.StallAfter, now)
+	var cleaned []session.Session
+	for i, s := range sessions {
+		if len(opts.Statuses) > 0 && !slices.Contains(opts.Statuses, s.Status) {
+			continue
+		}
+		if opts.OlderThan > 0 && !quietFor(s, opts.OlderThan, now) {
+			continue
+		}
+		if len(session.FilterProject([]session.Session{s}, opts.Project)) == 0 {
+			continue
+		}
+		if !opts.DryRun {
+			session.Remove(paths[i])
+		}
+		cleaned = append(cleaned, s)
+	}
+	return cleaned, nil
+}
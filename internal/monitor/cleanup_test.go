@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestRemoveExitedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, id := range []string{"dead", "alive", "idle", "other-os"} {
+		if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "dead.note"), []byte("note\n"), 0644)
+	otherOS := "windows"
+	if runtime.GOOS == "windows" {
+		otherOS = "linux"
+	}
+	sessions := []session.Session{
+		{SessionID: "dead", Status: session.StatusExited, PID: 100},
+		{SessionID: "alive", Status: session.StatusExited, PID: 200},
+		{SessionID: "idle", Status: session.StatusIdle, PID: 300},
+		{SessionID: "other-os", Status: session.StatusExited, PID: 400, OS: otherOS},
+	}
+	alive := func(s session.Session) bool { return s.PID == 200 }
+
+	removed := removeExitedFiles([]string{dir}, sessions, alive)
+	if len(removed) != 1 || removed[0] != "dead" {
+		t.Errorf("removed %v, want [dead]", removed)
+	}
+	for _, name := range []string{"dead.json", "dead.note"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should be gone", name)
+		}
+	}
+	for _, id := range []string{"alive", "idle", "other-os"} {
+		if _, err := os.Stat(filepath.Join(dir, id+".json")); err != nil {
+			t.Errorf("%s.json should be kept: %v", id, err)
+		}
+	}
+	if got := dropSessions(sessions, removed); len(got) != 3 || got[0].SessionID != "alive" {
+		t.Errorf("dropSessions = %+v, want the other three", got)
+	}
+}
+
+func TestCleanExitedBetweenPIDChecks(t *testing.T) {
+	dead := session.Session{SessionID: "dead", Project: "/home/user/api", Status: session.StatusIdle, PID: 99999999, OS: runtime.GOOS}
+
+	t.Run("the clean action should remove a session found exited earlier", func(t *testing.T) {
+		dir := writeSessions(t, dead)
+		// The project leaves out any real Claude Code process, untracked.
+		m, err := New([]string{dir}, Options{Project: "/home/user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		updated, _ := m.Update(tickMsg(time.Now()))
+		updated, _ = updated.(Model).do(actionClean)
+		if _, err := os.Stat(filepath.Join(dir, "dead.json")); !os.IsNotExist(err) {
+			t.Error("dead.json should be gone")
+		}
+		if got := updated.(Model).sessions; len(got) != 0 {
+			t.Errorf("sessions = %+v, want none", got)
+		}
+	})
+
+	t.Run("auto-clean should remove it on the next tick", func(t *testing.T) {
+		dir := writeSessions(t, dead)
+		m, err := New([]string{dir}, Options{Project: "/home/user", CleanExited: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Update(tickMsg(time.Now()))
+		if _, err := os.Stat(filepath.Join(dir, "dead.json")); !os.IsNotExist(err) {
+			t.Error("dead.json should be gone")
+		}
+	})
+}
+
+func TestClean(t *testing.T) {
+	newDir := func(t *testing.T) string {
+		dir := t.TempDir()
+		now := time.Now().UTC().Format(time.RFC3339)
+		for _, s := range []session.Session{
+			{SessionID: "idle-api", Project: "/home/user/api", Status: session.StatusIdle, LastActivity: now},
+			{SessionID: "idle-web", Project: "/home/user/web", Status: session.StatusIdle, LastActivity: now},
+			{SessionID: "dead-api", Project: "/home/user/api", Status: session.StatusWorking, LastActivity: now, PID: 99999999, OS: runtime.GOOS},
+		} {
+			data, _ := json.Marshal(s)
+			if err := os.WriteFile(filepath.Join(dir, s.SessionID+".json"), data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+	ids := func(sessions []session.Session) []string {
+		var ids []string
+		for _, s := range sessions {
+			ids = append(ids, s.SessionID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	t.Run("statuses and project should select the sessions", func(t *testing.T) {
+		dir := newDir(t)
+		cleaned, err := Clean(dir, CleanOptions{Statuses: []string{session.StatusIdle, session.StatusExited}, Project: "/home/user/api"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := ids(cleaned); !slices.Equal(got, []string{"dead-api", "idle-api"}) {
+			t.Errorf("cleaned %v, want dead-api and idle-api", got)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "idle-web.json")); err != nil {
+			t.Errorf("idle-web.json should be kept: %v", err)
+		}
+	})
+
+	t.Run("dry run should remove nothing", func(t *testing.T) {
+		dir := newDir(t)
+		cleaned, _ := Clean(dir, CleanOptions{DryRun: true})
+		if len(cleaned) != 3 {
+			t.Errorf("cleaned %v, want all three", ids(cleaned))
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 3 {
+			t.Errorf("%d files left, want 3", len(entries))
+		}
+	})
+
+	t.Run("older than should keep recent sessions", func(t *testing.T) {
+		dir := newDir(t)
+		old := session.Session{SessionID: "old-api", Project: "/home/user/api", Status: session.StatusIdle, LastActivity: time.Now().Add(-3 * time.Hour).UTC().Format(time.RFC3339)}
+		data, _ := json.Marshal(old)
+		os.WriteFile(filepath.Join(dir, old.SessionID+".json"), data, 0644)
+		cleaned, err := Clean(dir, CleanOptions{OlderThan: 2 * time.Hour})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := ids(cleaned); !slices.Equal(got, []string{"old-api"}) {
+			t.Errorf("cleaned %v, want only old-api", got)
+		}
+	})
+
+	t.Run("unknown statuses should be rejected", func(t *testing.T) {
+		if _, err := Clean(newDir(t), CleanOptions{Statuses: []string{"dead"}}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
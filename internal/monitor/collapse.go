@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// DefaultCollapseAfter is how long a session may stay idle before its row
+// collapses to a single faint line.
+const DefaultCollapseAfter = 30 * time.Minute
+
+// collapsible reports whether s has been idle for longer than after (0 =
+// never collapse).
+func collapsible(s session.Session, after time.Duration, now time.Time) bool {
+	if after <= 0 || s.Status != session.StatusIdle {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, s.LastActivity)
+	return err == nil && now.Sub(t) > after
+}
+
+// collapsed reports whether s is shown as a single line: long idle and not
+// opened with the expand action.
+func (opts viewOptions) collapsed(s session.Session, now time.Time) bool {
+	return !opts.opened[s.SessionID] && collapsible(s, opts.collapse, now)
+}
+
+// renderCollapsed renders the row as one faint line: connector, labels, the
+// prompt or title, and the time since the last activity on the right.
+func (r sessionRow) renderCollapsed(w columnWidths, hovered bool) string {
+	conn, text := faintStyle.Render(r.connector), faintStyle
+	if hovered {
+		conn, text = boldStyle.Render(r.connector), faintStyle.Bold(true)
+	}
+	var label string
+	if r.project != "" {
+		label += r.project + " "
+	}
+	if r.worktree != "" {
+		label += "⎇ " + r.worktree + " "
+	}
+	prompt := r.prompt
+	if prompt == "" {
+		prompt = "…"
+	}
+	elapsed := "idle " + session.TimeSince(r.rawLastActivity)
+	line := "○ " + label + prompt
+	if w.contentWidth > 0 {
+		available := w.contentWidth - w.conn - 1 - lipgloss.Width(elapsed) - 2
+		if lipgloss.Width(line) > available && available > 1 {
+			line = ansi.Truncate(line, available-1, "") + "…"
+		}
+		if gap := available - lipgloss.Width(line); gap > 0 {
+			line += strings.Repeat(" ", gap)
+		}
+	}
+	return padRight(conn, w.conn) + " " + text.Render(line+"  "+elapsed) + "\n"
+}
+
+// pruneOpened forgets opened sessions that are gone or no longer idle, so
+// they collapse again the next time they sit idle.
+func pruneOpened(opened map[string]bool, sessions []session.Session) {
+	idle := map[string]bool{}
+	for _, s := range sessions {
+		if s.Status == session.StatusIdle {
+			idle[s.SessionID] = true
+		}
+	}
+	for sid := range opened {
+		if !idle[sid] {
+			delete(opened, sid)
+		}
+	}
+}
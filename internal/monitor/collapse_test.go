@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestCollapse(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) string { return now.Add(-d).Format(time.RFC3339) }
+	old := session.Session{SessionID: "old-1111", Project: "/p", Status: session.StatusIdle, LastPrompt: "Tidy up", LastActivity: ago(2 * time.Hour)}
+	recent := session.Session{SessionID: "new-2222", Project: "/p", Status: session.StatusIdle, LastPrompt: "Deploy", LastActivity: ago(time.Minute)}
+
+	t.Run("only sessions idle for longer than the threshold should collapse", func(t *testing.T) {
+		working := old
+		working.Status = session.StatusWorking
+		for _, tt := range []struct {
+			s     session.Session
+			after time.Duration
+			want  bool
+		}{
+			{old, time.Hour, true},
+			{recent, time.Hour, false},
+			{working, time.Hour, false},
+			{old, 0, false},
+		} {
+			if got := collapsible(tt.s, tt.after, now); got != tt.want {
+				t.Errorf("collapsible(%s, %v) = %v, want %v", tt.s.SessionID, tt.after, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("collapsed row should be a single line and map to one click line", func(t *testing.T) {
+		opts := viewOptions{collapse: time.Hour}
+		sessions := []session.Session{old, recent}
+		view := render(sessions, spinner.New(), 100, nil, "", opts, "", nil)
+		plain := ansi.Strip(view)
+		if !strings.Contains(plain, "○ Tidy up") || !strings.Contains(plain, "idle 2h") {
+			t.Errorf("expected a collapsed line for the old session, got:\n%s", plain)
+		}
+		clicks := buildClickMap(sessions, plain, opts)
+		lines := map[string]int{}
+		for _, sid := range clicks {
+			lines[sid]++
+		}
+		if lines["old-1111"] != 1 || lines["new-2222"] != 2 {
+			t.Errorf("click lines = %v, want 1 for the collapsed row and 2 for the full one", lines)
+		}
+	})
+
+	t.Run("expand should open a collapsed session and close it again", func(t *testing.T) {
+		m := Model{sessions: []session.Session{old}, collapse: time.Hour, hoverSID: "old-1111"}
+		next, _ := m.do(actionExpand)
+		if !next.(Model).viewOptions().opened["old-1111"] {
+			t.Fatal("expand should open the collapsed session")
+		}
+		next, _ = next.(Model).do(actionExpand)
+		if next.(Model).viewOptions().opened["old-1111"] {
+			t.Error("expand again should collapse it")
+		}
+	})
+
+	t.Run("opened sessions should be forgotten once they are no longer idle", func(t *testing.T) {
+		opened := map[string]bool{"old-1111": true, "gone": true}
+		busy := old
+		busy.Status = session.StatusWorking
+		pruneOpened(opened, []session.Session{busy})
+		if len(opened) != 0 {
+			t.Errorf("opened = %v, want empty", opened)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/martinwickman/ccmonitor/internal/config"
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// columnTimeout bounds how long a command column may run.
+const columnTimeout = 5 * time.Second
+
+// columnKey identifies one command column's value for one session.
+type columnKey struct {
+	col int
+	sid string
+}
+
+// columnValue is a cached command column result.
+type columnValue struct {
+	text string
+	at   time.Time
+}
+
+// columnResultMsg carries the output of a command column run.
+type columnResultMsg struct {
+	key  columnKey
+	text string
+	at   time.Time
+}
+
+// columnSet evaluates the user-defined columns from the config file.
+// Template columns are evaluated on every render. Command columns run in the
+// background on ticks and their output is reused until it is older than the
+// column's Every, so a slow command never blocks the UI.
+type columnSet struct {
+	cols     []config.Column
+	tmpls    []*template.Template // nil for command columns
+	values   map[columnKey]columnValue
+	inflight map[columnKey]bool
+}
+
+var columnFuncs = template.FuncMap{
+	"base": filepath.Base,
+	"trunc": func(n int, s string) string {
+		return session.Truncate(s, n)
+	},
+}
+
+func newColumnSet(cols []config.Column) (*columnSet, error) {
+	c := &columnSet{
+		cols:     cols,
+		tmpls:    make([]*template.Template, len(cols)),
+		values:   map[columnKey]columnValue{},
+		inflight: map[columnKey]bool{},
+	}
+	for i, col := range cols {
+		if col.Template == "" {
+			continue
+		}
+		t, err := template.New(col.Name).Funcs(columnFuncs).Option("missingkey=zero").Parse(col.Template)
+		if err != nil {
+			return nil, err
+		}
+		c.tmpls[i] = t
+	}
+	return c, nil
+}
+
+// cells returns the "name value" cells for every session with at least one
+// non-empty column, keyed by session ID.
+func (c *columnSet) cells(sessions []session.Session) map[string][]string {
+	if c == nil || len(c.cols) == 0 {
+		return nil
+	}
+	out := map[string][]string{}
+	for _, s := range sessions {
+		var cells []string
+		for i, col := range c.cols {
+			var text string
+			if t := c.tmpls[i]; t != nil {
+				var b strings.Builder
+				if t.Execute(&b, s) == nil {
+					text = b.String()
+				}
+			} else {
+				text = c.values[columnKey{i, s.SessionID}].text
+			}
+			if text = strings.TrimSpace(text); text != "" {
+				cells = append(cells, col.Name+" "+text)
+			}
+		}
+		if cells != nil {
+			out[s.SessionID] = cells
+		}
+	}
+	return out
+}
+
+// refresh starts command columns whose value is missing or stale, and drops
+// values for sessions that are gone.
+func (c *columnSet) refresh(sessions []session.Session, now time.Time) []tea.Cmd {
+	if c == nil {
+		return nil
+	}
+	current := map[string]bool{}
+	var cmds []tea.Cmd
+	for _, s := range sessions {
+		current[s.SessionID] = true
+		for i, col := range c.cols {
+			if col.Command == "" {
+				continue
+			}
+			key := columnKey{i, s.SessionID}
+			if v, ok := c.values[key]; c.inflight[key] || (ok && now.Sub(v.at) < col.Every) {
+				continue
+			}
+			c.inflight[key] = true
+			cmds = append(cmds, runColumn(key, col.Command, s))
+		}
+	}
+	for key := range c.values {
+		if !current[key.sid] {
+			delete(c.values, key)
+		}
+	}
+	return cmds
+}
+
+// store records a finished command column run.
+func (c *columnSet) store(msg columnResultMsg) {
+	delete(c.inflight, msg.key)
+	c.values[msg.key] = columnValue{text: msg.text, at: msg.at}
+}
+
+// runColumn runs a command column for one session.
+func runColumn(key columnKey, command string, s session.Session) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), columnTimeout)
+		defer cancel()
+		out, err := runShell(ctx, command, s).Output()
+		text := ""
+		if err == nil {
+			text, _, _ = strings.Cut(string(out), "\n")
+		} else {
+			slog.Debug("column command failed", "command", command, "session", s.SessionID, "err", err)
+		}
+		return columnResultMsg{key: key, text: text, at: time.Now()}
+	}
+}
+
+// runShell prepares command to run through the shell for a session, in its
+// project directory when that exists. Session fields are passed as
+// environment variables rather than substituted into the command, so prompt
+// text can never be interpreted by the shell.
+func runShell(ctx context.Context, command string, s session.Session) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"CCMONITOR_SESSION_ID="+s.SessionID,
+		"CCMONITOR_PROJECT="+s.Project,
+		"CCMONITOR_STATUS="+s.Status,
+		"CCMONITOR_PID="+strconv.Itoa(s.PID),
+	)
+	if s.Git != nil {
+		cmd.Env = append(cmd.Env, "CCMONITOR_BRANCH="+s.Git.Branch, "CCMONITOR_WORKTREE="+s.Git.Worktree)
+	}
+	if info, err := os.Stat(s.Project); err == nil && info.IsDir() {
+		cmd.Dir = s.Project
+	}
+	return cmd
+}
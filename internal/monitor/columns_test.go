@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/config"
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestColumnSet(t *testing.T) {
+	sessions := []session.Session{
+		{SessionID: "s1", Project: "/home/me/api", Git: &session.Git{Branch: "feature/login"}},
+		{SessionID: "s2", Project: "/home/me/web"},
+	}
+
+	t.Run("template column should render per session", func(t *testing.T) {
+		c, err := newColumnSet([]config.Column{{Name: "br", Template: "{{with .Git}}{{.Branch}}{{end}}"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cells := c.cells(sessions)
+		if got := cells["s1"]; len(got) != 1 || got[0] != "br feature/login" {
+			t.Errorf("s1 cells = %v, want [br feature/login]", got)
+		}
+		if _, ok := cells["s2"]; ok {
+			t.Error("session with empty value should have no cells")
+		}
+	})
+
+	t.Run("invalid template should be an error", func(t *testing.T) {
+		if _, err := newColumnSet([]config.Column{{Name: "x", Template: "{{.Nope"}}); err == nil {
+			t.Error("expected parse error")
+		}
+	})
+
+	t.Run("command column should run once per interval", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("uses sh")
+		}
+		c, _ := newColumnSet([]config.Column{{Name: "dir", Command: `basename "$CCMONITOR_PROJECT"`, Every: time.Minute}})
+		now := time.Now()
+
+		cmds := c.refresh(sessions[:1], now)
+		if len(cmds) != 1 {
+			t.Fatalf("got %d commands, want 1", len(cmds))
+		}
+		if again := c.refresh(sessions[:1], now); len(again) != 0 {
+			t.Error("command should not start again while in flight")
+		}
+		c.store(cmds[0]().(columnResultMsg))
+
+		if got := c.cells(sessions[:1])["s1"]; len(got) != 1 || got[0] != "dir api" {
+			t.Errorf("cells = %v, want [dir api]", got)
+		}
+		if again := c.refresh(sessions[:1], now.Add(30*time.Second)); len(again) != 0 {
+			t.Error("fresh value should be reused")
+		}
+		if again := c.refresh(sessions[:1], now.Add(2*time.Minute)); len(again) != 1 {
+			t.Error("stale value should be refreshed")
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// FollowOptions configures Follow.
+type FollowOptions struct {
+	Project    string        // only follow sessions in this directory tree ("" = all)
+	Ignore     []string      // project directories or patterns to leave out
+	StallAfter time.Duration // quiet period before a working session counts as stalled (0 = never)
+	// Transcripts is read instead of the sessions directories when set.
+	Transcripts string
+	// Demo follows made-up sessions instead.
+	Demo bool
+	// Terminal reports that the output is a terminal: Follow then colors
+	// the status words and Accessible rewrites its announcement line in place.
+	Terminal bool
+}
+
+// Follow prints one line per session status change to w until writing
+// fails: first the current state of every session, then each change as it
+// is picked up, including sessions that disappear (shown as ended).
+func Follow(w io.Writer, sessionsDirs []string, opts FollowOptions) error {
+	return watch(sessionsDirs, opts, func(_ []session.Session, changes []followChange, now time.Time) error {
+		for _, c := range changes {
+			if _, err := fmt.Fprintln(w, followLine(now, c.sid, c.state, opts.Terminal)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// watch polls the sessions directories at the same rate as the TUI and calls
+// handle with the sessions and what changed since the previous poll (on the
+// first poll, every session). It returns the first error from handle.
+func watch(sessionsDirs []string, opts FollowOptions, handle func(sessions []session.Session, changes []followChange, now time.Time) error) error {
+	last := map[string]followState{}
+	loader := NewLoader(sessionsDirs, LoaderOptions{
+		Project:     opts.Project,
+		Ignore:      opts.Ignore,
+		Transcripts: opts.Transcripts,
+		Demo:        opts.Demo,
+		Untracked:   true,
+	})
+	for {
+		now := time.Now()
+		sessions, _ := loader.Load(now)
+		MarkStalled(sessions, opts.StallAfter, now)
+		if err := handle(sessions, followChanges(last, sessions), now); err != nil {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// followState is what watch remembers per session to detect changes.
+type followState struct {
+	status, detail, project string
+}
+
+// followChange is a session's new state as reported by followChanges.
+type followChange struct {
+	sid   string
+	state followState
+}
+
+// followChanges returns every session that is new, changed status or detail,
+// or disappeared since last (as ended), and updates last to match sessions.
+func followChanges(last map[string]followState, sessions []session.Session) []followChange {
+	var changes []followChange
+	current := map[string]bool{}
+	for _, s := range sessions {
+		current[s.SessionID] = true
+		state := followState{status: s.Status, detail: s.Detail, project: s.Project}
+		if prev, ok := last[s.SessionID]; ok && prev == state {
+			continue
+		}
+		last[s.SessionID] = state
+		changes = append(changes, followChange{s.SessionID, state})
+	}
+	var gone []string
+	for sid := range last {
+		if !current[sid] {
+			gone = append(gone, sid)
+		}
+	}
+	sort.Strings(gone)
+	for _, sid := range gone {
+		state := followState{status: session.StatusEnded, project: last[sid].project}
+		delete(last, sid)
+		changes = append(changes, followChange{sid, state})
+	}
+	return changes
+}
+
+// followLine formats one change: time, status, project, detail and session
+// ID, separated by spaces so the output is easy to grep and cut.
+func followLine(now time.Time, sid string, state followState, styled bool) string {
+	status := fmt.Sprintf("%-8s", state.status)
+	if styled {
+		_, style, _ := statusDisplay(state.status, spinner.New())
+		status = style.Render(status)
+	}
+	parts := []string{session.In(now).Format(time.RFC3339), status, baseName(state.project)}
+	if state.detail != "" {
+		parts = append(parts, state.detail)
+	}
+	parts = append(parts, "("+sid+")")
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,61 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestFollowChanges(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	working := session.Session{SessionID: "s1", Project: "/home/me/api", Status: session.StatusWorking, Detail: "Running: go test"}
+	format := func(changes []followChange) []string {
+		var out []string
+		for _, c := range changes {
+			out = append(out, followLine(now, c.sid, c.state, false))
+		}
+		return out
+	}
+
+	t.Run("new sessions should be printed", func(t *testing.T) {
+		last := map[string]followState{}
+		lines := format(followChanges(last, []session.Session{working}))
+		want := "2026-03-01T12:00:00Z working  api Running: go test (s1)"
+		if len(lines) != 1 || lines[0] != want {
+			t.Errorf("lines = %q, want [%q]", lines, want)
+		}
+	})
+
+	t.Run("unchanged sessions should not be printed again", func(t *testing.T) {
+		last := map[string]followState{}
+		followChanges(last, []session.Session{working})
+		if lines := format(followChanges(last, []session.Session{working})); len(lines) != 0 {
+			t.Errorf("lines = %q, want none", lines)
+		}
+	})
+
+	t.Run("status changes should be printed", func(t *testing.T) {
+		last := map[string]followState{}
+		followChanges(last, []session.Session{working})
+		idle := working
+		idle.Status, idle.Detail = session.StatusIdle, ""
+		lines := format(followChanges(last, []session.Session{idle}))
+		if len(lines) != 1 || !strings.Contains(lines[0], " idle ") {
+			t.Errorf("lines = %q, want one idle line", lines)
+		}
+	})
+
+	t.Run("removed sessions should be printed as ended", func(t *testing.T) {
+		last := map[string]followState{}
+		followChanges(last, []session.Session{working})
+		lines := format(followChanges(last, nil))
+		if len(lines) != 1 || !strings.Contains(lines[0], "ended") || !strings.Contains(lines[0], " api ") {
+			t.Errorf("lines = %q, want one ended line for api", lines)
+		}
+		if len(last) != 0 {
+			t.Error("removed session should be forgotten")
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// hideSession runs the hide action: it takes the hovered session out of the
+// view until its status changes, without touching its file.
+func (m Model) hideSession() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok {
+		m.setStatus("Hover over a session to hide it")
+		return m, nil
+	}
+	if m.hidden == nil {
+		m.hidden = map[string]string{}
+	}
+	m.hidden[s.SessionID] = s.Status
+	m.sessions = filterHidden(m.sessions, m.hidden)
+	m.hoverSID = ""
+	m.clickMap = buildClickTargets(m.sessions, m.renderPlain(), m.width, m.viewOptions())
+	m.setStatus(fmt.Sprintf("Hid %s until it changes", m.names.name(s.Project)))
+	return m, nil
+}
+
+// unhideAll runs the unhide action: hidden sessions show again from the next
+// refresh.
+func (m Model) unhideAll() (tea.Model, tea.Cmd) {
+	if len(m.hidden) == 0 {
+		m.setStatus("No hidden sessions")
+		return m, nil
+	}
+	m.setStatus(fmt.Sprintf("Showing %s again", plural(len(m.hidden), "hidden session")))
+	m.hidden = nil
+	return m, nil
+}
+
+// filterHidden drops the hidden sessions from sessions. hidden maps session
+// IDs to the status they had when hidden; a session whose status has changed
+// since, say an idle one now waiting for permission, is shown again and
+// forgotten, as are sessions that are gone.
+func filterHidden(sessions []session.Session, hidden map[string]string) []session.Session {
+	if len(hidden) == 0 {
+		return sessions
+	}
+	seen := map[string]bool{}
+	var out []session.Session
+	for _, s := range sessions {
+		status, ok := hidden[s.SessionID]
+		if ok && status == s.Status {
+			seen[s.SessionID] = true
+			continue
+		}
+		out = append(out, s)
+	}
+	for sid := range hidden {
+		if !seen[sid] {
+			delete(hidden, sid)
+		}
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestHide(t *testing.T) {
+	sessions := []session.Session{
+		{SessionID: "s1", Project: "/p/api", Status: session.StatusIdle},
+		{SessionID: "s2", Project: "/p/web", Status: session.StatusIdle},
+	}
+
+	t.Run("hidden session should leave the view and be counted", func(t *testing.T) {
+		got, _ := Model{sessions: sessions, hoverSID: "s1"}.do(actionHide)
+		m := got.(Model)
+		if len(m.sessions) != 1 || m.sessions[0].SessionID != "s2" {
+			t.Fatalf("sessions = %v, want only s2", m.sessions)
+		}
+		if m.viewOptions().hidden != 1 {
+			t.Errorf("hidden = %d, want 1", m.viewOptions().hidden)
+		}
+		got, _ = m.do(actionUnhide)
+		if m = got.(Model); m.hidden != nil {
+			t.Errorf("hidden = %v, want none after unhide", m.hidden)
+		}
+	})
+
+	t.Run("session should come back when its status changes", func(t *testing.T) {
+		hidden := map[string]string{"s1": session.StatusIdle, "gone": session.StatusIdle}
+		waiting := []session.Session{sessions[0], sessions[1]}
+		waiting[0].Status = session.StatusWaiting
+		if got := filterHidden(waiting, hidden); len(got) != 2 {
+			t.Errorf("got %d sessions, want the waiting one shown again", len(got))
+		}
+		if len(hidden) != 0 {
+			t.Errorf("hidden = %v, want changed and gone sessions forgotten", hidden)
+		}
+	})
+}
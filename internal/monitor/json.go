@@ -0,0 +1,32 @@
+package monitor
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// jsonSession is a session as "ccmonitor --once --json" prints it: the
+// session file's fields with the status the monitor derives, plus what
+// scripts would otherwise have to work out themselves.
+type jsonSession struct {
+	session.Session
+	Note       string `json:"note,omitempty"`
+	AgeSeconds int64  `json:"age_seconds"` // since the last activity
+	Alive      bool   `json:"alive"`       // the process is still running
+}
+
+// RenderJSON renders sessions as an indented JSON array, most in need of
+// attention first. Liveness and stalls should already be checked.
+func RenderJSON(sessions []session.Session, now time.Time) ([]byte, error) {
+	out := []jsonSession{}
+	for _, s := range session.SortByAttention(sessions) {
+		js := jsonSession{Session: s, Note: s.Note, Alive: s.Status != session.StatusExited && s.Status != session.StatusEnded}
+		if t, err := time.Parse(time.RFC3339, s.LastActivity); err == nil {
+			js.AgeSeconds = int64(now.Sub(t).Seconds())
+		}
+		out = append(out, js)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestRenderJSON(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sessions := []session.Session{
+		{SessionID: "s1", Project: "/p/api", Status: session.StatusExited, LastActivity: "2026-03-01T11:00:00Z"},
+		{SessionID: "s2", Project: "/p/web", Status: session.StatusWaiting, LastActivity: "2026-03-01T11:59:30Z", Note: "review css"},
+	}
+	data, err := RenderJSON(sessions, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, data)
+	}
+	if len(got) != 2 || got[0]["session_id"] != "s2" {
+		t.Fatalf("got %s, want s2 first", data)
+	}
+	for _, tt := range []struct {
+		i     int
+		key   string
+		value any
+	}{
+		{0, "status", "waiting"},
+		{0, "note", "review css"},
+		{0, "age_seconds", 30.0},
+		{0, "alive", true},
+		{1, "alive", false},
+		{1, "age_seconds", 3600.0},
+	} {
+		if got[tt.i][tt.key] != tt.value {
+			t.Errorf("session %d: %s = %v, want %v", tt.i, tt.key, got[tt.i][tt.key], tt.value)
+		}
+	}
+
+	t.Run("no sessions should be an empty array", func(t *testing.T) {
+		if data, _ := RenderJSON(nil, now); string(data) != "[]" {
+			t.Errorf("got %s, want []", data)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/martinwickman/ccmonitor/internal/config"
+)
+
+// Built-in actions that keys can be bound to. The names are used as keys in
+// the "keys" object of the config file.
+const (
+	actionQuit           = "quit"
+	actionSummary        = "summary"
+	actionFlat           = "flat"
+	actionByStatus       = "by_status"
+	actionSort           = "sort"
+	actionHide           = "hide"
+	actionUnhide         = "unhide"
+	actionExpand         = "expand"
+	actionView           = "view"
+	actionTranscript     = "transcript"
+	actionCopy           = "copy"
+	actionReply          = "reply"
+	actionInterrupt      = "interrupt"
+	actionNote           = "note"
+	actionLaunch         = "launch"
+	actionApprove        = "approve"
+	actionApproveSession = "approve_session"
+	actionDeny           = "deny"
+	actionMenu           = "menu"
+	actionSwitch         = "switch"
+	actionKill           = "kill"
+	actionClean          = "clean"
+	actionCopyID         = "copy_id"
+	actionEditor         = "editor"
+	actionReveal         = "reveal"
+	actionDown           = "down"
+	actionUp             = "up"
+	actionPrevProject    = "prev_project"
+	actionNextProject    = "next_project"
+	actionTop            = "top"
+	actionBottom         = "bottom"
+	actionPageUp         = "page_up"
+	actionPageDown       = "page_down"
+	actionSearch         = "search"
+	actionCommand        = "command"
+	actionFilterWorking  = "filter_working"
+	actionFilterWaiting  = "filter_waiting"
+	actionFilterIdle     = "filter_idle"
+	actionFilterExited   = "filter_exited"
+)
+
+// defaultKeys are the bindings used for actions the config doesn't rebind.
+var defaultKeys = map[string][]string{
+	actionQuit:           {"q", "ctrl+c"},
+	actionSummary:        {"p"},
+	actionFlat:           {"s"},
+	actionByStatus:       {"t"},
+	actionSort:           {"S"},
+	actionHide:           {"H"},
+	actionUnhide:         {"U"},
+	actionExpand:         {"z"}, // as in vim folds
+	actionView:           {"v", "d"},
+	actionTranscript:     {"T"},
+	actionCopy:           {"c"},
+	actionReply:          {"r"},
+	actionInterrupt:      {"i"},
+	actionNote:           {"n"},
+	actionLaunch:         {"L"},
+	actionApprove:        {"y", "1"},
+	actionApproveSession: {"2"},
+	actionDeny:           {"3"},
+	actionMenu:           {"m"},
+	actionSwitch:         {"enter"},
+	actionKill:           {"K"},
+	actionClean:          {"X"},
+	actionCopyID:         {},
+	actionEditor:         {"e"},
+	actionReveal:         {"f"},
+	actionDown:           {"j", "down"},
+	actionUp:             {"k", "up"},
+	actionPrevProject:    {"h", "left"},
+	actionNextProject:    {"l", "right"},
+	actionTop:            {"g", "home"}, // so gg works as in vim
+	actionBottom:         {"G", "end"},
+	actionPageUp:         {"pgup", "ctrl+b"},
+	actionPageDown:       {"pgdown", "ctrl+f"},
+	actionSearch:         {"/"},
+	actionCommand:        {":"},
+	actionFilterWorking:  {"W"},
+	actionFilterWaiting:  {"A"}, // needs attention
+	actionFilterIdle:     {"I"},
+	actionFilterExited:   {"E"},
+}
+
+var defaultKeymap, _ = newKeymap(nil, nil)
+
+// keymap resolves key presses to built-in actions or custom commands.
+type keymap struct {
+	actions map[string]string   // key → built-in action
+	keys    map[string][]string // built-in action → keys
+	custom  map[string]config.Action
+	// ordered lists the custom actions in config file order, for the menu.
+	ordered []config.Action
+}
+
+// newKeymap merges the configured bindings over the defaults. It fails on
+// unknown action names and on keys bound to more than one action.
+func newKeymap(keys map[string][]string, actions []config.Action) (keymap, error) {
+	km := keymap{
+		actions: map[string]string{},
+		keys:    map[string][]string{},
+		custom:  map[string]config.Action{},
+	}
+	for action, def := range defaultKeys {
+		km.keys[action] = def
+	}
+	for action, ks := range keys {
+		if _, ok := defaultKeys[action]; !ok {
+			return keymap{}, fmt.Errorf("keys: unknown action %q", action)
+		}
+		km.keys[action] = ks
+	}
+
+	// Bind in sorted order so conflict errors are deterministic.
+	names := make([]string, 0, len(km.keys))
+	for action := range km.keys {
+		names = append(names, action)
+	}
+	sort.Strings(names)
+	for _, action := range names {
+		for _, k := range km.keys[action] {
+			if other, ok := km.actions[k]; ok {
+				return keymap{}, fmt.Errorf("keys: %q is bound to both %s and %s", k, other, action)
+			}
+			km.actions[k] = action
+		}
+	}
+	for _, a := range actions {
+		if other, ok := km.actions[a.Key]; ok {
+			return keymap{}, fmt.Errorf("actions: %q is already bound to %s", a.Key, other)
+		}
+		if other, ok := km.custom[a.Key]; ok {
+			return keymap{}, fmt.Errorf("actions: %q is bound to both %s and %s", a.Key, other.Name, a.Name)
+		}
+		km.custom[a.Key] = a
+		km.ordered = append(km.ordered, a)
+	}
+	return km, nil
+}
+
+// action returns the built-in action bound to key ("" if none). A zero
+// keymap uses the default bindings.
+func (km keymap) action(key string) string {
+	if km.actions == nil {
+		km = defaultKeymap
+	}
+	return km.actions[key]
+}
+
+// bound returns the keys bound to action. A zero keymap reports the defaults.
+func (km keymap) bound(action string) []string {
+	if km.keys == nil {
+		return defaultKeys[action]
+	}
+	return km.keys[action]
+}
+
+// key returns the first key bound to action, for help text ("" if unbound).
+func (km keymap) key(action string) string {
+	if ks := km.bound(action); len(ks) > 0 {
+		return ks[0]
+	}
+	return ""
+}
+
+// hint joins the keys bound to action with "/", e.g. "y/1".
+func (km keymap) hint(action string) string {
+	return strings.Join(km.bound(action), "/")
+}
+
+// actionResultMsg carries the result of a custom action.
+type actionResultMsg struct {
+	name string
+	err  error
+}
+
+// runAction runs a custom action for the hovered session.
+func (m Model) runAction(a config.Action) (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok {
+		m.setStatus(fmt.Sprintf("Hover over a session to run %s", a.Name))
+		return m, nil
+	}
+	return m, func() tea.Msg {
+		return actionResultMsg{name: a.Name, err: runShell(context.Background(), a.Command, s).Run()}
+	}
+}
@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/martinwickman/ccmonitor/internal/config"
+)
+
+func TestKeymap(t *testing.T) {
+	t.Run("defaults should apply when nothing is configured", func(t *testing.T) {
+		km, err := newKeymap(nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for key, want := range map[string]string{"q": actionQuit, "ctrl+c": actionQuit, "y": actionApprove, "3": actionDeny} {
+			if got := km.action(key); got != want {
+				t.Errorf("action(%q) = %q, want %q", key, got, want)
+			}
+		}
+	})
+
+	t.Run("configured keys should replace the action's defaults", func(t *testing.T) {
+		km, err := newKeymap(map[string][]string{actionQuit: {"x"}}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := km.action("x"); got != actionQuit {
+			t.Errorf("action(x) = %q, want quit", got)
+		}
+		if got := km.action("q"); got != "" {
+			t.Errorf("action(q) = %q, want unbound", got)
+		}
+		if got := km.key(actionQuit); got != "x" {
+			t.Errorf("key(quit) = %q, want x", got)
+		}
+	})
+
+	t.Run("an empty list should unbind the action", func(t *testing.T) {
+		km, err := newKeymap(map[string][]string{actionLaunch: {}}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := km.key(actionLaunch); got != "" {
+			t.Errorf("key(launch) = %q, want unbound", got)
+		}
+	})
+
+	t.Run("custom actions should be bound", func(t *testing.T) {
+		km, err := newKeymap(nil, []config.Action{{Key: "o", Name: "open", Command: "code ."}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if a, ok := km.custom["o"]; !ok || a.Name != "open" {
+			t.Errorf("custom[o] = %+v, %v", a, ok)
+		}
+	})
+
+	t.Run("invalid bindings should be rejected", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			keys    map[string][]string
+			actions []config.Action
+		}{
+			{"unknown action", map[string][]string{"explode": {"x"}}, nil},
+			{"key bound twice", map[string][]string{actionView: {"c"}}, nil},
+			{"custom action on a built-in key", nil, []config.Action{{Key: "q", Command: "x"}}},
+			{"two custom actions on one key", nil, []config.Action{{Key: "o", Command: "a"}, {Key: "o", Command: "b"}}},
+		}
+		for _, tt := range tests {
+			if _, err := newKeymap(tt.keys, tt.actions); err == nil {
+				t.Errorf("%s: expected error", tt.name)
+			}
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// pidCheckInterval is how often a Loader checks which session processes are
+// still running, which is too slow to do on every poll.
+const pidCheckInterval = 10 * time.Second
+
+// LoaderOptions configures NewLoader.
+type LoaderOptions struct {
+	Project string   // only sessions in this directory tree ("" = all)
+	Ignore  []string // project directories or patterns to leave out
+	// Transcripts is read instead of the sessions directories when set.
+	Transcripts string
+	// Demo loads made-up sessions instead.
+	Demo bool
+	// Untracked adds rows for Claude Code processes without a session file,
+	// when the sessions come from the hooks.
+	Untracked bool
+}
+
+// Loader loads the sessions the way the monitor shows them, for everything
+// that polls them: the TUI, follow mode, the tray and the Go API. PIDs are
+// checked every pidCheckInterval, and the processes found dead are
+// remembered until the next check, so exited sessions don't flip back to
+// the status in their files on the polls in between.
+type Loader struct {
+	source    sessionSource
+	opts      LoaderOptions
+	exited    map[process]bool // found dead by the last PID check
+	untracked []session.Session
+	// lastPIDCheck is when CheckPIDLiveness was last run.
+	lastPIDCheck time.Time
+}
+
+// NewLoader returns a Loader for the session files in sessionsDirs, which
+// checks PIDs on its first load.
+func NewLoader(sessionsDirs []string, opts LoaderOptions) *Loader {
+	return &Loader{
+		source: sessionSource{dirs: sessionsDirs, transcripts: opts.Transcripts, demo: opts.Demo},
+		opts:   opts,
+	}
+}
+
+// Load returns the sessions in the loader's directory tree, with those
+// whose process has died marked exited. An error reading them is returned
+// along with the sessions that could be read.
+func (l *Loader) Load(now time.Time) ([]session.Session, error) {
+	sessions, err := l.source.load(now)
+	check := now.Sub(l.lastPIDCheck) >= pidCheckInterval
+	if check && l.opts.Untracked && l.source.hooks() {
+		l.untracked = FindUntracked(sessions)
+	}
+	sessions = withUntracked(sessions, l.untracked)
+	sessions = session.FilterProject(sessions, l.opts.Project)
+	sessions = session.FilterIgnored(sessions, l.opts.Ignore)
+	if !check {
+		for i := range sessions {
+			if sessions[i].PID > 0 && l.exited[processOf(sessions[i])] {
+				sessions[i].Status, sessions[i].Detail = session.StatusExited, "Process ended"
+			}
+		}
+		return sessions, err
+	}
+	CheckPIDLiveness(sessions)
+	l.exited = map[process]bool{}
+	for _, s := range sessions {
+		if s.Status == session.StatusExited {
+			l.exited[processOf(s)] = true
+		}
+	}
+	l.lastPIDCheck = now
+	return sessions, err
+}
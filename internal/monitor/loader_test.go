@@ -0,0 +1,68 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// writeSessions writes sessions as session files in a new directory.
+func writeSessions(t *testing.T, sessions ...session.Session) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, s := range sessions {
+		data, _ := json.Marshal(s)
+		if err := os.WriteFile(filepath.Join(dir, s.SessionID+".json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoader(t *testing.T) {
+	dead := session.Session{SessionID: "dead", Project: "/home/user/api", Status: session.StatusIdle, PID: 99999999, OS: runtime.GOOS}
+
+	t.Run("exited sessions should stay exited between PID checks", func(t *testing.T) {
+		dir := writeSessions(t, dead)
+		l := NewLoader([]string{dir}, LoaderOptions{})
+		now := time.Now()
+		for _, at := range []time.Duration{0, time.Second, 9 * time.Second, pidCheckInterval} {
+			sessions, err := l.Load(now.Add(at))
+			if err != nil || len(sessions) != 1 || sessions[0].Status != session.StatusExited {
+				t.Fatalf("after %v: got %+v, %v, want the session exited", at, sessions, err)
+			}
+		}
+	})
+
+	t.Run("a new process should not inherit the exited status", func(t *testing.T) {
+		dir := writeSessions(t, dead)
+		l := NewLoader([]string{dir}, LoaderOptions{})
+		now := time.Now()
+		l.Load(now)
+		resumed := dead
+		resumed.PID = 99999998
+		data, _ := json.Marshal(resumed)
+		os.WriteFile(filepath.Join(dir, "dead.json"), data, 0644)
+		if sessions, _ := l.Load(now.Add(time.Second)); sessions[0].Status != session.StatusIdle {
+			t.Errorf("status = %q, want the file's until the next PID check", sessions[0].Status)
+		}
+	})
+
+	t.Run("the exited filter should keep its sessions after a tick", func(t *testing.T) {
+		dir := writeSessions(t, dead)
+		m, err := New([]string{dir}, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.statusFilter = []string{session.StatusExited}
+		updated, _ := m.Update(tickMsg(time.Now()))
+		if got := updated.(Model).sessions; len(got) != 1 || got[0].Status != session.StatusExited {
+			t.Errorf("sessions = %+v, want the exited one", got)
+		}
+	})
+}
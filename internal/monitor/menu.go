@@ -0,0 +1,142 @@
+package monitor
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/martinwickman/ccmonitor/internal/config"
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// menuItem is one entry of the quick actions menu: a built-in action, or a
+// custom action from the config file.
+type menuItem struct {
+	label  string
+	hint   string // keys that run the action directly
+	action string
+	custom *config.Action
+}
+
+// menuItems lists the actions that apply to s, in menu order.
+func (m Model) menuItems(s session.Session) []menuItem {
+	var items []menuItem
+	add := func(action, label string) {
+		items = append(items, menuItem{label: label, hint: m.keys.hint(action), action: action})
+	}
+	if len(s.Terminals) > 0 {
+		add(actionSwitch, "Switch to session")
+	}
+	add(actionView, "View details")
+	if s.TranscriptPath != "" {
+		add(actionTranscript, "Read transcript")
+	}
+	if s.AwaitingPermission() {
+		add(actionApprove, "Approve")
+		add(actionApproveSession, "Approve for the session")
+		add(actionDeny, "Deny")
+	}
+	if len(s.Terminals) > 0 {
+		add(actionReply, "Reply")
+	}
+	if s.Status == session.StatusWorking {
+		add(actionInterrupt, "Interrupt")
+	}
+	if s.LastPrompt != "" {
+		add(actionCopy, "Copy prompt")
+	}
+	add(actionCopyID, "Copy session ID")
+	add(actionNote, "Edit note")
+	add(actionEditor, "Open project in editor")
+	add(actionReveal, "Show project in file manager")
+	add(actionHide, "Hide until it changes")
+	if s.PID > 0 && s.Status != session.StatusExited {
+		add(actionKill, "Kill")
+	}
+	if s.Status == session.StatusExited {
+		add(actionClean, "Remove exited sessions")
+	}
+	for _, a := range m.keys.ordered {
+		items = append(items, menuItem{label: a.Name, hint: a.Key, custom: &a})
+	}
+	return items
+}
+
+// openMenu opens the quick actions menu for the hovered session.
+func (m Model) openMenu() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok {
+		m.setStatus("Hover over a session to show its actions")
+		return m, nil
+	}
+	m.menuSID = s.SessionID
+	m.menuIndex = 0
+	return m, nil
+}
+
+// updateMenu handles key presses while the menu is open: up/down (or k/j)
+// move, enter runs the highlighted action, esc closes.
+func (m Model) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s, ok := m.find(m.menuSID)
+	if !ok {
+		m.menuSID = ""
+		return m, nil
+	}
+	items := m.menuItems(s)
+	switch msg.String() {
+	case "up", "k":
+		if m.menuIndex > 0 {
+			m.menuIndex--
+		}
+	case "down", "j":
+		if m.menuIndex < len(items)-1 {
+			m.menuIndex++
+		}
+	case "esc", "q":
+		m.menuSID = ""
+	case "enter":
+		m.menuSID = ""
+		if m.menuIndex >= len(items) {
+			return m, nil
+		}
+		// Actions work on the hovered session; the mouse is ignored while
+		// the menu is open, but pin it to the menu's session anyway.
+		m.hoverSID = s.SessionID
+		item := items[m.menuIndex]
+		if item.custom != nil {
+			return m.runAction(*item.custom)
+		}
+		return m.do(item.action)
+	}
+	return m, nil
+}
+
+// renderMenu renders the quick actions menu for s with the selected entry
+// highlighted.
+func renderMenu(s session.Session, items []menuItem, selected, width int, names projectNames) string {
+	if width == 0 {
+		width = 80
+	}
+	labelWidth := 0
+	for _, item := range items {
+		labelWidth = max(labelWidth, lipgloss.Width(item.label))
+	}
+
+	var b strings.Builder
+	b.WriteString(projectStyle.Render(names.name(s.Project)) + "  " + projectPathStyle.Render(s.Project) + "\n")
+	for i, item := range items {
+		marker, label := "  ", item.label
+		if i == selected {
+			marker, label = boldStyle.Render("›")+" ", boldStyle.Render(label)
+		}
+		line := marker + label + strings.Repeat(" ", labelWidth-lipgloss.Width(item.label))
+		if item.hint != "" {
+			line += "  " + faintStyle.Render(item.hint)
+		}
+		b.WriteString("\n" + line)
+	}
+
+	box := projectBoxStyle.Width(width - 4).Render(b.String())
+	return box + "\n" + helpStyle.Render("↑/↓ select · enter run · esc close")
+}
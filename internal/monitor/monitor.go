@@ -1,19 +1,29 @@
 package monitor
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 	"os/exec"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
-	ps "github.com/mitchellh/go-ps"
 
+	"github.com/martinwickman/ccmonitor/internal/clipboard"
+	"github.com/martinwickman/ccmonitor/internal/config"
+	"github.com/martinwickman/ccmonitor/internal/proc"
 	"github.com/martinwickman/ccmonitor/internal/session"
 	"github.com/martinwickman/ccmonitor/internal/switcher"
+	"github.com/martinwickman/ccmonitor/internal/terminal"
+	"github.com/martinwickman/ccmonitor/internal/transcript"
 )
 
 // tickMsg is sent on every refresh interval (session reload).
@@ -25,6 +35,38 @@ type flashTickMsg time.Time
 // switchResultMsg carries the result of an async tab/pane switch.
 type switchResultMsg struct{ err error }
 
+// sendResultMsg carries the result of async input sent to a session.
+// done is the status message shown on success.
+type sendResultMsg struct {
+	done string
+	err  error
+}
+
+// copyResultMsg carries the result of an async clipboard copy. what names
+// the copied text for the status message.
+type copyResultMsg struct {
+	what string
+	err  error
+}
+
+// launchResultMsg carries the result of an async session launch.
+type launchResultMsg struct {
+	dir string
+	err error
+}
+
+// promptKind identifies what the text prompt in the status line is for.
+type promptKind int
+
+const (
+	promptNone    promptKind = iota
+	promptNote               // editing the note of promptSID
+	promptLaunch             // entering a directory to launch a session in
+	promptReply              // typing text to send to promptSID
+	promptSearch             // "/" search query
+	promptCommand            // ":" command line
+)
+
 func tickCmd() tea.Cmd {
 	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
 		return tickMsg(t)
@@ -39,18 +81,29 @@ func flashTickCmd() tea.Cmd {
 
 const flashDuration = 2 * time.Second
 
+// doubleClickTime is the longest gap between the clicks of a double-click.
+const doubleClickTime = 400 * time.Millisecond
+
 // Model holds the state for the Bubble Tea program.
 type Model struct {
-	sessionsDir string
-	sessions    []session.Session
-	spinner     spinner.Model
-	width       int
+	source sessionSource
+	// loader loads the sessions from source on each tick.
+	loader *Loader
+	// project limits the view to sessions in this directory tree ("" = all).
+	project  string
+	sessions []session.Session
+	spinner  spinner.Model
+	width    int
+	height   int
+	// offset is the first content line shown when the view is taller than
+	// the terminal (scrolled with the mouse wheel).
+	offset int
 	// lastState tracks the last known status+detail per session ID for change detection.
 	lastState map[string]string
 	// flashUntil tracks when the flash expires per session ID.
 	flashUntil map[string]time.Time
-	// clickMap maps Y line number to session ID for mouse click handling.
-	clickMap map[int]string
+	// clickMap maps view positions to session IDs for mouse click handling.
+	clickMap clickTargets
 	// statusMsg is feedback text shown after a click action.
 	statusMsg string
 	// statusUntil is when to clear the status message.
@@ -59,70 +112,565 @@ type Model struct {
 	showSummary bool
 	// debug shows session IDs and PIDs in the display.
 	debug bool
+	// layout picks project boxes, one flat list or status boxes.
+	layout layout
+	// order sorts the sessions within project boxes.
+	order session.SortOrder
+	// stallAfter is how long a working session may stay quiet before it is
+	// shown as stalled (0 = never).
+	stallAfter time.Duration
+	// stallBell rings the terminal bell when a session becomes stalled.
+	stallBell bool
+	// autoClean removes the session files of exited sessions on each tick,
+	// as soon as a PID check finds them.
+	autoClean bool
+	// collapse is how long a session may sit idle before its row
+	// collapses to one line (0 = never).
+	collapse time.Duration
+	// opened holds long-idle sessions expanded back to full rows.
+	opened map[string]bool
+	// launchCmd is the command template run in new tabs/windows (see switcher.Launch).
+	launchCmd string
+	// editorCmd opens a project in the editor ("" = $VISUAL/$EDITOR, then code).
+	editorCmd string
+	// lastClickSID and lastClickAt detect double-clicks.
+	lastClickSID string
+	lastClickAt  time.Time
 	// hoverSID is the session ID currently under the mouse cursor.
 	hoverSID string
-	// lastPIDCheck is when CheckPIDLiveness was last run.
-	lastPIDCheck time.Time
+	// titles refreshes the sessions' tab titles between hook events (nil
+	// when the sessions don't come from the hooks).
+	titles *tabTitles
+	// loadErr is the last error loading the sessions, logged once.
+	loadErr string
+	// cache memoizes rendered project boxes between frames.
+	cache *renderCache
+	// prompt is the active text prompt in the status line (promptNone = none).
+	prompt promptKind
+	// promptSID is the session a note or reply prompt applies to.
+	promptSID string
+	// input is the text field of the active prompt.
+	input textinput.Model
+	// confirmSID is the session waiting for the user to confirm confirmAction
+	// (interrupt or kill).
+	confirmSID    string
+	confirmAction string
+	// menuSID is the session whose quick actions menu is open ("" = closed),
+	// and menuIndex the highlighted entry.
+	menuSID   string
+	menuIndex int
+	// statusFilter limits the view to these statuses (nil = all), set with
+	// :filter.
+	statusFilter []string
+	// hidden holds the sessions taken out of the view with the hide action,
+	// with their status at the time (see filterHidden).
+	hidden map[string]string
+	// detailSID is the session shown in the full-detail overlay ("" = closed).
+	detailSID string
+	// transcriptSID is the session whose transcript is shown in the overlay
+	// ("" = closed), and transcriptMsgs its last messages.
+	transcriptSID  string
+	transcriptMsgs []transcript.Message
+	// columns evaluates the custom columns from the config file.
+	columns *columnSet
+	// keys maps key presses to actions.
+	keys keymap
+	// names maps project directories to their display names.
+	names projectNames
+	// maxRows caps the sessions shown per project box (0 = no cap).
+	maxRows int
+	// expanded holds the projects whose boxes show all sessions anyway.
+	expanded map[string]bool
+	// borders colors tmux pane borders by status (nil = disabled).
+	borders *paneBorders
+}
+
+// setStatus shows a feedback message for a few seconds.
+func (m *Model) setStatus(msg string) {
+	m.statusMsg = msg
+	m.statusUntil = time.Now().Add(3 * time.Second)
+}
+
+// logLoadError logs an error loading the sessions when it differs from the
+// last one, so an unreadable directory isn't logged every second.
+func (m *Model) logLoadError(err error) {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	if msg == m.loadErr {
+		return
+	}
+	if err != nil {
+		slog.Warn("loading sessions", "err", err)
+	} else {
+		slog.Info("loading sessions works again")
+	}
+	m.loadErr = msg
+}
+
+// openPrompt shows a text prompt in the status line, pre-filled with value.
+func (m Model) openPrompt(kind promptKind, label, placeholder, value string) (tea.Model, tea.Cmd) {
+	ti := textinput.New()
+	ti.Prompt = label
+	ti.Placeholder = placeholder
+	ti.CharLimit = 200
+	ti.SetValue(value)
+	ti.CursorEnd()
+	m.input = ti
+	m.prompt = kind
+	return m, m.input.Focus()
+}
+
+// startNote opens the note editor for the session under the mouse cursor,
+// pre-filled with its current note.
+func (m Model) startNote() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok {
+		m.setStatus("Hover over a session to attach a note")
+		return m, nil
+	}
+	if !m.source.hooks() {
+		m.setStatus("Notes need the hooks installed")
+		return m, nil
+	}
+	m.promptSID = s.SessionID
+	return m.openPrompt(promptNote, fmt.Sprintf("Note for %s: ", m.names.name(s.Project)), "empty removes the note", s.Note)
+}
+
+// find returns the session with the given ID.
+func (m Model) find(sid string) (session.Session, bool) {
+	for _, s := range m.sessions {
+		if s.SessionID == sid {
+			return s, true
+		}
+	}
+	return session.Session{}, false
+}
+
+// hovered returns the session under the mouse cursor.
+func (m Model) hovered() (session.Session, bool) {
+	return m.find(m.hoverSID)
+}
+
+// startReply opens a prompt for text to type into the hovered session.
+func (m Model) startReply() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok {
+		m.setStatus("Hover over a session to reply to it")
+		return m, nil
+	}
+	if len(s.Terminals) == 0 {
+		m.setStatus(fmt.Sprintf("%s has no terminal info to send to", m.names.name(s.Project)))
+		return m, nil
+	}
+	m.promptSID = s.SessionID
+	return m.openPrompt(promptReply, fmt.Sprintf("Reply to %s: ", m.names.name(s.Project)), "sent with enter", "")
+}
+
+// sendCmd runs send for a session without blocking the UI.
+func sendCmd(s session.Session, done string, send func(session.Session) error) tea.Cmd {
+	return func() tea.Msg {
+		return sendResultMsg{done: done, err: send(s)}
+	}
+}
+
+// permissionChoices maps actions to the option they pick in Claude Code's
+// permission dialog, and the status shown once the keystroke is sent.
+var permissionChoices = map[string]struct{ option, done string }{
+	actionApprove:        {"1", "Approved"},
+	actionApproveSession: {"2", "Approved for the session"},
+	actionDeny:           {"3", "Denied"},
+}
+
+// answerPermission answers the permission dialog of the hovered session.
+func (m Model) answerPermission(action string) (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok || !s.AwaitingPermission() {
+		m.setStatus("Hover over a session waiting for permission")
+		return m, nil
+	}
+	c := permissionChoices[action]
+	return m, sendCmd(s, fmt.Sprintf("%s: %s", c.done, m.names.name(s.Project)), func(s session.Session) error {
+		return switcher.Type(s, c.option)
+	})
+}
+
+// startInterrupt asks for confirmation before interrupting the hovered
+// session. Only working sessions can be interrupted.
+func (m Model) startInterrupt() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok || s.Status != session.StatusWorking {
+		m.setStatus("Hover over a working session to interrupt it")
+		return m, nil
+	}
+	m.confirmSID, m.confirmAction = s.SessionID, actionInterrupt
+	return m, nil
+}
+
+// startKill asks for confirmation before terminating the hovered session's
+// process. Only sessions started on this OS can be killed.
+func (m Model) startKill() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok || s.PID <= 0 || s.Status == session.StatusExited {
+		m.setStatus("Hover over a running session to kill it")
+		return m, nil
+	}
+	if s.OS != "" && s.OS != runtime.GOOS {
+		m.setStatus(fmt.Sprintf("%s runs on %s and can't be killed from here", m.names.name(s.Project), s.OS))
+		return m, nil
+	}
+	m.confirmSID, m.confirmAction = s.SessionID, actionKill
+	return m, nil
+}
+
+// confirm runs the pending interrupt or kill on "y"; any other key cancels.
+// An interrupt sends Escape, a kill terminates the process and removes the
+// session file, so the session doesn't linger as exited.
+func (m Model) confirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	sid, action := m.confirmSID, m.confirmAction
+	m.confirmSID, m.confirmAction = "", ""
+	s, ok := m.find(sid)
+	if msg.String() != "y" || !ok {
+		m.setStatus(fmt.Sprintf("%s cancelled", confirmVerbs[action].verb))
+		return m, nil
+	}
+	done := fmt.Sprintf("%s %s", confirmVerbs[action].done, m.names.name(s.Project))
+	if action == actionKill {
+		var dirs []string
+		if m.source.hooks() {
+			dirs = m.source.dirs
+		}
+		return m, sendCmd(s, done, func(s session.Session) error {
+			err := proc.Stop(s.PID, s.PIDStart, killGrace)
+			if err == nil || errors.Is(err, proc.ErrGone) {
+				removeSessionFiles(dirs, s.SessionID)
+			}
+			return err
+		})
+	}
+	return m, sendCmd(s, done, func(s session.Session) error {
+		return switcher.SendKey(s, terminal.KeyEscape)
+	})
+}
+
+// killGrace is how long a killed session gets to exit after SIGTERM before
+// it is sent SIGKILL. A variable for tests.
+var killGrace = 3 * time.Second
+
+// confirmVerbs words the confirmation, cancellation and success messages.
+var confirmVerbs = map[string]struct{ verb, done string }{
+	actionInterrupt: {"Interrupt", "Interrupted"},
+	actionKill:      {"Kill", "Killed"},
+}
+
+// showDetail opens the full-detail overlay for the hovered session.
+func (m Model) showDetail() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok {
+		m.setStatus("Hover over a session to show its details")
+		return m, nil
+	}
+	m.detailSID = s.SessionID
+	return m, nil
+}
+
+// copyPrompt copies the full last prompt of the hovered session to the
+// clipboard.
+func (m Model) copyPrompt() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok || s.LastPrompt == "" {
+		m.setStatus("Hover over a session with a prompt to copy it")
+		return m, nil
+	}
+	return m, copyCmd("Prompt", s.LastPrompt)
+}
+
+// copyID copies the hovered session's ID to the clipboard, e.g. for
+// claude --resume.
+func (m Model) copyID() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok {
+		m.setStatus("Hover over a session to copy its ID")
+		return m, nil
+	}
+	return m, copyCmd("Session ID", s.SessionID)
+}
+
+// copyCmd copies text to the clipboard without blocking the UI.
+func copyCmd(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		return copyResultMsg{what: what, err: clipboard.Copy(text)}
+	}
+}
+
+// openResultMsg carries the result of opening a project in another app
+// (named for the status message, e.g. "the editor").
+type openResultMsg struct {
+	dir, app string
+	err      error
+}
+
+// openEditor opens the hovered session's project. A configured editor
+// command runs directly in the project directory; otherwise $VISUAL or
+// $EDITOR opens in a new tab or window like L does, and as a last resort
+// VS Code is used when it is installed.
+func (m Model) openEditor() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok {
+		m.setStatus("Hover over a session to open its project")
+		return m, nil
+	}
+	dir := s.Project
+	var open func() error
+	switch {
+	case m.editorCmd != "":
+		command := m.editorCmd
+		open = func() error { return runShell(context.Background(), command, s).Run() }
+	case os.Getenv("VISUAL") != "" || os.Getenv("EDITOR") != "":
+		editor := os.Getenv("VISUAL")
+		if editor == "" {
+			editor = os.Getenv("EDITOR")
+		}
+		open = func() error { return switcher.Launch(dir, editor+" .") }
+	default:
+		if _, err := exec.LookPath("code"); err != nil {
+			m.setStatus("Set an editor in the config file, $VISUAL or $EDITOR to open projects")
+			return m, nil
+		}
+		open = func() error { return runShell(context.Background(), "code .", s).Run() }
+	}
+	m.setStatus(fmt.Sprintf("Opening %s...", m.names.name(dir)))
+	return m, func() tea.Msg {
+		return openResultMsg{dir: dir, app: "the editor", err: open()}
+	}
+}
+
+// switchTo focuses the session's tmux pane or terminal tab.
+func (m *Model) switchTo(s session.Session) tea.Cmd {
+	m.setStatus(fmt.Sprintf("Switching to %s...", m.names.name(s.Project)))
+	return func() tea.Msg {
+		ch := make(chan error, 1)
+		go func() { ch <- switcher.Switch(s) }()
+		select {
+		case err := <-ch:
+			return switchResultMsg{err: err}
+		case <-time.After(10 * time.Second):
+			return switchResultMsg{err: fmt.Errorf("timed out (10s)")}
+		}
+	}
+}
+
+// switchHovered switches to the session under the mouse cursor.
+func (m Model) switchHovered() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok {
+		m.setStatus("Hover over a session to switch to it")
+		return m, nil
+	}
+	return m, m.switchTo(s)
+}
+
+// startLaunch asks for the directory to start a new session in. It defaults
+// to the project of the hovered session, then the --project filter, then the
+// monitor's working directory.
+func (m Model) startLaunch() (tea.Model, tea.Cmd) {
+	dir := m.project
+	if s, ok := m.hovered(); ok {
+		dir = s.Project
+	}
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	return m.openPrompt(promptLaunch, "Launch in: ", "project directory", dir)
+}
+
+// updatePrompt handles key presses while a prompt is open: enter submits,
+// esc cancels, everything else goes to the text field.
+func (m Model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.prompt = promptNone
+		return m, nil
+	case tea.KeyEnter:
+		kind := m.prompt
+		m.prompt = promptNone
+		switch kind {
+		case promptNote:
+			m.saveNote(m.input.Value())
+		case promptLaunch:
+			return m, m.launch(strings.TrimSpace(m.input.Value()))
+		case promptReply:
+			text := m.input.Value()
+			s, ok := m.find(m.promptSID)
+			if !ok || strings.TrimSpace(text) == "" {
+				return m, nil
+			}
+			return m, sendCmd(s, fmt.Sprintf("Sent to %s", m.names.name(s.Project)), func(s session.Session) error {
+				return switcher.SendText(s, text)
+			})
+		case promptSearch:
+			return m.search(m.input.Value())
+		case promptCommand:
+			return m.runCommand(m.input.Value())
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// saveNote stores the note for promptSID and updates the in-memory copy so it
+// shows up before the next reload.
+func (m *Model) saveNote(note string) {
+	if err := session.SaveNote(m.source.dirs[0], m.promptSID, note); err != nil {
+		m.setStatus(fmt.Sprintf("Saving note failed: %v", err))
+		return
+	}
+	for i := range m.sessions {
+		if m.sessions[i].SessionID == m.promptSID {
+			m.sessions[i].Note = strings.TrimSpace(note)
+		}
+	}
+	m.setStatus("Note saved")
+}
+
+// launch starts a new session in dir without blocking the UI.
+func (m *Model) launch(dir string) tea.Cmd {
+	if dir == "" {
+		m.setStatus("No directory given")
+		return nil
+	}
+	m.setStatus(fmt.Sprintf("Launching in %s...", m.names.name(dir)))
+	tmpl := m.launchCmd
+	return func() tea.Msg {
+		return launchResultMsg{dir: dir, err: switcher.Launch(dir, tmpl)}
+	}
 }
 
 // CheckPIDLiveness marks sessions with dead PIDs as "exited".
 // Sessions record the OS they were created on. When the monitor runs on a
 // different OS (e.g. Windows .exe reading WSL sessions), it uses the
-// appropriate method to check each PID.
+// appropriate method to check each PID. Sessions from other machines can't
+// be checked and keep their status.
 func CheckPIDLiveness(sessions []session.Session) {
 	alive := alivePIDs(sessions)
 	for i := range sessions {
-		if sessions[i].PID <= 0 {
+		if sessions[i].PID <= 0 || sessions[i].OnOtherHost(localHost()) {
 			continue
 		}
-		if !alive[sessions[i].PID] {
+		if !alive[processOf(sessions[i])] {
 			sessions[i].Status = session.StatusExited
 			sessions[i].Detail = "Process ended"
 		}
 	}
 }
 
-// alivePIDs returns the set of PIDs that are still running.
+// DefaultStallAfter is how long a working session may go without hook events
+// before it is shown as stalled.
+const DefaultStallAfter = 10 * time.Minute
+
+// MarkStalled flags working sessions whose last hook event is older than
+// after as stalled, and returns the IDs of the sessions it flagged. A hung
+// Bash command or a crashed CLI otherwise looks like work in progress forever.
+// after <= 0 disables the check.
+func MarkStalled(sessions []session.Session, after time.Duration, now time.Time) []string {
+	if after <= 0 {
+		return nil
+	}
+	var stalled []string
+	for i := range sessions {
+		if sessions[i].Status != session.StatusWorking {
+			continue
+		}
+		last, err := time.Parse(time.RFC3339, sessions[i].LastActivity)
+		if err != nil || now.Sub(last) < after {
+			continue
+		}
+		sessions[i].Status = session.StatusStalled
+		stalled = append(stalled, sessions[i].SessionID)
+	}
+	return stalled
+}
+
+// bell rings the terminal bell. It writes straight to the terminal, as
+// Bubble Tea has no bell of its own; BEL does not move the cursor, so it
+// doesn't disturb the rendered frame.
+func bell() tea.Msg {
+	os.Stdout.WriteString("\a")
+	return nil
+}
+
+// process identifies a session's process: its PID and, where recorded, its
+// start time, so sessions of a reused PID are told apart.
+type process struct {
+	pid   int
+	start string
+}
+
+func processOf(s session.Session) process {
+	return process{pid: s.PID, start: s.PIDStart}
+}
+
+// alivePIDs returns the set of session processes that are still running.
 // Sessions record which OS they were created on. When the monitor runs on a
-// different OS, cross-platform checks are used:
+// different OS, cross-platform checks are used, which only check the PID:
 //   - Windows monitor + Linux session → batch-check via "wsl kill -0"
 //   - Linux monitor + Windows session → batch-check via "powershell.exe Get-Process"
 //   - Same OS → native go-ps
-func alivePIDs(sessions []session.Session) map[int]bool {
-	alive := make(map[int]bool)
-	var wslPIDs, winPIDs []int
+func alivePIDs(sessions []session.Session) map[process]bool {
+	alive := make(map[process]bool)
+	var wslProcs, winProcs []process
 
 	for i := range sessions {
-		if sessions[i].PID <= 0 {
+		if sessions[i].PID <= 0 || sessions[i].OnOtherHost(localHost()) {
 			continue
 		}
+		p := processOf(sessions[i])
 		switch {
 		case runtime.GOOS == "windows" && sessions[i].OS != "windows":
-			wslPIDs = append(wslPIDs, sessions[i].PID)
+			wslProcs = append(wslProcs, p)
 		case runtime.GOOS != "windows" && sessions[i].OS == "windows":
-			winPIDs = append(winPIDs, sessions[i].PID)
+			winProcs = append(winProcs, p)
 		default:
-			alive[sessions[i].PID] = isNativePIDAlive(sessions[i].PID)
+			alive[p] = isNativePIDAlive(sessions[i])
 		}
 	}
 
-	for pid, ok := range checkWSLPIDs(wslPIDs) {
-		alive[pid] = ok
+	wslAlive := checkWSLPIDs(pids(wslProcs))
+	for _, p := range wslProcs {
+		alive[p] = wslAlive[p.pid]
 	}
-	for pid, ok := range checkWindowsPIDs(winPIDs) {
-		alive[pid] = ok
+	winAlive := checkWindowsPIDs(pids(winProcs))
+	for _, p := range winProcs {
+		alive[p] = winAlive[p.pid]
 	}
 
 	return alive
 }
 
-// isNativePIDAlive checks a PID using the native OS process table (go-ps).
-func isNativePIDAlive(pid int) bool {
-	proc, err := ps.FindProcess(pid)
+func pids(procs []process) []int {
+	var out []int
+	for _, p := range procs {
+		out = append(out, p.pid)
+	}
+	return out
+}
+
+// isNativePIDAlive checks a session's PID using the native OS process table.
+// A recorded start time that no longer matches means the PID was reused, so
+// it counts as dead. Only Claude sessions must also look like Claude Code.
+func isNativePIDAlive(s session.Session) bool {
+	check := proc.Alive
+	if !s.IsClaude() {
+		check = proc.Running
+	}
+	alive, err := check(s.PID, s.PIDStart)
 	if err != nil {
 		return true // assume alive on error
 	}
-	return proc != nil
+	return alive
 }
 
 // checkWSLPIDs checks Linux PIDs from Windows via "wsl kill -0 <pid>".
@@ -168,91 +716,360 @@ func parseAlivePIDs(output string) map[int]bool {
 	return alive
 }
 
-// New creates a new monitor model that reads from the given directory.
-func New(sessionsDir string, debug bool) Model {
-	sessions, _ := session.LoadAll(sessionsDir)
-	CheckPIDLiveness(sessions)
+// pruneState drops change-tracking entries for sessions that no longer exist,
+// and flash entries that have expired, so a long-running monitor doesn't
+// accumulate state for every session it has ever seen.
+func pruneState(sessions []session.Session, lastState map[string]string, flashUntil map[string]time.Time, now time.Time) {
+	current := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		current[s.SessionID] = true
+	}
+	for sid := range lastState {
+		if !current[sid] {
+			delete(lastState, sid)
+		}
+	}
+	for sid, until := range flashUntil {
+		if !current[sid] || !now.Before(until) {
+			delete(flashUntil, sid)
+		}
+	}
+}
+
+// Options configures a monitor created with New.
+type Options struct {
+	Debug       bool                // show session IDs and PIDs
+	Project     string              // only show sessions in this directory tree ("" = all)
+	LaunchCmd   string              // command template for new sessions ("" = claude)
+	StallAfter  time.Duration       // quiet period before a working session counts as stalled (0 = never)
+	StallBell   bool                // ring the terminal bell when a session stalls
+	Columns     []config.Column     // custom columns from the config file
+	Keys        map[string][]string // action name → keys, replacing the defaults
+	Actions     []config.Action     // custom key actions from the config file
+	TmuxBorder  bool                // color each session's tmux pane border by status
+	CleanExited bool                // remove the session files of exited sessions
+	EditorCmd   string              // shell command that opens a project, run in its directory
+	Aliases     map[string]string   // project directory or pattern → display name
+	Ignore      []string            // project directories or patterns to hide
+	MaxRows     int                 // sessions shown per project box before folding (0 = DefaultMaxRows, <0 = all)
+	Collapse    time.Duration       // idle time after which a session's row collapses to one line (0 = never)
+	// Transcripts is Claude Code's transcripts directory, read instead of
+	// the sessions directories when set (see package transcript).
+	Transcripts string
+	// Demo shows made-up sessions instead of reading any (see package demo).
+	Demo bool
+}
+
+// DefaultMaxRows is how many sessions a project box shows before the rest
+// are folded into an "… and N more" line.
+const DefaultMaxRows = 10
+
+// New creates a new monitor model that reads from the given directories. It
+// fails if a custom column template doesn't parse or the key bindings are
+// invalid.
+func New(sessionsDirs []string, opts Options) (Model, error) {
+	columns, err := newColumnSet(opts.Columns)
+	if err != nil {
+		return Model{}, err
+	}
+	keys, err := newKeymap(opts.Keys, opts.Actions)
+	if err != nil {
+		return Model{}, err
+	}
+	var borders *paneBorders
+	if opts.TmuxBorder {
+		borders = newPaneBorders()
+	}
+
+	maxRows := opts.MaxRows
+	if maxRows == 0 {
+		maxRows = DefaultMaxRows
+	} else if maxRows < 0 {
+		maxRows = 0
+	}
+
+	source := sessionSource{dirs: sessionsDirs, transcripts: opts.Transcripts, demo: opts.Demo}
+	loader := NewLoader(sessionsDirs, LoaderOptions{
+		Project:     opts.Project,
+		Ignore:      opts.Ignore,
+		Transcripts: opts.Transcripts,
+		Demo:        opts.Demo,
+		Untracked:   true,
+	})
+	sessions, _ := loader.Load(time.Now())
+	var titles *tabTitles
+	if source.hooks() {
+		titles = newTabTitles()
+	}
+	MarkStalled(sessions, opts.StallAfter, time.Now())
 
 	s := spinner.New()
 	s.Spinner = spinner.MiniDot
 	s.Style = workingStyle
 
 	return Model{
-		sessionsDir:  sessionsDir,
-		sessions:     sessions,
-		spinner:      s,
-		lastState:    map[string]string{},
-		flashUntil:   map[string]time.Time{},
-		showSummary:  false,
-		debug:        debug,
-		lastPIDCheck: time.Now(),
-	}
+		source:      source,
+		loader:      loader,
+		project:     opts.Project,
+		launchCmd:   opts.LaunchCmd,
+		editorCmd:   opts.EditorCmd,
+		stallAfter:  opts.StallAfter,
+		collapse:    opts.Collapse,
+		opened:      map[string]bool{},
+		stallBell:   opts.StallBell,
+		autoClean:   opts.CleanExited,
+		sessions:    sessions,
+		spinner:     s,
+		lastState:   map[string]string{},
+		flashUntil:  map[string]time.Time{},
+		showSummary: false,
+		debug:       opts.Debug,
+		titles:      titles,
+		cache:       newRenderCache(),
+		columns:     columns,
+		keys:        keys,
+		names:       newProjectNames(opts.Aliases),
+		maxRows:     maxRows,
+		expanded:    map[string]bool{},
+		borders:     borders,
+	}, nil
+}
+
+// Close undoes changes the monitor made outside its own window: it restores
+// the tmux pane borders colored by Options.TmuxBorder. Call it once the
+// program has exited.
+func (m Model) Close() {
+	m.borders.restore()
 }
 
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(tickCmd(), flashTickCmd(), m.spinner.Tick)
 }
 
+// do runs a built-in action; unknown actions ("" for unbound keys) do nothing.
+func (m Model) do(action string) (tea.Model, tea.Cmd) {
+	switch action {
+	case actionQuit:
+		return m, tea.Quit
+	case actionSummary:
+		m.showSummary = !m.showSummary
+		return m, nil
+	case actionExpand:
+		m.toggleExpanded()
+		return m, nil
+	case actionFlat, actionByStatus:
+		target := layoutFlat
+		if action == actionByStatus {
+			target = layoutStatus
+		}
+		if m.layout == target {
+			m.layout = layoutProjects
+		} else {
+			m.layout = target
+		}
+		m.clickMap = buildClickTargets(m.sessions, m.renderPlain(), m.width, m.viewOptions())
+		return m, nil
+	case actionHide:
+		return m.hideSession()
+	case actionUnhide:
+		return m.unhideAll()
+	case actionSort:
+		m.order = session.SortOrders[(slices.Index(session.SortOrders, m.order)+1)%len(session.SortOrders)]
+		m.setStatus("Sorted by " + m.order.String())
+		m.clickMap = buildClickTargets(m.sessions, m.renderPlain(), m.width, m.viewOptions())
+		return m, nil
+	case actionNote:
+		return m.startNote()
+	case actionLaunch:
+		return m.startLaunch()
+	case actionReply:
+		return m.startReply()
+	case actionInterrupt:
+		return m.startInterrupt()
+	case actionKill:
+		return m.startKill()
+	case actionClean:
+		return m.cleanExited()
+	case actionView:
+		return m.showDetail()
+	case actionTranscript:
+		return m.showTranscript()
+	case actionCopy:
+		return m.copyPrompt()
+	case actionCopyID:
+		return m.copyID()
+	case actionEditor:
+		return m.openEditor()
+	case actionReveal:
+		return m.reveal()
+	case actionSwitch:
+		return m.switchHovered()
+	case actionMenu:
+		return m.openMenu()
+	case actionApprove, actionApproveSession, actionDeny:
+		return m.answerPermission(action)
+	case actionFilterWorking, actionFilterWaiting, actionFilterIdle, actionFilterExited:
+		return m.toggleFilter(filterActions[action])
+	case actionDown:
+		m.moveSelection(1)
+	case actionUp:
+		m.moveSelection(-1)
+	case actionPrevProject:
+		m.moveProject(-1)
+	case actionNextProject:
+		m.moveProject(1)
+	case actionTop:
+		m.selectEdge(false)
+	case actionBottom:
+		m.selectEdge(true)
+	case actionPageUp:
+		m.scroll(-m.pageLines())
+	case actionPageDown:
+		m.scroll(m.pageLines())
+	case actionSearch:
+		return m.openPrompt(promptSearch, "/", "project, prompt, branch, note…", "")
+	case actionCommand:
+		return m.openPrompt(promptCommand, ":", "filter waiting · switch <id> · note · q", "")
+	}
+	m.ensureVisible(m.hoverSID)
+	return m, nil
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
-			return m, tea.Quit
-		case "p":
-			m.showSummary = !m.showSummary
+		if m.prompt != promptNone {
+			return m.updatePrompt(msg)
+		}
+		if m.confirmSID != "" {
+			return m.confirm(msg)
+		}
+		if m.menuSID != "" {
+			return m.updateMenu(msg)
+		}
+		if (m.detailSID != "" || m.transcriptSID != "") && msg.String() != "ctrl+c" {
+			m.detailSID, m.transcriptSID = "", ""
 			return m, nil
 		}
+		if a, ok := m.keys.custom[msg.String()]; ok {
+			return m.runAction(a)
+		}
+		return m.do(m.keys.action(msg.String()))
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
+		m.height = msg.Height
+		m.scroll(0)
 		return m, nil
 	case switchResultMsg:
 		if msg.err != nil {
-			m.statusMsg = fmt.Sprintf("Switch failed: %v", msg.err)
+			m.setStatus(fmt.Sprintf("Switch failed: %v", msg.err))
+		} else {
+			m.setStatus("Switched!")
+		}
+		return m, nil
+	case sendResultMsg:
+		if msg.err != nil {
+			m.setStatus(fmt.Sprintf("Send failed: %v", msg.err))
+		} else {
+			m.setStatus(msg.done)
+		}
+		return m, nil
+	case columnResultMsg:
+		m.columns.store(msg)
+		return m, nil
+	case titleResultMsg:
+		m.titles.store(msg)
+		return m, nil
+	case actionResultMsg:
+		if msg.err != nil {
+			m.setStatus(fmt.Sprintf("%s failed: %v", msg.name, msg.err))
+		} else {
+			m.setStatus(fmt.Sprintf("Ran %s", msg.name))
+		}
+		return m, nil
+	case copyResultMsg:
+		if msg.err != nil {
+			m.setStatus(fmt.Sprintf("Copy failed: %v", msg.err))
+		} else {
+			m.setStatus(msg.what + " copied")
+		}
+		return m, nil
+	case pagerResultMsg:
+		if msg.err != nil {
+			m.setStatus(fmt.Sprintf("Pager failed: %v", msg.err))
+		}
+		return m, nil
+	case openResultMsg:
+		if msg.err != nil {
+			m.setStatus(fmt.Sprintf("Opening %s failed: %v", msg.app, msg.err))
+		} else {
+			m.setStatus(fmt.Sprintf("Opened %s in %s", m.names.name(msg.dir), msg.app))
+		}
+		return m, nil
+	case launchResultMsg:
+		if msg.err != nil {
+			m.setStatus(fmt.Sprintf("Launch failed: %v", msg.err))
 		} else {
-			m.statusMsg = "Switched!"
+			m.setStatus(fmt.Sprintf("Launched in %s", m.names.name(msg.dir)))
 		}
-		m.statusUntil = time.Now().Add(3 * time.Second)
 		return m, nil
 	case tea.MouseMsg:
-		// Update hover state on any mouse event
-		m.hoverSID = m.clickMap[msg.Y]
+		if m.detailSID != "" || m.transcriptSID != "" || m.menuSID != "" {
+			if msg.Action == tea.MouseActionPress {
+				m.detailSID, m.transcriptSID, m.menuSID = "", "", ""
+			}
+			return m, nil
+		}
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.scroll(-scrollStep)
+		case tea.MouseButtonWheelDown:
+			m.scroll(scrollStep)
+		}
+		// Update hover state on any mouse event. The click map is in
+		// content lines, the mouse in screen lines.
+		y := m.contentLine(msg.Y)
+		m.hoverSID = m.clickMap.at(msg.X, y)
 
 		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
-			if sid, ok := m.clickMap[msg.Y]; ok {
-				// Find the session to switch to
-				for _, s := range m.sessions {
-					if s.SessionID == sid {
-						proj := baseName(s.Project)
-						m.statusMsg = fmt.Sprintf("Switching to %s...", proj)
-						m.statusUntil = time.Now().Add(3 * time.Second)
-						sess := s
-						return m, func() tea.Msg {
-							ch := make(chan error, 1)
-							go func() { ch <- switcher.Switch(sess) }()
-							select {
-							case err := <-ch:
-								return switchResultMsg{err: err}
-							case <-time.After(10 * time.Second):
-								return switchResultMsg{err: fmt.Errorf("timed out (10s)")}
-							}
-						}
-					}
+			if s, ok := m.find(m.hoverSID); ok {
+				// The first click of a double-click has already switched.
+				double := s.SessionID == m.lastClickSID && time.Since(m.lastClickAt) < doubleClickTime
+				m.lastClickSID, m.lastClickAt = s.SessionID, time.Now()
+				if double {
+					m.lastClickSID = ""
+					return m.openEditor()
 				}
+				return m, m.switchTo(s)
 			}
 		}
 		return m, nil
 	case tickMsg:
-		m.sessions, _ = session.LoadAll(m.sessionsDir)
-		if time.Since(m.lastPIDCheck) >= 10*time.Second {
-			CheckPIDLiveness(m.sessions)
-			m.lastPIDCheck = time.Now()
+		var err error
+		m.sessions, err = m.loader.Load(time.Now())
+		m.logLoadError(err)
+		m.titles.apply(m.sessions)
+		if m.autoClean && m.source.hooks() {
+			m.removeExited()
+		}
+		stalled := MarkStalled(m.sessions, m.stallAfter, time.Now())
+		m.sessions = filterStatus(m.sessions, m.statusFilter)
+		m.sessions = filterHidden(m.sessions, m.hidden)
+		pruneOpened(m.opened, m.sessions)
+		if m.transcriptSID != "" {
+			m.reloadTranscript()
 		}
 		// Build click map by scanning the actual rendered view for session IDs.
-		view := render(m.sessions, m.spinner, m.width, m.flashUntil, "", m.showSummary, m.debug, "")
-		m.clickMap = buildClickMap(m.sessions, view)
+		m.clickMap = buildClickTargets(m.sessions, m.renderPlain(), m.width, m.viewOptions())
 		now := time.Now()
 		newFlash := false
+		newStall := false
+		for _, sid := range stalled {
+			// Only ring on transitions, not for sessions already stalled at startup.
+			if prev, known := m.lastState[sid]; known && !strings.HasPrefix(prev, session.StatusStalled+"|") {
+				newStall = true
+			}
+		}
 		for _, s := range m.sessions {
 			state := s.Status + "|" + s.Detail
 			prev, known := m.lastState[s.SessionID]
@@ -262,10 +1079,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.lastState[s.SessionID] = state
 		}
+		pruneState(m.sessions, m.lastState, m.flashUntil, now)
 		cmds := []tea.Cmd{tickCmd()}
 		if newFlash {
 			cmds = append(cmds, flashTickCmd())
 		}
+		if newStall && m.stallBell {
+			cmds = append(cmds, bell)
+		}
+		cmds = append(cmds, m.columns.refresh(m.sessions, now)...)
+		cmds = append(cmds, m.borders.update(m.sessions))
+		cmds = append(cmds, m.titles.refresh(m.sessions, now))
 		return m, tea.Batch(cmds...)
 	case flashTickMsg:
 		// Re-render to update flash animation; only keep ticking if flashes are active
@@ -290,9 +1114,60 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) View() string {
+	if s, ok := m.find(m.detailSID); ok {
+		return renderDetail(s, m.spinner, m.width, m.names)
+	}
+	if s, ok := m.find(m.transcriptSID); ok {
+		return renderTranscript(s, m.transcriptMsgs, m.width, m.height, m.names)
+	}
+	if s, ok := m.find(m.menuSID); ok {
+		return renderMenu(s, m.menuItems(s), m.menuIndex, m.width, m.names)
+	}
 	var status string
 	if m.statusMsg != "" && time.Now().Before(m.statusUntil) {
 		status = m.statusMsg
 	}
-	return render(m.sessions, m.spinner, m.width, m.flashUntil, status, m.showSummary, m.debug, m.hoverSID)
+	if s, ok := m.hovered(); ok && status == "" && s.AwaitingPermission() {
+		status = fmt.Sprintf("%s approve · %s approve for session · %s deny",
+			m.keys.hint(actionApprove), m.keys.hint(actionApproveSession), m.keys.hint(actionDeny))
+	}
+	if s, ok := m.find(m.confirmSID); ok {
+		status = fmt.Sprintf("%s %s? y to confirm, any other key cancels", confirmVerbs[m.confirmAction].verb, m.names.name(s.Project))
+	}
+	if m.prompt != promptNone {
+		status = m.input.View()
+	}
+	return viewport(render(m.sessions, m.spinner, m.width, m.flashUntil, status, m.viewOptions(), m.hoverSID, m.cache), m.offset, m.height)
+}
+
+// Snapshot renders the whole view at width the way the monitor first draws
+// it, with nothing selected and not cut to the window height: the same
+// layout, columns, folding and help line. Used by "ccmonitor snapshot".
+func (m Model) Snapshot(width int) string {
+	return render(m.sessions, m.spinner, width, nil, "", m.viewOptions(), "", nil)
+}
+
+func (m Model) viewOptions() viewOptions {
+	return viewOptions{
+		showSummary: m.showSummary,
+		debug:       m.debug,
+		layout:      m.layout,
+		order:       m.order,
+		extra:       m.columns.cells(m.sessions),
+		keys:        m.keys,
+		names:       m.names,
+		maxRows:     m.maxRows,
+		expanded:    m.expanded,
+		filter:      m.statusFilter,
+		hidden:      len(m.hidden),
+		collapse:    m.collapse,
+		opened:      m.opened,
+		source:      m.source.label(),
+	}
+}
+
+// renderPlain renders the view without status line or hover highlight, for
+// building the click map.
+func (m Model) renderPlain() string {
+	return render(m.sessions, m.spinner, m.width, m.flashUntil, "", m.viewOptions(), "", nil)
 }
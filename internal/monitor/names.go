@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// projectNames resolves project directories to the names shown for them:
+// the config file's alias for the directory, else its last path element.
+// The zero value has no aliases.
+type projectNames struct {
+	exact map[string]string // normalized dir → name
+	globs []projectGlob     // longest (most specific) pattern first
+}
+
+type projectGlob struct {
+	pattern, name string
+}
+
+// newProjectNames builds the resolver for config aliases: directory or glob
+// pattern (path.Match syntax, "~/" for the home directory) → display name.
+// Patterns are assumed valid; config.Load checks them.
+func newProjectNames(aliases map[string]string) projectNames {
+	n := projectNames{exact: map[string]string{}}
+	home, _ := os.UserHomeDir()
+	for dir, name := range aliases {
+		if rest, ok := strings.CutPrefix(dir, "~/"); ok && home != "" {
+			dir = home + "/" + rest
+		}
+		dir = normalizeDir(dir)
+		if strings.ContainsAny(dir, "*?[") {
+			n.globs = append(n.globs, projectGlob{dir, name})
+		} else {
+			n.exact[dir] = name
+		}
+	}
+	sort.Slice(n.globs, func(i, j int) bool {
+		if a, b := n.globs[i].pattern, n.globs[j].pattern; len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return n.globs[i].pattern < n.globs[j].pattern
+	})
+	return n
+}
+
+// name returns the display name for a project directory. An exact alias
+// wins over a pattern, and a longer pattern over a shorter one.
+func (n projectNames) name(dir string) string {
+	p := normalizeDir(dir)
+	if name, ok := n.exact[p]; ok {
+		return name
+	}
+	for _, g := range n.globs {
+		if ok, _ := path.Match(g.pattern, p); ok {
+			return g.name
+		}
+	}
+	return baseName(dir)
+}
+
+// normalizeDir makes Windows and Unix paths comparable: forward slashes,
+// cleaned.
+func normalizeDir(p string) string {
+	return path.Clean(strings.ReplaceAll(p, "\\", "/"))
+}
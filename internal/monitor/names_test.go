@@ -0,0 +1,43 @@
+package monitor
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProjectNames(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	names := newProjectNames(map[string]string{
+		"/home/me/src/acme-api-monorepo": "API",
+		"/home/me/src/acme-*":            "acme",
+		"/home/me/src/acme-web*":         "web",
+		"~/notes":                        "Notes",
+		`C:\src\tools`:                   "tools",
+	})
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"/home/me/src/acme-api-monorepo", "API"},
+		{"/home/me/src/acme-api-monorepo/", "API"},
+		{"/home/me/src/acme-billing", "acme"},
+		{"/home/me/src/acme-web-admin", "web"},
+		{"/home/me/src/acme-web/sub", "sub"},
+		{home + "/notes", "Notes"},
+		{`C:\src\tools`, "tools"},
+		{"/home/me/src/other", "other"},
+	}
+	for _, tt := range tests {
+		if got := names.name(tt.dir); got != tt.want {
+			t.Errorf("name(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+
+	if got := (projectNames{}).name("/home/me/src/other"); got != "other" {
+		t.Errorf("zero value should fall back to the directory name, got %q", got)
+	}
+}
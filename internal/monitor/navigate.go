@@ -0,0 +1,304 @@
+package monitor
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// Keyboard navigation moves hoverSID, the same highlight the mouse drives, so
+// every action that works on the hovered session also works on the selected
+// one.
+
+// displayOrder returns the session IDs grouped as they appear on screen.
+func (m Model) displayOrder() [][]string {
+	var order [][]string
+	for _, g := range displayGroups(m.sessions, m.viewOptions()) {
+		var ids []string
+		for _, s := range g.Sessions {
+			ids = append(ids, s.SessionID)
+		}
+		order = append(order, ids)
+	}
+	return order
+}
+
+// groupOf returns the index of the group in order that holds sid, or -1.
+func groupOf(order [][]string, sid string) int {
+	for gi, ids := range order {
+		if slices.Contains(ids, sid) {
+			return gi
+		}
+	}
+	return -1
+}
+
+// moveSelection selects the session delta rows away from the current one,
+// across project boxes, stopping at either end. With nothing selected it
+// starts at the top.
+func (m *Model) moveSelection(delta int) {
+	var flat []string
+	for _, ids := range m.displayOrder() {
+		flat = append(flat, ids...)
+	}
+	if len(flat) == 0 {
+		return
+	}
+	i := -1
+	for j, id := range flat {
+		if id == m.hoverSID {
+			i = j
+		}
+	}
+	if i < 0 {
+		m.hoverSID = flat[0]
+		return
+	}
+	i = min(max(i+delta, 0), len(flat)-1)
+	m.hoverSID = flat[i]
+}
+
+// moveProject selects the first session of the previous (delta -1) or next
+// (delta 1) project box.
+func (m *Model) moveProject(delta int) {
+	order := m.displayOrder()
+	if len(order) == 0 {
+		return
+	}
+	gi := groupOf(order, m.hoverSID)
+	if gi < 0 {
+		gi = 0
+	} else {
+		gi = min(max(gi+delta, 0), len(order)-1)
+	}
+	m.hoverSID = order[gi][0]
+}
+
+// selectEdge selects the first (last false) or last session on screen.
+func (m *Model) selectEdge(last bool) {
+	order := m.displayOrder()
+	if len(order) == 0 {
+		return
+	}
+	if last {
+		ids := order[len(order)-1]
+		m.hoverSID = ids[len(ids)-1]
+	} else {
+		m.hoverSID = order[0][0]
+	}
+}
+
+// toggleExpanded opens the selected session's row when it is collapsed for
+// being idle too long (or collapses it again). Otherwise it shows all
+// sessions of the selected session's project box, or folds it again.
+func (m *Model) toggleExpanded() {
+	if s, ok := m.hovered(); ok && collapsible(s, m.collapse, time.Now()) {
+		if m.opened == nil {
+			m.opened = map[string]bool{}
+		}
+		if m.opened[s.SessionID] {
+			delete(m.opened, s.SessionID)
+		} else {
+			m.opened[s.SessionID] = true
+		}
+		m.clickMap = buildClickTargets(m.sessions, m.renderPlain(), m.width, m.viewOptions())
+		return
+	}
+	if m.layout != layoutProjects {
+		m.setStatus("Only project boxes are folded")
+		return
+	}
+	for _, g := range session.GroupByProject(m.sessions, m.order) {
+		for _, s := range g.Sessions {
+			if s.SessionID != m.hoverSID {
+				continue
+			}
+			if m.expanded == nil {
+				m.expanded = map[string]bool{}
+			}
+			if m.expanded[g.Project] {
+				delete(m.expanded, g.Project)
+			} else if len(g.Sessions) > m.maxRows && m.maxRows > 0 {
+				m.expanded[g.Project] = true
+			}
+			m.clickMap = buildClickTargets(m.sessions, m.renderPlain(), m.width, m.viewOptions())
+			return
+		}
+	}
+}
+
+// matches reports whether any visible text of s contains query, ignoring case.
+func matches(s session.Session, query string) bool {
+	query = strings.ToLower(query)
+	fields := []string{s.SessionID, s.Project, s.Status, s.Detail, s.LastPrompt, s.Summary, s.Note, s.Agent}
+	if s.Git != nil {
+		fields = append(fields, s.Git.Branch)
+	}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// search selects the next session after the current one that matches query,
+// wrapping around.
+func (m Model) search(query string) (tea.Model, tea.Cmd) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return m, nil
+	}
+	var flat []string
+	for _, ids := range m.displayOrder() {
+		flat = append(flat, ids...)
+	}
+	start := 0
+	for i, id := range flat {
+		if id == m.hoverSID {
+			start = i + 1
+		}
+	}
+	for n := range flat {
+		id := flat[(start+n)%len(flat)]
+		if s, ok := m.find(id); ok && matches(s, query) {
+			m.hoverSID = id
+			m.ensureVisible(id)
+			return m, nil
+		}
+	}
+	m.setStatus(fmt.Sprintf("No session matches %q", query))
+	return m, nil
+}
+
+// filterStatus keeps only sessions whose status is in statuses (nil = all).
+func filterStatus(sessions []session.Session, statuses []string) []session.Session {
+	if len(statuses) == 0 {
+		return sessions
+	}
+	var out []session.Session
+	for _, s := range sessions {
+		for _, st := range statuses {
+			if s.Status == st {
+				out = append(out, s)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// filterActions are the actions that toggle a status in the filter.
+var filterActions = map[string]string{
+	actionFilterWorking: session.StatusWorking,
+	actionFilterWaiting: session.StatusWaiting,
+	actionFilterIdle:    session.StatusIdle,
+	actionFilterExited:  session.StatusExited,
+}
+
+// toggleFilter adds status to the status filter, or takes it out when it is
+// already there, as :filter with one status more or less would.
+func (m Model) toggleFilter(status string) (tea.Model, tea.Cmd) {
+	filter := slices.Clone(m.statusFilter)
+	if i := slices.Index(filter, status); i >= 0 {
+		filter = slices.Delete(filter, i, i+1)
+	} else {
+		filter = append(filter, status)
+	}
+	return m.runCommand(strings.Join(append([]string{"filter"}, filter...), " "))
+}
+
+// knownStatuses are the statuses accepted by :filter.
+var knownStatuses = []string{
+	session.StatusStarting, session.StatusWorking, session.StatusCompacting, session.StatusIdle, session.StatusWaiting,
+	session.StatusLimited, session.StatusStalled, session.StatusError, session.StatusExited, session.StatusUntracked,
+}
+
+// runCommand runs a ":" command line:
+//
+//	:filter [status...]   only show sessions with these statuses (none = all)
+//	:switch <id|project>  switch to the session by ID prefix or project name
+//	:q, :quit             quit
+//	:<action>             run a built-in action (see defaultKeys) on the
+//	                      selected session, e.g. :note or :kill
+func (m Model) runCommand(line string) (tea.Model, tea.Cmd) {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		return m, nil
+	}
+	switch args[0] {
+	case "q", "quit":
+		return m, tea.Quit
+	case "filter":
+		for _, st := range args[1:] {
+			if !slices.Contains(knownStatuses, st) {
+				m.setStatus(fmt.Sprintf("Unknown status %q (want one of %s)", st, strings.Join(knownStatuses, ", ")))
+				return m, nil
+			}
+		}
+		m.statusFilter = args[1:]
+		m.sessions = filterStatus(m.sessions, m.statusFilter)
+		m.clickMap = buildClickTargets(m.sessions, m.renderPlain(), m.width, m.viewOptions())
+		if len(m.statusFilter) == 0 {
+			m.setStatus("Filter cleared")
+		} else {
+			m.setStatus("Showing " + strings.Join(m.statusFilter, ", "))
+		}
+		return m, nil
+	case "switch":
+		if len(args) != 2 {
+			m.setStatus("Usage: :switch <session ID or project>")
+			return m, nil
+		}
+		s, ok := m.lookup(args[1])
+		if !ok {
+			m.setStatus(fmt.Sprintf("No session %q", args[1]))
+			return m, nil
+		}
+		m.hoverSID = s.SessionID
+		return m, m.switchTo(s)
+	}
+	if _, ok := defaultKeys[args[0]]; ok && len(args) == 1 {
+		return m.do(args[0])
+	}
+	m.setStatus(fmt.Sprintf("Unknown command %q", args[0]))
+	return m, nil
+}
+
+// lookup finds a session by session ID prefix or project name (directory name
+// or alias). Prefixes must
+// be unambiguous; of several sessions in one project, the one that needs
+// attention most wins.
+func (m Model) lookup(query string) (session.Session, bool) {
+	return findSession(m.sessions, query, m.names)
+}
+
+// findSession is lookup for a list of sessions. An absolute path matches the
+// sessions in that directory or below.
+func findSession(sessions []session.Session, query string, names projectNames) (session.Session, bool) {
+	var byID, byProject []session.Session
+	for _, s := range sessions {
+		if strings.HasPrefix(s.SessionID, query) {
+			byID = append(byID, s)
+		}
+		if baseName(s.Project) == query || names.name(s.Project) == query {
+			byProject = append(byProject, s)
+		}
+	}
+	if filepath.IsAbs(query) {
+		byProject = append(byProject, session.FilterProject(sessions, query)...)
+	}
+	if len(byID) == 1 {
+		return byID[0], true
+	}
+	if len(byProject) > 0 {
+		return session.SortByAttention(byProject)[0], true
+	}
+	return session.Session{}, false
+}
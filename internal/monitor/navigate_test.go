@@ -0,0 +1,163 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestNavigation(t *testing.T) {
+	sessions := []session.Session{
+		{SessionID: "a1", Project: "/p/api", Status: session.StatusWorking, LastPrompt: "fix the login bug"},
+		{SessionID: "a2", Project: "/p/api", Status: session.StatusIdle},
+		{SessionID: "w1", Project: "/p/web", Status: session.StatusWaiting, Note: "review css"},
+	}
+	press := func(m Model, keys ...string) Model {
+		for _, k := range keys {
+			got, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+			m = got.(Model)
+		}
+		return m
+	}
+
+	t.Run("j and k should move across project boxes", func(t *testing.T) {
+		m := Model{sessions: sessions}
+		tests := []struct {
+			keys []string
+			want string
+		}{
+			{[]string{"j"}, "a1"},
+			{[]string{"j", "j", "j"}, "w1"},
+			{[]string{"j", "j", "j", "j"}, "w1"},
+			{[]string{"j", "j", "k"}, "a1"},
+		}
+		for _, tt := range tests {
+			if got := press(m, tt.keys...).hoverSID; got != tt.want {
+				t.Errorf("%v selected %q, want %q", tt.keys, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("h and l should jump between projects", func(t *testing.T) {
+		m := Model{sessions: sessions, hoverSID: "a2"}
+		if got := press(m, "l").hoverSID; got != "w1" {
+			t.Errorf("l selected %q, want w1", got)
+		}
+		if got := press(m, "l", "h").hoverSID; got != "a1" {
+			t.Errorf("l h selected %q, want a1", got)
+		}
+	})
+
+	t.Run("gg and G should select the first and last session", func(t *testing.T) {
+		m := Model{sessions: sessions, hoverSID: "a2"}
+		if got := press(m, "G").hoverSID; got != "w1" {
+			t.Errorf("G selected %q, want w1", got)
+		}
+		if got := press(m, "G", "g", "g").hoverSID; got != "a1" {
+			t.Errorf("gg selected %q, want a1", got)
+		}
+	})
+
+	t.Run("S should cycle the sort order, and j follow it", func(t *testing.T) {
+		m := press(Model{sessions: sessions}, "S")
+		if m.order != session.OrderByActivity {
+			t.Fatalf("order = %v, want activity", m.order)
+		}
+		if got := press(m, "S").order; got != session.OrderByStatus {
+			t.Errorf("order = %v, want status", got)
+		}
+		if got := press(m, "S", "S").order; got != session.OrderByID {
+			t.Errorf("order = %v, want ID again", got)
+		}
+		idleFirst := Model{sessions: []session.Session{
+			{SessionID: "x1", Project: "/p", Status: session.StatusIdle},
+			{SessionID: "x2", Project: "/p", Status: session.StatusWaiting},
+		}}
+		if got := press(idleFirst, "S", "S", "j").hoverSID; got != "x2" {
+			t.Errorf("j selected %q, want the waiting x2 first", got)
+		}
+	})
+
+	t.Run("search should select the next match", func(t *testing.T) {
+		m := Model{sessions: sessions}
+		got, _ := m.search("CSS")
+		if got.(Model).hoverSID != "w1" {
+			t.Errorf("search selected %q, want w1", got.(Model).hoverSID)
+		}
+		got, _ = m.search("nothing like this")
+		if got.(Model).hoverSID != "" || got.(Model).statusMsg == "" {
+			t.Error("no match should keep the selection and say so")
+		}
+	})
+}
+
+func TestRunCommand(t *testing.T) {
+	sessions := []session.Session{
+		{SessionID: "abc123", Project: "/p/api", Status: session.StatusWorking},
+		{SessionID: "def456", Project: "/p/web", Status: session.StatusWaiting},
+	}
+
+	t.Run("filter should keep matching statuses", func(t *testing.T) {
+		got, _ := Model{sessions: sessions}.runCommand("filter waiting")
+		m := got.(Model)
+		if len(m.sessions) != 1 || m.sessions[0].SessionID != "def456" {
+			t.Errorf("sessions = %v, want only def456", m.sessions)
+		}
+		if len(m.statusFilter) != 1 {
+			t.Errorf("statusFilter = %v, want [waiting]", m.statusFilter)
+		}
+	})
+
+	t.Run("filter keys should toggle their status", func(t *testing.T) {
+		got, _ := Model{sessions: sessions}.do(actionFilterWorking)
+		m := got.(Model)
+		if len(m.sessions) != 1 || m.sessions[0].SessionID != "abc123" {
+			t.Errorf("sessions = %v, want only abc123", m.sessions)
+		}
+		got, _ = m.do(actionFilterWaiting)
+		if m = got.(Model); strings.Join(m.statusFilter, ",") != "working,waiting" {
+			t.Errorf("statusFilter = %v, want [working waiting]", m.statusFilter)
+		}
+		got, _ = m.do(actionFilterWorking)
+		if m = got.(Model); strings.Join(m.statusFilter, ",") != "waiting" {
+			t.Errorf("statusFilter = %v, want working toggled off", m.statusFilter)
+		}
+	})
+
+	t.Run("unknown status should be rejected", func(t *testing.T) {
+		got, _ := Model{sessions: sessions}.runCommand("filter busy")
+		if m := got.(Model); m.statusFilter != nil || len(m.sessions) != 2 {
+			t.Error("invalid filter should change nothing")
+		}
+	})
+
+	t.Run("switch should find sessions by ID prefix or project", func(t *testing.T) {
+		m := Model{sessions: sessions}
+		for query, want := range map[string]string{"abc": "abc123", "web": "def456"} {
+			s, ok := m.lookup(query)
+			if !ok || s.SessionID != want {
+				t.Errorf("lookup(%q) = %q, %v, want %q", query, s.SessionID, ok, want)
+			}
+		}
+		if _, ok := m.lookup("zzz"); ok {
+			t.Error("lookup of an unknown session should fail")
+		}
+	})
+
+	t.Run("action names should run the action", func(t *testing.T) {
+		got, _ := Model{sessions: sessions, hoverSID: "abc123"}.runCommand("view")
+		if got.(Model).detailSID != "abc123" {
+			t.Error(":view should open the detail overlay")
+		}
+	})
+
+	t.Run("unknown commands should report an error", func(t *testing.T) {
+		got, cmd := Model{sessions: sessions}.runCommand("explode")
+		if got.(Model).statusMsg == "" || cmd != nil {
+			t.Error("expected an error status and no command")
+		}
+	})
+}
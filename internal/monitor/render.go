@@ -2,12 +2,15 @@ package monitor
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/martinwickman/ccmonitor/internal/session"
 )
 
@@ -17,41 +20,187 @@ type columnWidths struct {
 	contentWidth int // total available width inside the box
 }
 
+// boxKey identifies a rendered project box by its inner content and width.
+type boxKey struct {
+	width   int
+	content string
+}
+
+// renderCache memoizes rendered project boxes between frames. Boxing (border,
+// padding, wrapping) is the most expensive part of a frame, and most boxes are
+// unchanged from one frame to the next — only groups with a spinning or
+// flashing row differ. Entries not used by the latest frame are dropped, so
+// the cache never holds more than one frame's worth of boxes.
+type renderCache struct {
+	boxes map[boxKey]string
+	next  map[boxKey]string
+}
+
+func newRenderCache() *renderCache {
+	return &renderCache{boxes: map[boxKey]string{}, next: map[boxKey]string{}}
+}
+
+// box returns style.Render(content), reusing the previous frame's result when
+// the content and width are unchanged. A nil cache renders directly.
+func (c *renderCache) box(style lipgloss.Style, width int, content string) string {
+	if c == nil {
+		return style.Render(content)
+	}
+	key := boxKey{width: width, content: content}
+	out, ok := c.boxes[key]
+	if !ok {
+		out = style.Render(content)
+	}
+	c.next[key] = out
+	return out
+}
+
+// endFrame drops boxes that weren't used by the frame just rendered.
+func (c *renderCache) endFrame() {
+	if c == nil {
+		return
+	}
+	c.boxes, c.next = c.next, c.boxes
+	clear(c.next)
+}
+
+// layout is how sessions are split into boxes.
+type layout int
+
+const (
+	layoutProjects layout = iota // one box per project
+	layoutFlat                   // one list sorted by attention
+	layoutStatus                 // one box per status, rows labeled with their project
+)
+
+// viewOptions holds the display toggles that affect rendering.
+type viewOptions struct {
+	showSummary bool // prefer the tab title over the last prompt
+	debug       bool // show session IDs and PIDs
+	layout      layout
+	order       session.SortOrder // within project boxes
+	// extra holds the custom column cells per session ID (see columnSet).
+	extra map[string][]string
+	// keys supplies the key names shown in the help line.
+	keys keymap
+	// names supplies the project names shown in box titles and rows.
+	names projectNames
+	// maxRows caps the sessions shown per project box (0 = no cap); the
+	// rest are folded into an "… and N more" line unless the project is in
+	// expanded.
+	maxRows  int
+	expanded map[string]bool
+	// filter is the :filter status list, shown in the header.
+	filter []string
+	// hidden counts the sessions hidden with the hide action, also noted
+	// in the header.
+	hidden int
+	// collapse is how long a session may sit idle before its row
+	// collapses to one line (0 = never), unless it is in opened.
+	collapse time.Duration
+	opened   map[string]bool
+	// source notes in the header where the sessions come from, unless
+	// from the hooks (see sessionSource.label).
+	source string
+}
+
 // RenderOnce produces a single snapshot of the current sessions for non-interactive output.
-func RenderOnce(sessions []session.Session, width int, debug bool) string {
+// aliases maps project directories or patterns to display names (see
+// config.Config.Aliases).
+func RenderOnce(sessions []session.Session, width int, debug bool, aliases map[string]string) string {
 	sp := spinner.New()
 	sp.Spinner = spinner.MiniDot
-	return renderView(sessions, sp, width, nil, "", false, true, debug, "")
+	opts := viewOptions{showSummary: true, debug: debug, names: newProjectNames(aliases)}
+	return renderView(sessions, sp, width, nil, "", false, opts, "", nil)
 }
 
-func render(sessions []session.Session, sp spinner.Model, width int, flashUntil map[string]time.Time, statusMsg string, showSummary bool, debug bool, hoverSID string) string {
-	return renderView(sessions, sp, width, flashUntil, statusMsg, true, showSummary, debug, hoverSID)
+func render(sessions []session.Session, sp spinner.Model, width int, flashUntil map[string]time.Time, statusMsg string, opts viewOptions, hoverSID string, cache *renderCache) string {
+	return renderView(sessions, sp, width, flashUntil, statusMsg, true, opts, hoverSID, cache)
 }
 
-func renderView(sessions []session.Session, sp spinner.Model, width int, flashUntil map[string]time.Time, statusMsg string, interactive bool, showSummary bool, debug bool, hoverSID string) string {
+// displayGroups returns the boxes to render, in order: one per project, a
+// single attention-sorted group in flat mode, or one per status. Project
+// boxes are folded to opts.maxRows.
+func displayGroups(sessions []session.Session, opts viewOptions) []session.ProjectGroup {
+	switch opts.layout {
+	case layoutFlat:
+		return []session.ProjectGroup{{Sessions: session.SortByAttention(sessions)}}
+	case layoutStatus:
+		return session.GroupByStatus(sessions)
+	}
+	groups := session.GroupByProject(sessions, opts.order)
+	for i, g := range groups {
+		if !opts.expanded[g.Project] {
+			groups[i] = foldGroup(g, opts.maxRows)
+		}
+	}
+	return groups
+}
+
+// foldGroup keeps the limit sessions of g that need attention most, in their
+// usual order, and counts the rest in g.More.
+func foldGroup(g session.ProjectGroup, limit int) session.ProjectGroup {
+	if limit <= 0 || len(g.Sessions) <= limit {
+		return g
+	}
+	keep := map[string]bool{}
+	for _, s := range session.SortByAttention(g.Sessions)[:limit] {
+		keep[s.SessionID] = true
+	}
+	var shown []session.Session
+	for _, s := range g.Sessions {
+		if keep[s.SessionID] {
+			shown = append(shown, s)
+		}
+	}
+	g.More = len(g.Sessions) - len(shown)
+	g.Sessions = shown
+	return g
+}
+
+func renderView(sessions []session.Session, sp spinner.Model, width int, flashUntil map[string]time.Time, statusMsg string, interactive bool, opts viewOptions, hoverSID string, cache *renderCache) string {
 	if width == 0 {
 		width = 80
 	}
 
 	if len(sessions) == 0 {
-		s := titleStyle.Render("ccmonitor") + "\n\n" +
+		s := titleStyle.Render("ccmonitor") + "  " + hostStyle.Render("@"+localHost()) + "\n\n" +
 			idleStyle.Render("No active sessions.")
+		if opts.hidden > 0 {
+			s += idleStyle.Render(fmt.Sprintf(" %s hidden.", plural(opts.hidden, "session")))
+		}
 		if interactive {
-			s += "\n" + renderHelp(showSummary)
+			s += "\n" + renderHelp(opts, width)
 		}
 		return s
 	}
 
-	groups := session.GroupByProject(sessions)
+	groups := displayGroups(sessions, opts)
 
-	// Box width accounts for border (2) and padding (2)
-	boxWidth := width - 4
+	// Wide terminals lay the boxes out in a grid. Box width accounts for
+	// border (2) and padding (2).
+	cols := gridColumns(width, len(groups))
+	stride := width / cols
+	boxWidth := stride - 4
 
 	var b strings.Builder
 
 	// Header
 	header := titleStyle.Render("ccmonitor") + "  " +
-		countStyle.Render(fmt.Sprintf("%d projects, %d sessions", len(groups), len(sessions)))
+		hostStyle.Render("@"+localHost()) + "  " +
+		countStyle.Render(fmt.Sprintf("%d projects, %d sessions", len(session.GroupByProject(sessions, opts.order)), len(sessions)))
+	if len(opts.filter) > 0 {
+		header += countStyle.Render(" · showing " + strings.Join(opts.filter, ", "))
+	}
+	if opts.hidden > 0 {
+		header += countStyle.Render(fmt.Sprintf(" · %d hidden", opts.hidden))
+	}
+	if opts.source != "" {
+		header += countStyle.Render(" · " + opts.source)
+	}
+	if total, ok := totalCost(sessions); ok {
+		header += countStyle.Render(" · " + formatCost(total) + " total")
+	}
 	b.WriteString(header + "\n")
 
 	// Summary bar
@@ -61,8 +210,18 @@ func renderView(sessions []session.Session, sp spinner.Model, width int, flashUn
 	// Build rows for all groups and compute global column widths
 	groupRows := make([][]sessionRow, len(groups))
 	var allRows []sessionRow
+	now := time.Now()
 	for i, g := range groups {
-		rows := buildRows(g.Sessions, sp, flashUntil, showSummary, debug)
+		rows := buildRows(g.Sessions, g.Worktrees, sp, flashUntil, opts.showSummary, opts.debug)
+		for j := range rows {
+			rows[j].extra = opts.extra[rows[j].sessionID]
+			rows[j].collapsed = opts.collapsed(g.Sessions[j], now)
+		}
+		if opts.layout != layoutProjects {
+			for j := range rows {
+				rows[j].project = opts.names.name(g.Sessions[j].Project)
+			}
+		}
 		groupRows[i] = rows
 		allRows = append(allRows, rows...)
 	}
@@ -71,34 +230,236 @@ func renderView(sessions []session.Session, sp spinner.Model, width int, flashUn
 
 	boxStyle := projectBoxStyle.Width(boxWidth)
 
-	for i, g := range groups {
-		box := renderProjectGroup(g, groupRows[i], w, hoverSID)
-		b.WriteString(boxStyle.Render(box) + "\n")
+	for start := 0; start < len(groups); start += cols {
+		var boxes []string
+		for i := start; i < min(start+cols, len(groups)); i++ {
+			box := cache.box(boxStyle, boxWidth, renderProjectGroup(groups[i], groupRows[i], w, hoverSID, opts))
+			if cols > 1 {
+				box = lipgloss.PlaceHorizontal(stride, lipgloss.Left, box)
+			}
+			boxes = append(boxes, box)
+		}
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, boxes...) + "\n")
 	}
+	cache.endFrame()
 
 	if interactive {
 		if statusMsg != "" {
-			b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Render(statusMsg) + "\n")
+			b.WriteString(statusMsgStyle.Render(statusMsg) + "\n")
 		}
-		b.WriteString(renderHelp(showSummary))
+		b.WriteString(renderHelp(opts, width))
 	}
 
 	return b.String()
 }
 
-func renderHelp(showSummary bool) string {
-	faint := lipgloss.NewStyle().Faint(true).Render
-	bold := lipgloss.NewStyle().Bold(true).Render
+// totalCost adds up the estimated cost of the sessions shown, and reports
+// whether any of them has been counted.
+func totalCost(sessions []session.Session) (float64, bool) {
+	var total float64
+	counted := false
+	for _, s := range sessions {
+		if s.Usage != nil {
+			total += s.Usage.CostUSD
+			counted = true
+		}
+	}
+	return total, counted
+}
+
+// gridColumnWidth is the narrowest a column of boxes gets: terminals at
+// least twice as wide show the boxes side by side.
+const gridColumnWidth = 100
 
-	var toggle string
-	if showSummary {
-		toggle = faint("p prompt/") + bold("title")
-	} else {
-		toggle = faint("p ") + bold("prompt") + faint("/title")
+// gridColumns returns how many columns n boxes are laid out in at width.
+// Boxes fill the grid row by row, so the first row holds the boxes that
+// need attention most.
+func gridColumns(width, n int) int {
+	if width == 0 {
+		width = 80
 	}
+	return max(1, min(width/gridColumnWidth, n))
+}
+
+// renderHelp renders the key hints, wrapping between items so that no line
+// is wider than width.
+func renderHelp(opts viewOptions, width int) string {
+	faint := faintStyle.Render
+	bold := boldStyle.Render
 
-	line := faint("q quit · ") + toggle + faint(" · click to switch tab")
-	return helpStyle.Render(line)
+	keys := opts.keys
+	var items []string
+	if k := keys.key(actionQuit); k != "" {
+		items = append(items, faint(k+" quit"))
+	}
+	if k := keys.key(actionSummary); k != "" {
+		if opts.showSummary {
+			items = append(items, faint(k+" prompt/")+bold("title"))
+		} else {
+			items = append(items, faint(k+" ")+bold("prompt")+faint("/title"))
+		}
+	}
+	if k := keys.key(actionFlat); k != "" {
+		switch opts.layout {
+		case layoutProjects:
+			items = append(items, faint(k+" ")+bold("grouped")+faint("/flat"))
+		case layoutFlat:
+			items = append(items, faint(k+" grouped/")+bold("flat"))
+		default:
+			items = append(items, faint(k+" grouped/flat"))
+		}
+	}
+	if k := keys.key(actionByStatus); k != "" {
+		if opts.layout == layoutStatus {
+			items = append(items, faint(k+" by ")+bold("status"))
+		} else {
+			items = append(items, faint(k+" by status"))
+		}
+	}
+	// The sort order is only noted once changed; the help line is full.
+	if k := keys.key(actionSort); k != "" && opts.layout == layoutProjects && opts.order != session.OrderByID {
+		items = append(items, faint(k+" sort by ")+bold(opts.order.String()))
+	}
+	if down, up := keys.key(actionDown), keys.key(actionUp); down != "" && up != "" {
+		items = append(items, faint(down+"/"+up+" move"))
+	}
+	var filterKeys []string
+	for _, action := range []string{actionFilterWorking, actionFilterWaiting, actionFilterIdle, actionFilterExited} {
+		if k := keys.key(action); k != "" {
+			filterKeys = append(filterKeys, k)
+		}
+	}
+	if len(filterKeys) > 0 {
+		items = append(items, faint(strings.Join(filterKeys, "/")+" filter"))
+	}
+	for _, action := range []string{actionSearch, actionCommand, actionMenu, actionView, actionCopy, actionReply, actionInterrupt, actionNote, actionLaunch} {
+		if k := keys.key(action); k != "" {
+			items = append(items, faint(k+" "+action))
+		}
+	}
+	items = append(items, faint("click to switch tab"))
+
+	sep := faint(" · ")
+	var lines []string
+	line := ""
+	for _, item := range items {
+		switch {
+		case line == "":
+			line = item
+		case lipgloss.Width(line+sep+item) > width:
+			lines = append(lines, line)
+			line = item
+		default:
+			line += sep + item
+		}
+	}
+	lines = append(lines, line)
+	return helpStyle.Render(strings.Join(lines, "\n"))
+}
+
+// renderDetail renders the full, untruncated state of one session as a
+// boxed overlay, wrapping long text to the terminal width.
+func renderDetail(s session.Session, sp spinner.Model, width int, names projectNames) string {
+	if width == 0 {
+		width = 80
+	}
+	boxWidth := width - 4
+
+	indicator, style, label := sessionStatusDisplay(s, sp)
+	status := style.Render(indicator + " " + label)
+	if age, ok := statusAge(s, time.Now()); ok {
+		status += faintStyle.Render(" for " + session.Duration(age))
+	}
+	if s.Detail != "" {
+		status += "  " + s.Detail
+	}
+
+	var b strings.Builder
+	b.WriteString(projectStyle.Render(names.name(s.Project)) + "  " + projectPathStyle.Render(s.Project) + "\n\n")
+	b.WriteString(status + "\n")
+	field := func(name, value string, st lipgloss.Style) {
+		if value == "" {
+			return
+		}
+		b.WriteString("\n" + boldStyle.Render(name) + "\n" + st.Render(value) + "\n")
+	}
+	field("Prompt", s.LastPrompt, promptStyle)
+	field("Title", s.Summary, lipgloss.NewStyle())
+	field("Note", s.Note, noteStyle)
+	field("Last error", s.LastError, exitedStyle)
+	if s.ContextLeft != nil {
+		field("Context", fmt.Sprintf("%s (%d%% left)", contextBar(s.ContextLeft), *s.ContextLeft), lipgloss.NewStyle())
+	}
+	if s.Usage != nil {
+		u := s.Usage
+		field("Usage", fmt.Sprintf("%s in · %s out · %s cache write · %s cache read · about %s",
+			formatTokens(u.InputTokens), formatTokens(u.OutputTokens), formatTokens(u.CacheCreationTokens),
+			formatTokens(u.CacheReadTokens), formatCost(u.CostUSD)), lipgloss.NewStyle())
+	}
+	if s.Compacted != "" {
+		field("Compacted", session.TimeSince(s.Compacted)+" ("+fullTime(s.Compacted)+")", lipgloss.NewStyle())
+	}
+	if s.NotificationType != nil {
+		field("Notification", *s.NotificationType, waitingStyle)
+	}
+	field("Model", s.Model, lipgloss.NewStyle())
+	field("Transcript", s.TranscriptPath, faintStyle)
+	field("Environment", environmentLine(s), faintStyle)
+	field("Process", processLine(s), faintStyle)
+	lastActivity := fullTime(s.LastActivity)
+	footer := s.SessionID
+	if s.ResumedFrom != "" {
+		footer += " · resumed from " + session.Truncate(s.ResumedFrom, 8)
+	}
+	b.WriteString("\n" + faintStyle.Render(fmt.Sprintf("%s · last activity %s", footer, lastActivity)))
+
+	box := projectBoxStyle.Width(boxWidth).Render(b.String())
+	return box + "\n" + helpStyle.Render("any key or click to close")
+}
+
+// environmentLine describes where a session runs: terminal emulator, shell,
+// OS and the terminal backends the hook found, e.g.
+// "vscode 1.95.0 · zsh · linux · tmux %3".
+func environmentLine(s session.Session) string {
+	var parts []string
+	if s.Env != nil {
+		for _, p := range []string{s.Env.Terminal, s.Env.Shell} {
+			if p != "" {
+				parts = append(parts, p)
+			}
+		}
+	}
+	if s.OS != "" {
+		parts = append(parts, s.OS)
+	}
+	for _, t := range s.Terminals {
+		parts = append(parts, t.Backend+" "+t.ID)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// processLine describes the Claude process of a session, e.g.
+// "PID 4242 · on laptop".
+func processLine(s session.Session) string {
+	var parts []string
+	if s.PID != 0 {
+		parts = append(parts, fmt.Sprintf("PID %d", s.PID))
+	}
+	if s.Host != "" {
+		parts = append(parts, "on "+s.Host)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// fullTime formats an RFC 3339 timestamp to the second, in the configured
+// time zone, for the detail view, where the rows' short clock times aren't
+// precise enough. Anything else is returned as it is.
+func fullTime(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	return session.In(t.Local()).Format("2006-01-02 15:04:05 MST")
 }
 
 func renderSummary(sessions []session.Session) string {
@@ -108,12 +469,24 @@ func renderSummary(sessions []session.Session) string {
 	}
 
 	var parts []string
+	if n := counts[session.StatusError]; n > 0 {
+		parts = append(parts, errorStyle.Render(fmt.Sprintf("⚠ %d error", n)))
+	}
+	if n := counts[session.StatusStalled]; n > 0 {
+		parts = append(parts, stalledStyle.Render(fmt.Sprintf("⧖ %d stalled", n)))
+	}
 	if n := counts[session.StatusWorking]; n > 0 {
 		parts = append(parts, workingStyle.Render(fmt.Sprintf("● %d working", n)))
 	}
+	if n := counts[session.StatusCompacting]; n > 0 {
+		parts = append(parts, compactingStyle.Render(fmt.Sprintf("◎ %d compacting", n)))
+	}
 	if n := counts[session.StatusWaiting]; n > 0 {
 		parts = append(parts, waitingStyle.Render(fmt.Sprintf("◆ %d waiting", n)))
 	}
+	if n := counts[session.StatusLimited]; n > 0 {
+		parts = append(parts, limitedStyle.Render(fmt.Sprintf("◷ %d limited", n)))
+	}
 	if n := counts[session.StatusIdle]; n > 0 {
 		parts = append(parts, idleStyle.Render(fmt.Sprintf("○ %d idle", n)))
 	}
@@ -123,20 +496,40 @@ func renderSummary(sessions []session.Session) string {
 	if n := counts[session.StatusExited]; n > 0 {
 		parts = append(parts, exitedStyle.Render(fmt.Sprintf("✕ %d exited", n)))
 	}
+	if n := counts[session.StatusUntracked]; n > 0 {
+		parts = append(parts, idleStyle.Render(fmt.Sprintf("◇ %d untracked", n)))
+	}
 
 	return strings.Join(parts, "  ")
 }
 
-// buildRows converts sessions into styled row data.
-func buildRows(sessions []session.Session, sp spinner.Model, flashUntil map[string]time.Time, showSummary bool, debug bool) []sessionRow {
+// buildRows converts sessions into styled row data. showWorktree labels each
+// row with its worktree, for groups that merge several worktrees of a repo.
+func buildRows(sessions []session.Session, showWorktree bool, sp spinner.Model, flashUntil map[string]time.Time, showSummary bool, debug bool) []sessionRow {
 	var rows []sessionRow
 	for i, s := range sessions {
 		isLast := i == len(sessions)-1
-		rows = append(rows, newSessionRow(s, isLast, sp, flashUntil, showSummary, debug))
+		row := newSessionRow(s, isLast, sp, flashUntil, showSummary, debug)
+		if showWorktree {
+			row.worktree = worktreeLabel(s)
+		}
+		rows = append(rows, row)
 	}
 	return rows
 }
 
+// worktreeLabel returns the branch a session is on, falling back to the
+// worktree directory name when HEAD is detached.
+func worktreeLabel(s session.Session) string {
+	if s.Git == nil {
+		return baseName(s.Project)
+	}
+	if s.Git.Branch != "" {
+		return s.Git.Branch
+	}
+	return baseName(s.Git.Worktree)
+}
+
 // computeWidths calculates column widths across all rows globally.
 func computeWidths(allRows []sessionRow) columnWidths {
 	w := columnWidths{status: 12} // fixed minimum to prevent spinner jitter
@@ -148,6 +541,15 @@ func computeWidths(allRows []sessionRow) columnWidths {
 	return w
 }
 
+// localHost returns this machine's hostname, looked up once.
+var localHost = sync.OnceValue(func() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return "localhost"
+	}
+	return h
+})
+
 // baseName extracts the last path component, handling both forward and
 // backslash separators so Windows paths work correctly on Linux/WSL.
 func baseName(path string) string {
@@ -162,17 +564,33 @@ func baseName(path string) string {
 	return name
 }
 
-func renderProjectGroup(g session.ProjectGroup, rows []sessionRow, w columnWidths, hoverSID string) string {
+func renderProjectGroup(g session.ProjectGroup, rows []sessionRow, w columnWidths, hoverSID string, opts viewOptions) string {
 	var b strings.Builder
 
-	dirName := baseName(g.Project)
+	dirName := opts.names.name(g.Project)
 	title := projectStyle.Render(dirName) + " " + projectPathStyle.Render(g.Project)
+	if g.Status != "" {
+		_, style, label := statusDisplay(g.Status, spinner.New())
+		title = style.Bold(true).Render(label) + " " + projectPathStyle.Render(plural(len(g.Sessions), "session"))
+	} else if g.Project == "" {
+		title = projectStyle.Render("All sessions") + " " + projectPathStyle.Render("needing attention first")
+	}
+	if g.Worktrees {
+		title += projectPathStyle.Render(" · worktrees")
+	}
 	b.WriteString(title + "\n")
-	b.WriteString(lipgloss.NewStyle().Faint(true).Render("│") + "\n")
+	b.WriteString(faintStyle.Render("│") + "\n")
 
 	for _, r := range rows {
 		b.WriteString(r.render(w, r.sessionID == hoverSID))
 	}
+	if g.More > 0 {
+		more := fmt.Sprintf("   … and %d more", g.More)
+		if k := opts.keys.key(actionExpand); k != "" {
+			more += fmt.Sprintf(" (press %s to expand)", k)
+		}
+		b.WriteString(faintStyle.Render(more) + "\n")
+	}
 
 	return b.String()
 }
@@ -193,23 +611,75 @@ func flashPhase(now time.Time, until time.Time) int {
 	return 2 // off
 }
 
+// clickTargets maps positions in the rendered view to session IDs for mouse
+// handling: a line map per column of boxes, each stride cells wide.
+type clickTargets struct {
+	columns []map[int]string
+	stride  int
+}
+
+// at returns the session shown at column x of line y, or "".
+func (c clickTargets) at(x, y int) string {
+	if len(c.columns) == 0 {
+		return ""
+	}
+	col := 0
+	if c.stride > 0 {
+		col = min(x/c.stride, len(c.columns)-1)
+	}
+	return c.columns[col][y]
+}
+
+// buildClickTargets maps the view rendered at width, column by column when
+// the boxes are laid out in a grid.
+func buildClickTargets(sessions []session.Session, view string, width int, opts viewOptions) clickTargets {
+	if width == 0 {
+		width = 80
+	}
+	groups := displayGroups(sessions, opts)
+	cols := gridColumns(width, len(groups))
+	if cols == 1 {
+		return clickTargets{columns: []map[int]string{buildClickMap(sessions, view, opts)}}
+	}
+	stride := width / cols
+	lines := strings.Split(view, "\n")
+	c := clickTargets{stride: stride}
+	for col := range cols {
+		var ordered []session.Session
+		for i := col; i < len(groups); i += cols {
+			ordered = append(ordered, groups[i].Sessions...)
+		}
+		cut := make([]string, len(lines))
+		for y, line := range lines {
+			cut[y] = ansi.Cut(line, col*stride, (col+1)*stride)
+		}
+		c.columns = append(c.columns, mapConnectors(ordered, cut, opts))
+	}
+	return c
+}
+
 // buildClickMap scans the rendered view for tree connectors (├─ / └─) and maps
 // their Y line numbers to session IDs. Connectors appear in the same order as
 // sessions are rendered, so we flatten the groups and match by position.
-func buildClickMap(sessions []session.Session, view string) map[int]string {
-	clickMap := make(map[int]string)
+func buildClickMap(sessions []session.Session, view string, opts viewOptions) map[int]string {
 	if len(sessions) == 0 {
-		return clickMap
+		return make(map[int]string)
 	}
 
 	// Flatten sessions in render order.
-	groups := session.GroupByProject(sessions)
+	groups := displayGroups(sessions, opts)
 	var ordered []session.Session
 	for _, g := range groups {
 		ordered = append(ordered, g.Sessions...)
 	}
+	return mapConnectors(ordered, strings.Split(view, "\n"), opts)
+}
 
-	lines := strings.Split(view, "\n")
+// mapConnectors maps the connector line of each of the ordered sessions, and
+// the lines below it that belong to the session, to its ID.
+func mapConnectors(ordered []session.Session, lines []string, opts viewOptions) map[int]string {
+	clickMap := make(map[int]string)
+	now := time.Now()
 	sessionIdx := 0
 	for y, line := range lines {
 		if sessionIdx >= len(ordered) {
@@ -218,9 +688,13 @@ func buildClickMap(sessions []session.Session, view string) map[int]string {
 		if strings.Contains(line, "├─") || strings.Contains(line, "└─") {
 			sid := ordered[sessionIdx].SessionID
 			clickMap[y] = sid
-			// Also map the status line directly below.
-			if y+1 < len(lines) {
-				clickMap[y+1] = sid
+			// Also map the lines below: note, status, error and subagents.
+			below := extraLines(ordered[sessionIdx])
+			if opts.collapsed(ordered[sessionIdx], now) {
+				below = 0
+			}
+			for dy := 1; dy <= below && y+dy < len(lines); dy++ {
+				clickMap[y+dy] = sid
 			}
 			sessionIdx++
 		}
@@ -1,11 +1,21 @@
 package monitor
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/martinwickman/ccmonitor/internal/config"
+	"github.com/martinwickman/ccmonitor/internal/proc"
+	"github.com/martinwickman/ccmonitor/internal/proc/proctest"
 	"github.com/martinwickman/ccmonitor/internal/session"
 )
 
@@ -38,7 +48,7 @@ func TestFlashPhase(t *testing.T) {
 
 func TestBuildClickMap(t *testing.T) {
 	t.Run("empty sessions should return empty map", func(t *testing.T) {
-		got := buildClickMap(nil, "some view\ncontent\n")
+		got := buildClickMap(nil, "some view\ncontent\n", viewOptions{layout: layoutProjects})
 		if len(got) != 0 {
 			t.Errorf("got %d entries, want 0", len(got))
 		}
@@ -49,7 +59,7 @@ func TestBuildClickMap(t *testing.T) {
 			{SessionID: "abcd1234-full-id", Project: "/p"},
 		}
 		view := "header\nsummary\n├─ Fix the bug\n   Working  Edit main.go\n"
-		got := buildClickMap(sessions, view)
+		got := buildClickMap(sessions, view, viewOptions{layout: layoutProjects})
 		if got[2] != "abcd1234-full-id" {
 			t.Errorf("line 2: got %q, want %q", got[2], "abcd1234-full-id")
 		}
@@ -64,7 +74,7 @@ func TestBuildClickMap(t *testing.T) {
 			{SessionID: "abcd1234-full-id", Project: "/p"},
 		}
 		view := "header\n└─ Fix the bug\n   Working  Edit main.go\nfooter\n"
-		got := buildClickMap(sessions, view)
+		got := buildClickMap(sessions, view, viewOptions{layout: layoutProjects})
 		if got[1] != "abcd1234-full-id" {
 			t.Errorf("line 1: got %q, want %q", got[1], "abcd1234-full-id")
 		}
@@ -79,7 +89,7 @@ func TestBuildClickMap(t *testing.T) {
 			{SessionID: "bbbbbbbb-2222", Project: "/p"},
 		}
 		view := "header\n├─ First task\n│  Working\n└─ Second task\n   Idle\nfooter\n"
-		got := buildClickMap(sessions, view)
+		got := buildClickMap(sessions, view, viewOptions{layout: layoutProjects})
 		if got[1] != "aaaaaaaa-1111" {
 			t.Errorf("line 1: got %q, want %q", got[1], "aaaaaaaa-1111")
 		}
@@ -94,12 +104,38 @@ func TestBuildClickMap(t *testing.T) {
 		}
 	})
 
+	t.Run("note line should be mapped along with the status line", func(t *testing.T) {
+		sessions := []session.Session{
+			{SessionID: "aaaaaaaa-1111", Project: "/p", Note: "parked"},
+			{SessionID: "bbbbbbbb-2222", Project: "/p"},
+		}
+		view := "header\n├─ First task\n│  ✎ parked\n│  Idle\n└─ Second task\n   Idle\n"
+		got := buildClickMap(sessions, view, viewOptions{layout: layoutProjects})
+		for y, want := range map[int]string{1: "aaaaaaaa-1111", 2: "aaaaaaaa-1111", 3: "aaaaaaaa-1111", 4: "bbbbbbbb-2222", 5: "bbbbbbbb-2222"} {
+			if got[y] != want {
+				t.Errorf("line %d: got %q, want %q", y, got[y], want)
+			}
+		}
+	})
+
+	t.Run("flat view should map sessions in attention order", func(t *testing.T) {
+		sessions := []session.Session{
+			{SessionID: "idle-1111", Project: "/a", Status: session.StatusIdle},
+			{SessionID: "wait-2222", Project: "/b", Status: session.StatusWaiting},
+		}
+		view := "header\n├─ b task\n│  Waiting\n└─ a task\n   Idle\n"
+		got := buildClickMap(sessions, view, viewOptions{layout: layoutFlat})
+		if got[1] != "wait-2222" || got[3] != "idle-1111" {
+			t.Errorf("got %v, want waiting session first", got)
+		}
+	})
+
 	t.Run("lines without connectors should not be mapped", func(t *testing.T) {
 		sessions := []session.Session{
 			{SessionID: "abcd1234-full-id", Project: "/p"},
 		}
 		view := "header line\nproject title\n├─ Fix the bug\n   Working\n"
-		got := buildClickMap(sessions, view)
+		got := buildClickMap(sessions, view, viewOptions{layout: layoutProjects})
 		if _, ok := got[0]; ok {
 			t.Errorf("header line should not be mapped")
 		}
@@ -109,6 +145,54 @@ func TestBuildClickMap(t *testing.T) {
 	})
 }
 
+func TestGridLayout(t *testing.T) {
+	sessions := []session.Session{
+		{SessionID: "api-1111", Project: "/src/api", Status: session.StatusWaiting, LastPrompt: "add limits"},
+		{SessionID: "web-2222", Project: "/src/web", Status: session.StatusIdle, LastPrompt: "fix mobile"},
+		{SessionID: "cli-3333", Project: "/src/cli", Status: session.StatusIdle, LastPrompt: "add --json"},
+	}
+	opts := viewOptions{layout: layoutProjects}
+
+	t.Run("boxes should sit side by side on wide terminals", func(t *testing.T) {
+		view := ansi.Strip(render(sessions, spinner.New(), 220, nil, "", opts, "", nil))
+		for _, line := range strings.Split(view, "\n") {
+			if strings.Count(line, "╭") == 2 {
+				return
+			}
+			if w := lipgloss.Width(line); w > 220 {
+				t.Fatalf("line wider than the terminal (%d): %q", w, line)
+			}
+		}
+		t.Errorf("expected two boxes on one line:\n%s", view)
+	})
+
+	t.Run("clicks should map to the box in their column", func(t *testing.T) {
+		view := render(sessions, spinner.New(), 220, nil, "", opts, "", nil)
+		targets := buildClickTargets(sessions, view, 220, opts)
+		found := map[string]bool{}
+		for col, lines := range targets.columns {
+			for y, sid := range lines {
+				if got := targets.at(col*targets.stride+5, y); got != sid {
+					t.Errorf("at column %d line %d: got %q, want %q", col, y, got, sid)
+				}
+				found[sid] = true
+			}
+		}
+		if len(found) != len(sessions) {
+			t.Errorf("mapped %v, want all sessions", found)
+		}
+	})
+
+	t.Run("narrow terminals should keep one column", func(t *testing.T) {
+		if got := gridColumns(150, 3); got != 1 {
+			t.Errorf("gridColumns(150, 3) = %d, want 1", got)
+		}
+		if got := gridColumns(320, 2); got != 2 {
+			t.Errorf("gridColumns(320, 2) = %d, want 2, one per box", got)
+		}
+	})
+}
+
 func TestCheckPIDLiveness(t *testing.T) {
 	t.Run("dead PID sets status to exited", func(t *testing.T) {
 		sessions := []session.Session{
@@ -123,9 +207,19 @@ func TestCheckPIDLiveness(t *testing.T) {
 		}
 	})
 
+	t.Run("another machine's session keeps its status", func(t *testing.T) {
+		sessions := []session.Session{
+			{SessionID: "s6", Status: "working", PID: 99999999, OS: runtime.GOOS, Host: "some-other-box"},
+		}
+		CheckPIDLiveness(sessions)
+		if sessions[0].Status != "working" {
+			t.Errorf("status = %q, want %q", sessions[0].Status, "working")
+		}
+	})
+
 	t.Run("alive PID keeps original status", func(t *testing.T) {
 		sessions := []session.Session{
-			{SessionID: "s2", Status: "working", PID: os.Getpid(), OS: runtime.GOOS},
+			{SessionID: "s2", Status: "working", PID: proctest.StartFakeClaude(t), OS: runtime.GOOS},
 		}
 		CheckPIDLiveness(sessions)
 		if sessions[0].Status != "working" {
@@ -133,6 +227,48 @@ func TestCheckPIDLiveness(t *testing.T) {
 		}
 	})
 
+	t.Run("a reused PID should not keep the old session alive", func(t *testing.T) {
+		pid := proctest.StartFakeClaude(t)
+		start := proc.StartTime(pid)
+		if start == "" {
+			t.Skip("no start times on this platform")
+		}
+		// The current session is listed second: keyed by PID alone, its
+		// result would overwrite the old one's.
+		sessions := []session.Session{
+			{SessionID: "old", Status: "idle", PID: pid, PIDStart: "1", OS: runtime.GOOS},
+			{SessionID: "new", Status: "working", PID: pid, PIDStart: start, OS: runtime.GOOS},
+		}
+		CheckPIDLiveness(sessions)
+		if sessions[0].Status != "exited" || sessions[1].Status != "working" {
+			t.Errorf("statuses = %q, %q, want exited, working", sessions[0].Status, sessions[1].Status)
+		}
+	})
+
+	t.Run("non-Claude PID sets status to exited", func(t *testing.T) {
+		sessions := []session.Session{
+			{SessionID: "s5", Status: "working", PID: os.Getpid(), OS: runtime.GOOS},
+		}
+		CheckPIDLiveness(sessions)
+		if sessions[0].Status != "exited" {
+			t.Errorf("status = %q, want %q", sessions[0].Status, "exited")
+		}
+	})
+
+	t.Run("reused PID sets status to exited", func(t *testing.T) {
+		pid := proctest.StartFakeClaude(t)
+		if proc.StartTime(pid) == "" {
+			t.Skip("start time not supported on this platform")
+		}
+		sessions := []session.Session{
+			{SessionID: "s4", Status: "working", PID: pid, PIDStart: "1", OS: runtime.GOOS},
+		}
+		CheckPIDLiveness(sessions)
+		if sessions[0].Status != "exited" {
+			t.Errorf("status = %q, want %q", sessions[0].Status, "exited")
+		}
+	})
+
 	t.Run("zero PID is left as-is", func(t *testing.T) {
 		sessions := []session.Session{
 			{SessionID: "s3", Status: "idle", PID: 0},
@@ -143,3 +279,590 @@ func TestCheckPIDLiveness(t *testing.T) {
 		}
 	})
 }
+
+func TestRenderOnceHeader(t *testing.T) {
+	t.Run("header should show the local hostname", func(t *testing.T) {
+		out := RenderOnce(benchSessions(1), 80, false, nil)
+		if !strings.Contains(out, "@"+localHost()) {
+			t.Errorf("header should contain @%s", localHost())
+		}
+	})
+
+	t.Run("empty view should show the local hostname", func(t *testing.T) {
+		out := RenderOnce(nil, 80, false, nil)
+		if !strings.Contains(out, "@"+localHost()) {
+			t.Errorf("header should contain @%s", localHost())
+		}
+	})
+	t.Run("header should be the lines the viewport pins", func(t *testing.T) {
+		sp := spinner.New()
+		out := render(benchSessions(3), sp, 80, nil, "", viewOptions{filter: []string{"waiting"}}, "", nil)
+		lines := strings.Split(ansi.Strip(out), "\n")
+		if !strings.Contains(lines[0], "showing waiting") {
+			t.Errorf("header should show the active filter, got %q", lines[0])
+		}
+		if !strings.Contains(lines[headerLines-1], "1 working") {
+			t.Errorf("summary bar should be the last pinned line, got %q", lines[headerLines-1])
+		}
+		if strings.TrimSpace(lines[headerLines]) != "" || !strings.HasPrefix(lines[headerLines+1], "╭") {
+			t.Errorf("first box should follow the header, got %q", lines[headerLines:headerLines+2])
+		}
+	})
+}
+
+func TestPruneState(t *testing.T) {
+	now := time.Now()
+	sessions := []session.Session{{SessionID: "live"}}
+	lastState := map[string]string{"live": "idle|", "gone": "working|"}
+	flashUntil := map[string]time.Time{
+		"live":    now.Add(time.Second),
+		"gone":    now.Add(time.Second),
+		"expired": now.Add(-time.Second),
+	}
+
+	pruneState(sessions, lastState, flashUntil, now)
+
+	if _, ok := lastState["gone"]; ok {
+		t.Error("lastState should not keep removed sessions")
+	}
+	if _, ok := lastState["live"]; !ok {
+		t.Error("lastState should keep current sessions")
+	}
+	if len(flashUntil) != 1 {
+		t.Errorf("flashUntil has %d entries, want 1", len(flashUntil))
+	}
+	if _, ok := flashUntil["live"]; !ok {
+		t.Error("flashUntil should keep active flashes for current sessions")
+	}
+}
+
+func TestRenderCache(t *testing.T) {
+	sp := spinner.New()
+	sp.Spinner = spinner.MiniDot
+	sessions := benchSessions(20)
+
+	t.Run("cached render should match uncached render", func(t *testing.T) {
+		cache := newRenderCache()
+		want := render(sessions, sp, 100, nil, "", viewOptions{}, "", nil)
+		for range 2 {
+			if got := render(sessions, sp, 100, nil, "", viewOptions{}, "", cache); got != want {
+				t.Fatal("cached render differs from uncached render")
+			}
+		}
+	})
+
+	t.Run("cache should only keep boxes from the latest frame", func(t *testing.T) {
+		cache := newRenderCache()
+		render(sessions, sp, 100, nil, "", viewOptions{}, "", cache)
+		render(sessions, sp, 120, nil, "", viewOptions{}, "", cache)
+		for key := range cache.boxes {
+			if key.width != 116 {
+				t.Errorf("found stale box for width %d", key.width)
+			}
+		}
+	})
+}
+
+func TestStatusLayout(t *testing.T) {
+	sp := spinner.New()
+	sessions := []session.Session{
+		{SessionID: "idle-1111", Project: "/home/user/alpha", Status: session.StatusIdle, LastPrompt: "Tidy up"},
+		{SessionID: "wait-2222", Project: "/home/user/beta", Status: session.StatusWaiting, LastPrompt: "Deploy"},
+	}
+
+	t.Run("boxes should be statuses in attention order with the project on each row", func(t *testing.T) {
+		out := ansi.Strip(render(sessions, sp, 100, nil, "", viewOptions{layout: layoutStatus}, "", nil))
+		waiting, idle := strings.Index(out, "Waiting 1 session"), strings.Index(out, "Idle 1 session")
+		if waiting < 0 || idle < 0 || waiting > idle {
+			t.Fatalf("expected a Waiting box before an Idle box, got:\n%s", out)
+		}
+		if !strings.Contains(out, "beta") || !strings.Contains(out, "alpha") {
+			t.Errorf("rows should show their project, got:\n%s", out)
+		}
+	})
+
+	t.Run("layout keys should toggle back to project boxes", func(t *testing.T) {
+		var m tea.Model = Model{sessions: sessions}
+		for _, step := range []struct {
+			action string
+			want   layout
+		}{
+			{actionByStatus, layoutStatus},
+			{actionFlat, layoutFlat},
+			{actionFlat, layoutProjects},
+			{actionByStatus, layoutStatus},
+			{actionByStatus, layoutProjects},
+		} {
+			m, _ = m.(Model).do(step.action)
+			if got := m.(Model).layout; got != step.want {
+				t.Fatalf("after %s: layout = %d, want %d", step.action, got, step.want)
+			}
+		}
+	})
+}
+
+func TestFoldGroups(t *testing.T) {
+	sp := spinner.New()
+	var sessions []session.Session
+	for i := range 5 {
+		sessions = append(sessions, session.Session{SessionID: fmt.Sprintf("idle-%d", i), Project: "/p", Status: session.StatusIdle})
+	}
+	sessions = append(sessions, session.Session{SessionID: "wait-9", Project: "/p", Status: session.StatusWaiting})
+	opts := viewOptions{maxRows: 3}
+
+	t.Run("boxes over the cap should keep the sessions needing attention most", func(t *testing.T) {
+		groups := displayGroups(sessions, opts)
+		var ids []string
+		for _, s := range groups[0].Sessions {
+			ids = append(ids, s.SessionID)
+		}
+		if got := strings.Join(ids, ","); got != "idle-0,idle-1,wait-9" {
+			t.Errorf("shown = %s, want idle-0,idle-1,wait-9 in their usual order", got)
+		}
+		if groups[0].More != 3 {
+			t.Errorf("More = %d, want 3", groups[0].More)
+		}
+	})
+
+	t.Run("folded box should end with an and-more line", func(t *testing.T) {
+		out := ansi.Strip(render(sessions, sp, 100, nil, "", opts, "", nil))
+		if !strings.Contains(out, "… and 3 more (press z to expand)") {
+			t.Errorf("expected the and-more line, got:\n%s", out)
+		}
+	})
+
+	t.Run("expand should toggle the selected session's box", func(t *testing.T) {
+		m := Model{sessions: sessions, maxRows: 3, hoverSID: "wait-9"}
+		next, _ := m.do(actionExpand)
+		if got := len(next.(Model).displayOrder()[0]); got != 6 {
+			t.Errorf("expanded box shows %d sessions, want 6", got)
+		}
+		next, _ = next.(Model).do(actionExpand)
+		if got := len(next.(Model).displayOrder()[0]); got != 3 {
+			t.Errorf("folded box shows %d sessions, want 3", got)
+		}
+	})
+}
+
+// benchSessions returns n sessions spread over 15 projects in mixed states.
+func benchSessions(n int) []session.Session {
+	statuses := []string{"working", "idle", "waiting", "starting"}
+	var sessions []session.Session
+	for i := range n {
+		sessions = append(sessions, session.Session{
+			SessionID:    fmt.Sprintf("%08d-session", i),
+			Project:      fmt.Sprintf("/home/user/project-%02d", i%15),
+			Status:       statuses[i%len(statuses)],
+			Detail:       "Edit internal/monitor/render.go",
+			LastPrompt:   "Refactor the render path so it stays fast with many sessions",
+			LastActivity: time.Now().Add(-time.Duration(i) * time.Minute).Format(time.RFC3339),
+		})
+	}
+	return sessions
+}
+
+func BenchmarkRender(b *testing.B) {
+	sp := spinner.New()
+	sp.Spinner = spinner.MiniDot
+	sessions := benchSessions(120)
+
+	b.Run("uncached", func(b *testing.B) {
+		for range b.N {
+			render(sessions, sp, 120, nil, "", viewOptions{}, "", nil)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		cache := newRenderCache()
+		for range b.N {
+			render(sessions, sp, 120, nil, "", viewOptions{}, "", cache)
+		}
+	})
+}
+
+func TestStartLaunch(t *testing.T) {
+	sessions := []session.Session{{SessionID: "s1", Project: "/home/me/api"}}
+
+	t.Run("hovered session should pre-fill its project", func(t *testing.T) {
+		m := Model{sessions: sessions, hoverSID: "s1", project: "/home/me"}
+		got, _ := m.startLaunch()
+		m = got.(Model)
+		if m.prompt != promptLaunch {
+			t.Fatalf("prompt = %v, want promptLaunch", m.prompt)
+		}
+		if m.input.Value() != "/home/me/api" {
+			t.Errorf("input = %q, want %q", m.input.Value(), "/home/me/api")
+		}
+	})
+
+	t.Run("without hover the project filter should be used", func(t *testing.T) {
+		m := Model{sessions: sessions, project: "/home/me"}
+		got, _ := m.startLaunch()
+		if v := got.(Model).input.Value(); v != "/home/me" {
+			t.Errorf("input = %q, want %q", v, "/home/me")
+		}
+	})
+
+	t.Run("esc should close the prompt without launching", func(t *testing.T) {
+		m := Model{sessions: sessions, hoverSID: "s1"}
+		got, _ := m.startLaunch()
+		got, cmd := got.(Model).Update(tea.KeyMsg{Type: tea.KeyEsc})
+		if got.(Model).prompt != promptNone {
+			t.Error("prompt should be closed after esc")
+		}
+		if cmd != nil {
+			t.Error("esc should not return a command")
+		}
+	})
+}
+
+func TestStartReply(t *testing.T) {
+	t.Run("session without terminals should not open the prompt", func(t *testing.T) {
+		m := Model{sessions: []session.Session{{SessionID: "s1", Project: "/p"}}, hoverSID: "s1"}
+		got, _ := m.startReply()
+		if got.(Model).prompt != promptNone {
+			t.Error("prompt should not open without terminal info")
+		}
+	})
+
+	t.Run("hovered session with a pane should open the reply prompt", func(t *testing.T) {
+		m := Model{
+			sessions: []session.Session{{SessionID: "s1", Project: "/p", Terminals: []session.Terminal{{Backend: "tmux", ID: "%1"}}}},
+			hoverSID: "s1",
+		}
+		got, _ := m.startReply()
+		m = got.(Model)
+		if m.prompt != promptReply || m.promptSID != "s1" {
+			t.Errorf("prompt = %v for %q, want promptReply for s1", m.prompt, m.promptSID)
+		}
+	})
+}
+
+func TestAnswerPermission(t *testing.T) {
+	perm := "permission_prompt"
+	waiting := session.Session{
+		SessionID: "s1", Project: "/p", Status: session.StatusWaiting, NotificationType: &perm,
+		Terminals: []session.Terminal{{Backend: "tmux", ID: "%1"}},
+	}
+
+	t.Run("session not waiting for permission should not be sent anything", func(t *testing.T) {
+		idle := waiting
+		idle.Status = session.StatusIdle
+		m := Model{sessions: []session.Session{idle}, hoverSID: "s1"}
+		got, cmd := m.answerPermission("y")
+		if cmd != nil {
+			t.Error("expected no command for a session that is not waiting")
+		}
+		if got.(Model).statusMsg == "" {
+			t.Error("expected a status message explaining why nothing happened")
+		}
+	})
+
+	t.Run("hovered permission prompt should be answered", func(t *testing.T) {
+		m := Model{sessions: []session.Session{waiting}, hoverSID: "s1"}
+		if _, cmd := m.answerPermission("3"); cmd == nil {
+			t.Error("expected a send command")
+		}
+	})
+}
+
+func TestKill(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	saved := killGrace
+	killGrace = 0 // the test process doesn't reap the fake, so it never exits
+	t.Cleanup(func() { killGrace = saved })
+	dir := t.TempDir()
+	pid := proctest.StartFakeClaude(t)
+	s := session.Session{SessionID: "s1", Project: "/p", Status: session.StatusWorking, PID: pid, PIDStart: proc.StartTime(pid)}
+	os.WriteFile(filepath.Join(dir, "s1.json"), []byte("{}"), 0644)
+
+	m := Model{sessions: []session.Session{s}, hoverSID: "s1", source: sessionSource{dirs: []string{dir}}}
+	got, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	if got.(Model).confirmAction != actionKill {
+		t.Fatal("K should ask to confirm the kill")
+	}
+	_, cmd := got.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if cmd == nil {
+		t.Fatal("expected a kill command after confirming")
+	}
+	if msg := cmd().(sendResultMsg); msg.err != nil {
+		t.Fatalf("unexpected error: %v", msg.err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "s1.json")); !os.IsNotExist(err) {
+		t.Error("session file should be removed after the kill")
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	working := session.Session{
+		SessionID: "s1", Project: "/p", Status: session.StatusWorking,
+		Terminals: []session.Terminal{{Backend: "tmux", ID: "%1"}},
+	}
+
+	t.Run("idle session should not ask for confirmation", func(t *testing.T) {
+		idle := working
+		idle.Status = session.StatusIdle
+		m := Model{sessions: []session.Session{idle}, hoverSID: "s1"}
+		got, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+		if got.(Model).confirmSID != "" {
+			t.Error("idle session should not be pending interrupt")
+		}
+	})
+
+	t.Run("y should confirm the interrupt", func(t *testing.T) {
+		m := Model{sessions: []session.Session{working}, hoverSID: "s1"}
+		got, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+		if got.(Model).confirmSID != "s1" {
+			t.Fatal("working session should be pending interrupt")
+		}
+		got, cmd := got.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+		if got.(Model).confirmSID != "" {
+			t.Error("pending interrupt should be cleared after confirming")
+		}
+		if cmd == nil {
+			t.Error("expected a send command after confirming")
+		}
+	})
+
+	t.Run("any other key should cancel", func(t *testing.T) {
+		m := Model{sessions: []session.Session{working}, hoverSID: "s1", confirmSID: "s1", confirmAction: actionInterrupt}
+		got, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+		if got.(Model).confirmSID != "" {
+			t.Error("pending interrupt should be cleared")
+		}
+		if cmd != nil {
+			t.Error("cancelling should not send anything or quit")
+		}
+	})
+}
+
+func TestRenderDetail(t *testing.T) {
+	prompt := strings.Repeat("refactor the session loader ", 10) + "and keep the tail visible"
+	s := session.Session{
+		SessionID:  "abcd1234",
+		Project:    "/home/me/api",
+		Status:     session.StatusWorking,
+		Detail:     "Running: go test ./...",
+		LastPrompt: prompt,
+	}
+
+	t.Run("long prompt should be wrapped, not truncated", func(t *testing.T) {
+		out := renderDetail(s, spinner.New(), 60, projectNames{})
+		for _, line := range strings.Split(out, "\n") {
+			if w := lipgloss.Width(line); w > 60 {
+				t.Errorf("line wider than terminal (%d): %q", w, line)
+			}
+		}
+		text := strings.Join(strings.Fields(strings.ReplaceAll(out, "│", "")), " ")
+		if !strings.Contains(text, "keep the tail visible") {
+			t.Error("end of the prompt should be shown")
+		}
+	})
+
+	t.Run("detail and session ID should be shown", func(t *testing.T) {
+		out := renderDetail(s, spinner.New(), 100, projectNames{})
+		for _, want := range []string{"Running: go test ./...", "abcd1234"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output should contain %q", want)
+			}
+		}
+	})
+
+	t.Run("resumed session should name its predecessor", func(t *testing.T) {
+		resumed := s
+		resumed.ResumedFrom = "a1b2c3d4-5678-90ab"
+		out := renderDetail(resumed, spinner.New(), 100, projectNames{})
+		if !strings.Contains(out, "resumed from a1b2c3d4 ") {
+			t.Errorf("output should contain the short predecessor ID:\n%s", out)
+		}
+	})
+
+	t.Run("environment should list terminal, shell, OS and backends", func(t *testing.T) {
+		env := s
+		env.Env = &session.Env{Terminal: "vscode 1.95.0", Shell: "zsh"}
+		env.OS = "linux"
+		env.Terminals = []session.Terminal{{Backend: "tmux", ID: "%3"}}
+		out := renderDetail(env, spinner.New(), 100, projectNames{})
+		if !strings.Contains(out, "vscode 1.95.0 · zsh · linux · tmux %3") {
+			t.Errorf("output should describe the environment:\n%s", out)
+		}
+	})
+
+	t.Run("PID, notification type and full timestamps should be shown", func(t *testing.T) {
+		perm := "permission_prompt"
+		waiting := s
+		waiting.Status = session.StatusWaiting
+		waiting.NotificationType = &perm
+		waiting.PID = 4242
+		waiting.Host = "laptop"
+		waiting.LastActivity = "2026-03-04T05:06:07Z"
+		out := renderDetail(waiting, spinner.New(), 100, projectNames{})
+		want := []string{"permission_prompt", "PID 4242 · on laptop", session.In(time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC).Local()).Format("2006-01-02 15:04:05")}
+		for _, w := range want {
+			if !strings.Contains(out, w) {
+				t.Errorf("output should contain %q:\n%s", w, out)
+			}
+		}
+	})
+}
+
+func TestRenderHelp(t *testing.T) {
+	t.Run("hints should wrap to fit the width", func(t *testing.T) {
+		out := renderHelp(viewOptions{}, 40)
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		if len(lines) < 2 {
+			t.Fatalf("expected help to wrap at width 40, got %q", out)
+		}
+		for _, line := range lines {
+			if w := lipgloss.Width(line); w > 40 {
+				t.Errorf("line wider than 40 (%d): %q", w, line)
+			}
+		}
+	})
+
+	t.Run("wide terminal should fit hints on one line", func(t *testing.T) {
+		out := strings.TrimSpace(renderHelp(viewOptions{}, 200))
+		if strings.Contains(out, "\n") {
+			t.Errorf("expected a single line, got %q", out)
+		}
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	sessions := benchSessions(40)
+	m := Model{sessions: sessions, height: 10, offset: 5, hoverSID: sessions[0].SessionID}
+
+	out := ansi.Strip(m.Snapshot(100))
+	if got := strings.Count(out, "\n") + 1; got <= m.height {
+		t.Errorf("got %d lines, want the whole view however small the window", got)
+	}
+	if !strings.Contains(out, "project-14") || !strings.Contains(out, "q quit") {
+		t.Errorf("expected every box and the help line, got:\n%s", out)
+	}
+}
+
+func TestMarkStalled(t *testing.T) {
+	now := time.Now()
+	quiet := now.Add(-15 * time.Minute).UTC().Format(time.RFC3339)
+	recent := now.Add(-time.Minute).UTC().Format(time.RFC3339)
+
+	t.Run("quiet working session should be stalled", func(t *testing.T) {
+		sessions := []session.Session{
+			{SessionID: "hung", Status: session.StatusWorking, LastActivity: quiet},
+			{SessionID: "busy", Status: session.StatusWorking, LastActivity: recent},
+			{SessionID: "idle", Status: session.StatusIdle, LastActivity: quiet},
+		}
+		got := MarkStalled(sessions, 10*time.Minute, now)
+		if len(got) != 1 || got[0] != "hung" {
+			t.Errorf("stalled = %v, want [hung]", got)
+		}
+		if sessions[0].Status != session.StatusStalled {
+			t.Errorf("hung status = %q, want stalled", sessions[0].Status)
+		}
+		if sessions[1].Status != session.StatusWorking || sessions[2].Status != session.StatusIdle {
+			t.Error("other sessions should keep their status")
+		}
+	})
+
+	t.Run("zero threshold should disable detection", func(t *testing.T) {
+		sessions := []session.Session{{SessionID: "hung", Status: session.StatusWorking, LastActivity: quiet}}
+		if got := MarkStalled(sessions, 0, now); got != nil || sessions[0].Status != session.StatusWorking {
+			t.Error("nothing should be stalled with detection disabled")
+		}
+	})
+}
+
+func TestMenu(t *testing.T) {
+	perm := "permission_prompt"
+	waiting := session.Session{
+		SessionID: "s1", Project: "/p", Status: session.StatusWaiting, PID: 42, NotificationType: &perm,
+		Terminals: []session.Terminal{{Backend: "tmux", ID: "%1"}},
+	}
+	press := func(m tea.Model, key string) tea.Model {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		}
+		got, _ := m.Update(msg)
+		return got
+	}
+
+	t.Run("items should depend on the session state", func(t *testing.T) {
+		m := Model{}
+		var labels []string
+		for _, item := range m.menuItems(waiting) {
+			labels = append(labels, item.label)
+		}
+		got := strings.Join(labels, ",")
+		if !strings.Contains(got, "Approve") || strings.Contains(got, "Interrupt") {
+			t.Errorf("items = %s, want approve but no interrupt for a waiting session", got)
+		}
+		if !strings.Contains(got, "Kill") {
+			t.Errorf("items = %s, want kill for a session with a PID", got)
+		}
+	})
+
+	t.Run("enter should run the selected action", func(t *testing.T) {
+		var m tea.Model = Model{sessions: []session.Session{waiting}, hoverSID: "s1"}
+		m = press(m, "m")
+		if m.(Model).menuSID != "s1" {
+			t.Fatal("menu should be open for the hovered session")
+		}
+		m = press(m, "down") // View details
+		m = press(m, "enter")
+		if m.(Model).menuSID != "" {
+			t.Error("menu should close after running an action")
+		}
+		if m.(Model).detailSID != "s1" {
+			t.Error("expected the detail overlay to open")
+		}
+	})
+
+	t.Run("custom actions should be listed last", func(t *testing.T) {
+		km, _ := newKeymap(nil, []config.Action{{Key: "o", Name: "open", Command: "true"}})
+		items := Model{keys: km}.menuItems(waiting)
+		if last := items[len(items)-1]; last.custom == nil || last.label != "open" || last.hint != "o" {
+			t.Errorf("last item = %+v, want the custom action", last)
+		}
+	})
+}
+
+func TestOpenEditor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	s := session.Session{SessionID: "s1", Project: dir, Status: session.StatusIdle}
+
+	t.Run("configured command should run in the project dir", func(t *testing.T) {
+		m := Model{sessions: []session.Session{s}, hoverSID: "s1", editorCmd: "touch opened"}
+		_, cmd := m.openEditor()
+		if cmd == nil {
+			t.Fatal("expected a command")
+		}
+		if msg := cmd().(openResultMsg); msg.err != nil {
+			t.Fatalf("unexpected error: %v", msg.err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "opened")); err != nil {
+			t.Errorf("editor command did not run in %s: %v", dir, err)
+		}
+	})
+
+	t.Run("double-click should open the editor instead of switching", func(t *testing.T) {
+		m := Model{sessions: []session.Session{s}, editorCmd: "true", clickMap: clickTargets{columns: []map[int]string{{3: "s1"}}}}
+		click := tea.MouseMsg{Y: 3, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+		got, _ := m.Update(click)
+		if got.(Model).statusMsg != "Switching to "+baseName(dir)+"..." {
+			t.Errorf("first click status = %q, want switching", got.(Model).statusMsg)
+		}
+		got, _ = got.(Model).Update(click)
+		if got.(Model).statusMsg != "Opening "+baseName(dir)+"..." {
+			t.Errorf("second click status = %q, want opening", got.(Model).statusMsg)
+		}
+	})
+}
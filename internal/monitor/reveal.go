@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// revealArgs returns the command that shows dir in the file manager, and the
+// directory to run it in. goos is the monitor's OS, sessionOS the OS the
+// session recorded its project path on, and wsl whether the monitor runs
+// inside WSL. Explorer is started from inside the directory where possible,
+// because WSL interop then translates the Linux path for it.
+func revealArgs(dir, goos, sessionOS string, wsl bool) (args []string, cwd string) {
+	switch {
+	case goos == "windows" && sessionOS != "" && sessionOS != "windows":
+		return []string{"wsl.exe", "--cd", dir, "explorer.exe", "."}, ""
+	case goos == "windows":
+		return []string{"explorer.exe", dir}, ""
+	case wsl && sessionOS == "windows":
+		return []string{"explorer.exe", dir}, ""
+	case wsl:
+		return []string{"explorer.exe", "."}, dir
+	case goos == "darwin":
+		return []string{"open", dir}, ""
+	default:
+		return []string{"xdg-open", dir}, ""
+	}
+}
+
+// reveal opens the hovered session's project in the platform file manager.
+func (m Model) reveal() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok {
+		m.setStatus("Hover over a session to show its files")
+		return m, nil
+	}
+	args, cwd := revealArgs(s.Project, runtime.GOOS, s.OS, os.Getenv("WSL_DISTRO_NAME") != "")
+	m.setStatus(fmt.Sprintf("Opening %s...", m.names.name(s.Project)))
+	return m, func() tea.Msg {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = cwd
+		err := cmd.Run()
+		var exit *exec.ExitError
+		if slices.Contains(args, "explorer.exe") && errors.As(err, &exit) {
+			// explorer.exe exits with 1 even when it opened the window.
+			err = nil
+		}
+		return openResultMsg{dir: s.Project, app: "the file manager", err: err}
+	}
+}
@@ -0,0 +1,33 @@
+package monitor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRevealArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		dir       string
+		goos      string
+		sessionOS string
+		wsl       bool
+		want      []string
+		wantCwd   string
+	}{
+		{"macOS", "/Users/me/api", "darwin", "darwin", false, []string{"open", "/Users/me/api"}, ""},
+		{"Linux", "/home/me/api", "linux", "linux", false, []string{"xdg-open", "/home/me/api"}, ""},
+		{"Windows", `C:\src\api`, "windows", "windows", false, []string{"explorer.exe", `C:\src\api`}, ""},
+		{"WSL session from Windows", "/home/me/api", "windows", "linux", false, []string{"wsl.exe", "--cd", "/home/me/api", "explorer.exe", "."}, ""},
+		{"inside WSL", "/home/me/api", "linux", "linux", true, []string{"explorer.exe", "."}, "/home/me/api"},
+		{"Windows session from WSL", `C:\src\api`, "linux", "windows", true, []string{"explorer.exe", `C:\src\api`}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, cwd := revealArgs(tt.dir, tt.goos, tt.sessionOS, tt.wsl)
+			if !slices.Equal(args, tt.want) || cwd != tt.wantCwd {
+				t.Errorf("revealArgs = %q in %q, want %q in %q", args, cwd, tt.want, tt.wantCwd)
+			}
+		})
+	}
+}
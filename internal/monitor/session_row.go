@@ -7,6 +7,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/martinwickman/ccmonitor/internal/session"
 )
 
@@ -16,15 +17,32 @@ type sessionRow struct {
 	connector       string
 	shortID         string
 	pid             int
+	model           string // short model name, shown in debug mode
 	status          string
 	detail          string
 	elapsed         string
 	rawLastActivity string
 	prompt          string
+	note            string
+	lastError       string
+	toolFailed      bool   // the detail names a tool call that just failed, shown in red
+	context         string // styled context usage bar from the status line, shown before elapsed
+	cost            string // styled estimated cost ("$1.24"), shown before the context bar
+	compacted       string // styled "⟲ compacted 5m ago", shown before the context bar
+	statusAge       string // styled "for 12m", shown after the status
+	queued          string // styled "+2 queued", shown after the status
+	history         string // styled status glyphs ("●◆●○"), shown before compaction
+	subagents       []session.Subagent
 	isQuoted        bool // true if prompt should be wrapped in quotes
 	isLast          bool
-	flashPhase      int // 0=none, 1=brightest ... 10=dimmest
+	worktree        string // branch/worktree label, set for merged worktree groups
+	project         string // project label, set in the flat (ungrouped) view
+	agent           string // agent name, set only for non-Claude sessions
+	host            string // originating host, set only for sessions from another machine
+	flashPhase      int    // 0=none, 1=brightest ... 10=dimmest
+	collapsed       bool   // long idle: render as one faint line
 	debug           bool
+	extra           []string // custom column cells, shown before elapsed
 }
 
 // newSessionRow builds a sessionRow from a session, applying truncation, styling,
@@ -37,17 +55,18 @@ func newSessionRow(s session.Session, isLast bool, sp spinner.Model, flashUntil
 		connector = "└─"
 	}
 
-	shortID := s.SessionID
-	if len(shortID) > 8 {
-		shortID = shortID[:8]
-	}
+	shortID := session.Truncate(s.SessionID, 8)
 
-	indicator, style, label := statusDisplay(s.Status, sp)
+	indicator, style, label := sessionStatusDisplay(s, sp)
 	elapsed := session.TimeSince(s.LastActivity)
 
+	// Measure in terminal cells, not runes: CJK and emoji take two columns.
 	detail := s.Detail
-	if len(detail) > 40 {
-		detail = detail[:38] + " …"
+	if s.Status == session.StatusLimited {
+		detail = limitCountdown(s.LimitResetsAt, now)
+	}
+	if lipgloss.Width(detail) > 40 {
+		detail = ansi.Truncate(detail, 38, "") + " …"
 	}
 
 	// Treat default "Claude Code" tab title as empty — it's not useful.
@@ -76,57 +95,95 @@ func newSessionRow(s session.Session, isLast bool, sp spinner.Model, flashUntil
 
 	phase := flashPhase(now, flashUntil[s.SessionID])
 
+	var agent string
+	if !s.IsClaude() {
+		agent = s.Agent
+	}
+
+	var host string
+	if s.OnOtherHost(localHost()) {
+		host = s.Host
+	}
+
 	return sessionRow{
 		sessionID:       s.SessionID,
 		connector:       connector,
-		shortID:         lipgloss.NewStyle().Faint(true).Render(shortID),
+		shortID:         faintStyle.Render(shortID),
 		pid:             s.PID,
+		model:           session.ModelName(s.Model),
 		status:          style.Render(indicator + " " + label),
 		detail:          detail,
-		elapsed:         lipgloss.NewStyle().Faint(true).Render(elapsed),
+		elapsed:         faintStyle.Render(elapsed),
 		rawLastActivity: s.LastActivity,
 		prompt:          prompt,
+		note:            s.Note,
+		lastError:       s.LastError,
+		toolFailed:      s.ToolFailed && s.Status == session.StatusWorking,
+		context:         contextBar(s.ContextLeft),
+		cost:            costLabel(s.Usage),
+		compacted:       compactedLabel(s.Compacted, now),
+		statusAge:       statusAgeLabel(s, now),
+		queued:          queuedLabel(s.Queued),
+		history:         historyStrip(s.History),
+		agent:           agent,
+		subagents:       s.Subagents,
 		isQuoted:        isQuoted,
 		isLast:          isLast,
+		host:            host,
 		flashPhase:      phase,
 		debug:           debug,
 	}
 }
 
 // render produces the full output for this row: line 1 is the prompt/summary
-// with session ID, line 2 is the status/detail/elapsed.
+// with session ID, then the user's note (if any), then the status/detail/elapsed,
+// then the last tool error (if any), then one line per running subagent.
 func (r sessionRow) render(w columnWidths, hovered bool) string {
+	if r.collapsed {
+		return r.renderCollapsed(w, hovered)
+	}
 	elapsed := r.elapsed
 	if r.flashPhase == 1 {
-		elapsed = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("9")). // bright red
-			Bold(true).
-			Render(session.TimeSince(r.rawLastActivity))
+		elapsed = flashStyle.Render(session.TimeSince(r.rawLastActivity))
 	} else if r.flashPhase == 2 {
-		elapsed = lipgloss.NewStyle().Faint(true).
-			Render(session.TimeSince(r.rawLastActivity))
+		elapsed = faintStyle.Render(session.TimeSince(r.rawLastActivity))
 	}
 
 	// Style connector: bold when hovered, faint otherwise
 	var styledConn string
 	if hovered {
-		styledConn = lipgloss.NewStyle().Bold(true).Render(r.connector)
+		styledConn = boldStyle.Render(r.connector)
 	} else {
-		styledConn = lipgloss.NewStyle().Faint(true).Render(r.connector)
+		styledConn = faintStyle.Render(r.connector)
 	}
 
 	// Line 1: connector + prompt/summary, with optional (shortID:PID) in debug mode
 	textStyle := promptStyle
-	faintStyle := lipgloss.NewStyle().Faint(true)
+	idStyle := faintStyle
 	if hovered {
-		textStyle = lipgloss.NewStyle().Bold(true)
-		faintStyle = lipgloss.NewStyle().Bold(true)
+		textStyle = boldStyle
+		idStyle = boldStyle
+	}
+
+	// Project, host and worktree labels go right after the connector
+	var label string
+	if r.project != "" {
+		label += projectStyle.Render(r.project) + " "
+	}
+	if r.agent != "" {
+		label += agentStyle.Render("["+r.agent+"]") + " "
+	}
+	if r.host != "" {
+		label += hostStyle.Render("@"+r.host) + " "
+	}
+	if r.worktree != "" {
+		label += faintStyle.Render("⎇ "+r.worktree) + " "
 	}
 
 	// Compute available width for prompt text, then truncate to fit
 	prompt := r.prompt
 	if w.contentWidth > 0 && prompt != "" {
-		available := w.contentWidth - w.conn - 1 - 8 // connector + space + right margin
+		available := w.contentWidth - w.conn - 1 - 8 - lipgloss.Width(label) // connector + space + label + right margin
 		if r.isQuoted {
 			available -= 2 // surrounding quotes
 		}
@@ -136,16 +193,19 @@ func (r sessionRow) render(w columnWidths, hovered bool) string {
 			if r.pid > 0 {
 				suffixLen += 1 + len(fmt.Sprintf("%d", r.pid)) // : + PID digits
 			}
+			if r.model != "" {
+				suffixLen += 1 + len(r.model) // space + model
+			}
 			available -= suffixLen
 		}
 		if available < 0 {
 			available = 0
 		}
-		if len(prompt) > available {
+		if lipgloss.Width(prompt) > available {
 			if available > 2 {
-				prompt = prompt[:available-2] + "…"
+				prompt = ansi.Truncate(prompt, available-2, "") + "…"
 			} else {
-				prompt = prompt[:available]
+				prompt = ansi.Truncate(prompt, available, "")
 			}
 		}
 	}
@@ -153,40 +213,88 @@ func (r sessionRow) render(w columnWidths, hovered bool) string {
 		prompt = "\"" + prompt + "\""
 	}
 
-	var line1 string
+	line1 := padRight(styledConn, w.conn) + " " + label
 	if r.debug {
 		idPart := r.shortID
 		if r.pid > 0 {
 			idPart += ":" + fmt.Sprintf("%d", r.pid)
 		}
+		if r.model != "" {
+			idPart += " " + r.model
+		}
 		if prompt != "" {
-			line1 = padRight(styledConn, w.conn) + " " +
-				textStyle.Render(prompt) + " " +
-				faintStyle.Render("("+idPart+")")
+			line1 += textStyle.Render(prompt) + " " +
+				idStyle.Render("("+idPart+")")
 		} else {
-			line1 = padRight(styledConn, w.conn) + " " +
-				faintStyle.Render(idPart)
+			line1 += idStyle.Render(idPart)
 		}
 	} else {
 		if prompt != "" {
-			line1 = padRight(styledConn, w.conn) + " " +
-				textStyle.Render(prompt)
+			line1 += textStyle.Render(prompt)
 		} else {
-			line1 = padRight(styledConn, w.conn) + " " +
-				faintStyle.Render("…")
+			line1 += idStyle.Render("…")
 		}
 	}
 
-	// Line 2: indent + status + detail ... elapsed (right-aligned)
-	indent := lipgloss.NewStyle().Faint(true).Render("│") + "  "
+	indent := faintStyle.Render("│") + "  "
 	if r.isLast {
 		indent = "   "
 	}
-	leftPart := indent +
-		padRight(r.status, w.status) + "  " +
-		r.detail
 
+	// Optional note line, indented like the status line
+	var noteLine string
+	if r.note != "" {
+		note := "✎ " + r.note
+		if w.contentWidth > 0 {
+			if available := w.contentWidth - lipgloss.Width(indent); lipgloss.Width(note) > available && available > 1 {
+				note = ansi.Truncate(note, available-1, "") + "…"
+			}
+		}
+		noteLine = indent + noteStyle.Render(note) + "\n"
+	}
+
+	// Line 2: indent + status + detail ... elapsed (right-aligned)
+	// Custom columns, compaction, cost and context sit right before elapsed
+	if r.context != "" {
+		elapsed = r.context + "  " + elapsed
+	}
+	if r.cost != "" {
+		elapsed = r.cost + "  " + elapsed
+	}
+	if r.compacted != "" {
+		elapsed = r.compacted + "  " + elapsed
+	}
+	if r.history != "" {
+		elapsed = r.history + "  " + elapsed
+	}
+	if len(r.extra) > 0 {
+		elapsed = faintStyle.Render(strings.Join(r.extra, "  ")) + "  " + elapsed
+	}
 	elapsedWidth := lipgloss.Width(elapsed)
+
+	// Shorten the detail rather than wrap when the right side is wide
+	leftPart := indent + padRight(r.status, w.status) + "  "
+	if r.statusAge != "" {
+		leftPart += r.statusAge + "  "
+	}
+	if r.queued != "" {
+		leftPart += r.queued + "  "
+	}
+	detail := r.detail
+	if w.contentWidth > 0 {
+		available := w.contentWidth - elapsedWidth - 2 - lipgloss.Width(leftPart)
+		if lipgloss.Width(detail) > available {
+			if available > 1 {
+				detail = ansi.Truncate(detail, available-1, "") + "…"
+			} else {
+				detail = ""
+			}
+		}
+	}
+	if r.toolFailed {
+		detail = errorStyle.Render(detail)
+	}
+	leftPart += detail
 	leftWidth := lipgloss.Width(leftPart)
 	// Right-align elapsed to contentWidth, with at least 2 spaces gap
 	targetWidth := w.contentWidth - elapsedWidth
@@ -197,7 +305,193 @@ func (r sessionRow) render(w columnWidths, hovered bool) string {
 	}
 	line2 := leftPart + elapsed
 
-	return line1 + "\n" + line2 + "\n"
+	// Optional error line: the last failed tool call, until a tool succeeds
+	var errorLine string
+	if r.lastError != "" {
+		text := "✗ " + r.lastError
+		if w.contentWidth > 0 {
+			if available := w.contentWidth - lipgloss.Width(indent); lipgloss.Width(text) > available && available > 1 {
+				text = ansi.Truncate(text, available-1, "") + "…"
+			}
+		}
+		errorLine = indent + exitedStyle.Render(text) + "\n"
+	}
+
+	// Subagent lines: nested under the status line with their own glyph
+	var subLines string
+	for _, a := range r.subagents {
+		text := a.Description
+		if text == "" {
+			text = "Task"
+		}
+		if a.Type != "" {
+			text = a.Type + ": " + text
+		}
+		since := "  " + session.TimeSince(a.Started)
+		prefix := indent + faintStyle.Render("  ↳ ")
+		if w.contentWidth > 0 {
+			available := w.contentWidth - lipgloss.Width(prefix) - lipgloss.Width(since)
+			if lipgloss.Width(text) > available && available > 1 {
+				text = ansi.Truncate(text, available-1, "") + "…"
+			}
+		}
+		subLines += prefix + subagentStyle.Render(text) + faintStyle.Render(since) + "\n"
+	}
+
+	return line1 + "\n" + noteLine + line2 + "\n" + errorLine + subLines
+}
+
+// extraLines returns how many lines a session's row has below its first
+// (connector) line.
+func extraLines(s session.Session) int {
+	n := 1 // status line
+	if s.Note != "" {
+		n++
+	}
+	if s.LastError != "" {
+		n++
+	}
+	return n + len(s.Subagents)
+}
+
+// contextBarWidth is the number of cells in the context usage bar.
+const contextBarWidth = 5
+
+// contextBar renders how much of the context window is used as a small bar
+// and percentage ("ctx ███░░ 62%"), yellow from 75% and red from 90% ("" when
+// unknown).
+func contextBar(left *int) string {
+	if left == nil {
+		return ""
+	}
+	used := min(max(100-*left, 0), 100)
+	filled := (used*contextBarWidth + 50) / 100
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", contextBarWidth-filled)
+	style := faintStyle
+	switch {
+	case used >= 90:
+		style = exitedStyle
+	case used >= 75:
+		style = waitingStyle
+	}
+	return faintStyle.Render("ctx ") + style.Render(fmt.Sprintf("%s %d%%", bar, used))
+}
+
+// costLabel renders the estimated cost of a session's tokens, or "" before
+// its first turn has been counted.
+func costLabel(u *session.Usage) string {
+	if u == nil || u.InputTokens+u.OutputTokens+u.CacheCreationTokens+u.CacheReadTokens == 0 {
+		return ""
+	}
+	return faintStyle.Render(formatCost(u.CostUSD))
+}
+
+// formatCost formats a dollar amount: "$0.42", "$12.30".
+func formatCost(usd float64) string {
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+// formatTokens abbreviates a token count: "950", "12.3k", "1.2M".
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// recentCompaction is how long a compaction stays highlighted on the row.
+const recentCompaction = 15 * time.Minute
+
+// compactedLabel tells when the conversation was last compacted, in yellow
+// while that is recent ("" when it never was).
+func compactedLabel(compacted string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, compacted)
+	if err != nil {
+		return ""
+	}
+	text := "⟲ compacted " + session.TimeSince(compacted)
+	if now.Sub(t) < recentCompaction {
+		return waitingStyle.Render(text)
+	}
+	return faintStyle.Render(text)
+}
+
+// statusAge returns how long s has had its status, or false when that isn't
+// known. Stalled, exited and untracked are decided by the monitor, so the
+// hook's status_since doesn't apply to them.
+func statusAge(s session.Session, now time.Time) (time.Duration, bool) {
+	switch s.Status {
+	case session.StatusStalled, session.StatusExited, session.StatusUntracked:
+		return 0, false
+	}
+	t, err := time.Parse(time.RFC3339, s.StatusSince)
+	if err != nil {
+		return 0, false
+	}
+	return max(now.Sub(t), 0), true
+}
+
+// statusAgeLabel renders how long s has had its status ("for 12m"), or ""
+// when that reads the same as the time since its last activity, shown at the
+// end of the row anyway.
+func statusAgeLabel(s session.Session, now time.Time) string {
+	age, ok := statusAge(s, now)
+	if !ok {
+		return ""
+	}
+	last, err := time.Parse(time.RFC3339, s.LastActivity)
+	if err == nil && session.Duration(age) == session.Duration(now.Sub(last)) {
+		return ""
+	}
+	return faintStyle.Render("for " + session.Duration(age))
+}
+
+// historyStrip renders a status history as one glyph per status in its
+// status color, e.g. "●◆●◆●○", or "" until the status has changed.
+func historyStrip(history []string) string {
+	if len(history) < 2 {
+		return ""
+	}
+	var b strings.Builder
+	for _, status := range history {
+		indicator, style, _ := statusDisplay(status, spinner.Model{})
+		if status == session.StatusWorking {
+			indicator = "●" // the spinner is for the current status
+		}
+		b.WriteString(style.Render(indicator))
+	}
+	return b.String()
+}
+
+// queuedLabel renders the number of queued messages ("+2 queued"), or ""
+// when there are none.
+func queuedLabel(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return waitingStyle.Render(fmt.Sprintf("+%d queued", n))
+}
+
+// limitCountdown describes the time left until a usage limit resets.
+func limitCountdown(resetsAt string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, resetsAt)
+	if err != nil {
+		return "Usage limit reached"
+	}
+	d := t.Sub(now)
+	switch {
+	case d <= 0:
+		return "Limit reset, ready to resume"
+	case d < time.Minute:
+		return fmt.Sprintf("Resets in %ds", int(d.Seconds())+1)
+	case d < time.Hour:
+		return fmt.Sprintf("Resets in %dm", int(d.Minutes())+1)
+	default:
+		return fmt.Sprintf("Resets in %dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
 }
 
 // padRight pads a string (which may contain ANSI codes) to the given visible width.
@@ -222,17 +516,46 @@ func statusDisplay(status string, sp spinner.Model) (indicator string, style lip
 	switch status {
 	case session.StatusWorking:
 		return sp.View(), workingStyle, "Working"
+	case session.StatusCompacting:
+		return "◎", compactingStyle, "Compacting"
 	case session.StatusWaiting:
 		return "◆", waitingStyle, "Waiting"
 	case session.StatusIdle:
 		return "○", idleStyle, "Idle"
 	case session.StatusStarting:
 		return "◌", startingStyle, "Started"
+	case session.StatusLimited:
+		return "◷", limitedStyle, "Limited"
+	case session.StatusStalled:
+		return "⧖", stalledStyle, "Stalled"
+	case session.StatusError:
+		return "⚠", errorStyle, "Error"
 	case session.StatusExited:
 		return "✕", exitedStyle, "Exited"
 	case session.StatusEnded:
 		return "─", idleStyle, "Ended"
+	case session.StatusUntracked:
+		return "◇", idleStyle, "Untracked"
 	default:
 		return "?", idleStyle, status
 	}
 }
+
+// sessionStatusDisplay is statusDisplay for a session, telling thinking
+// apart from running a tool while it works, and counting its subagents.
+func sessionStatusDisplay(s session.Session, sp spinner.Model) (indicator string, style lipgloss.Style, label string) {
+	indicator, style, label = statusDisplay(s.Status, sp)
+	if s.Status == session.StatusWorking {
+		switch s.Activity {
+		case session.ActivityThinking:
+			style, label = style.Italic(true), "Thinking"
+		case session.ActivityTool:
+			label = "Running"
+		}
+		// The main agent waits on its subagents, whatever it did last.
+		if n := len(s.Subagents); n > 0 {
+			style, label = style.Italic(false), "Working ("+plural(n, "agent")+")"
+		}
+	}
+	return indicator, style, label
+}
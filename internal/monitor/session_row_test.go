@@ -4,8 +4,11 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/martinwickman/ccmonitor/internal/session"
 )
 
@@ -95,4 +98,411 @@ func TestSessionRowRender(t *testing.T) {
 			t.Error("line 2 should contain detail text")
 		}
 	})
+
+	t.Run("long multi-byte detail and prompt should stay valid UTF-8", func(t *testing.T) {
+		s := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "working",
+			Detail:       strings.Repeat("ö", 60),
+			LastPrompt:   strings.Repeat("修", 200),
+			LastActivity: time.Now().Format(time.RFC3339),
+		}
+		row := newSessionRow(s, true, sp, nil, false, false)
+		output := row.render(columnWidths{conn: 2, status: 12, contentWidth: 80}, false)
+		if !utf8.ValidString(output) {
+			t.Errorf("output is not valid UTF-8: %q", output)
+		}
+		if !strings.Contains(output, strings.Repeat("ö", 38)+" …") {
+			t.Error("detail should be truncated to 38 runes plus ellipsis")
+		}
+	})
+
+	t.Run("detail should be shortened rather than wrap the status line", func(t *testing.T) {
+		left := 40
+		s := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "working",
+			Detail:       "Bash: go test ./internal/... -run TestSomething",
+			ContextLeft:  &left,
+			Compacted:    time.Now().Format(time.RFC3339),
+			LastActivity: time.Now().Format(time.RFC3339),
+		}
+		output := newSessionRow(s, true, sp, nil, false, false).render(columnWidths{conn: 2, status: 12, contentWidth: 70}, false)
+
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		if w := lipgloss.Width(lines[1]); w > 70 {
+			t.Errorf("status line is %d wide, want at most 70: %q", w, lines[1])
+		}
+		if !strings.Contains(lines[1], "Bash: go") || !strings.Contains(lines[1], "…") {
+			t.Errorf("detail should be shortened, got %q", lines[1])
+		}
+	})
+
+	t.Run("wide CJK prompt should fit the content width", func(t *testing.T) {
+		s := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "idle",
+			Detail:       strings.Repeat("错", 30),
+			LastPrompt:   strings.Repeat("修复这个错误", 20),
+			LastActivity: time.Now().Format(time.RFC3339),
+		}
+		row := newSessionRow(s, true, sp, nil, false, false)
+		w := columnWidths{conn: 2, status: 12, contentWidth: 60}
+		output := row.render(w, false)
+
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		if got := lipgloss.Width(lines[0]); got > w.contentWidth {
+			t.Errorf("prompt line is %d cells wide, want at most %d", got, w.contentWidth)
+		}
+		if got := lipgloss.Width(row.detail); got > 40 {
+			t.Errorf("detail is %d cells wide, want at most 40", got)
+		}
+	})
+
+	t.Run("worktree label should be shown before the prompt", func(t *testing.T) {
+		s := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "idle",
+			LastPrompt:   "Fix the bug",
+			LastActivity: time.Now().Format(time.RFC3339),
+			Git:          &session.Git{Repo: "/src/repo", Worktree: "/src/repo-feature", Branch: "feature/x"},
+		}
+		rows := buildRows([]session.Session{s}, true, sp, nil, false, false)
+		output := rows[0].render(columnWidths{conn: 2, status: 12, contentWidth: 80}, false)
+
+		line1 := strings.SplitN(output, "\n", 2)[0]
+		if !strings.Contains(line1, "⎇ feature/x") {
+			t.Errorf("line 1 should contain the branch label, got %q", line1)
+		}
+		if strings.Index(line1, "feature/x") > strings.Index(line1, "Fix the bug") {
+			t.Error("branch label should come before the prompt")
+		}
+	})
+
+	t.Run("host badge should only be shown for other machines", func(t *testing.T) {
+		base := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "idle",
+			LastPrompt:   "Fix the bug",
+			LastActivity: time.Now().Format(time.RFC3339),
+		}
+		w := columnWidths{conn: 2, status: 12, contentWidth: 80}
+
+		remote := base
+		remote.Host = "buildbox-7"
+		if out := newSessionRow(remote, true, sp, nil, false, false).render(w, false); !strings.Contains(out, "@buildbox-7") {
+			t.Error("remote session should show a host badge")
+		}
+
+		local := base
+		local.Host = localHost()
+		if out := newSessionRow(local, true, sp, nil, false, false).render(w, false); strings.Contains(out, "@"+localHost()) {
+			t.Error("local session should not show a host badge")
+		}
+	})
+
+	t.Run("note should render between prompt and status lines", func(t *testing.T) {
+		s := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "idle",
+			LastPrompt:   "Fix the bug",
+			Note:         "waiting on DB migration",
+			LastActivity: time.Now().Format(time.RFC3339),
+		}
+		output := newSessionRow(s, false, sp, nil, false, false).render(columnWidths{conn: 2, status: 12, contentWidth: 80}, false)
+
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("expected 3 lines, got %d", len(lines))
+		}
+		if !strings.Contains(lines[1], "waiting on DB migration") {
+			t.Errorf("line 2 should contain the note, got %q", lines[1])
+		}
+		if !strings.Contains(lines[2], "Idle") {
+			t.Errorf("line 3 should contain the status, got %q", lines[2])
+		}
+	})
+
+	t.Run("running subagents should render as nested lines after the status", func(t *testing.T) {
+		s := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "working",
+			LastPrompt:   "Refactor loader",
+			LastActivity: time.Now().Format(time.RFC3339),
+			Subagents: []session.Subagent{
+				{ID: "t1", Description: "Find callers", Type: "Explore", Started: time.Now().Format(time.RFC3339)},
+			},
+		}
+		output := newSessionRow(s, false, sp, nil, false, false).render(columnWidths{conn: 2, status: 12, contentWidth: 80}, false)
+
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("expected 3 lines, got %d", len(lines))
+		}
+		if !strings.Contains(lines[2], "↳") || !strings.Contains(lines[2], "Explore: Find callers") {
+			t.Errorf("line 3 should be the subagent, got %q", lines[2])
+		}
+		if extraLines(s) != 2 {
+			t.Errorf("extraLines = %d, want 2", extraLines(s))
+		}
+		if !strings.Contains(lines[1], "Working (1 agent)") {
+			t.Errorf("status line should count the subagents, got %q", lines[1])
+		}
+	})
+
+	t.Run("last tool error should render after the status line", func(t *testing.T) {
+		s := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "working",
+			LastPrompt:   "Run the tests",
+			LastError:    "Bash: exit status 1",
+			LastActivity: time.Now().Format(time.RFC3339),
+			Subagents: []session.Subagent{
+				{ID: "t1", Description: "Find callers", Started: time.Now().Format(time.RFC3339)},
+			},
+		}
+		output := newSessionRow(s, false, sp, nil, false, false).render(columnWidths{conn: 2, status: 12, contentWidth: 80}, false)
+
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("expected 4 lines, got %d", len(lines))
+		}
+		if !strings.Contains(lines[2], "✗ Bash: exit status 1") {
+			t.Errorf("line 3 should be the error, got %q", lines[2])
+		}
+		if !strings.Contains(lines[3], "↳") {
+			t.Errorf("line 4 should be the subagent, got %q", lines[3])
+		}
+		if extraLines(s) != 3 {
+			t.Errorf("extraLines = %d, want 3", extraLines(s))
+		}
+	})
+
+	t.Run("debug mode should show the model after the PID", func(t *testing.T) {
+		s := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "idle",
+			PID:          4242,
+			Model:        "claude-opus-4-1-20250805",
+			LastPrompt:   "Refactor loader",
+			LastActivity: time.Now().Format(time.RFC3339),
+		}
+		output := newSessionRow(s, true, sp, nil, false, true).render(columnWidths{conn: 2, status: 12, contentWidth: 80}, false)
+		if !strings.Contains(output, "(abcd1234:4242 opus-4-1)") {
+			t.Errorf("debug line should show the model, got %q", output)
+		}
+	})
+
+	t.Run("estimated cost should show on the status line", func(t *testing.T) {
+		s := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "idle",
+			Usage:        &session.Usage{InputTokens: 5000, OutputTokens: 800, CostUSD: 1.234},
+			LastActivity: time.Now().Format(time.RFC3339),
+		}
+		output := newSessionRow(s, true, sp, nil, false, false).render(columnWidths{conn: 2, status: 12, contentWidth: 80}, false)
+		if !strings.Contains(output, "$1.23") {
+			t.Errorf("status line should show the cost, got %q", output)
+		}
+		if total, ok := totalCost([]session.Session{s, s, {}}); !ok || total != 2.468 {
+			t.Errorf("totalCost = %v, %v, want 2.468", total, ok)
+		}
+	})
+
+	t.Run("context usage should show on the status line", func(t *testing.T) {
+		left := 8
+		s := session.Session{
+			SessionID:    "abcd1234-full-session-id",
+			Status:       "idle",
+			ContextLeft:  &left,
+			LastActivity: time.Now().Format(time.RFC3339),
+		}
+		output := newSessionRow(s, true, sp, nil, false, false).render(columnWidths{conn: 2, status: 12, contentWidth: 80}, false)
+
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		if len(lines) != 2 || !strings.Contains(lines[1], "ctx █████ 92%") {
+			t.Errorf("status line should show the context usage, got %q", output)
+		}
+	})
+}
+
+func TestAgentBadge(t *testing.T) {
+	sp := spinner.New()
+	w := columnWidths{conn: 2, status: 12, contentWidth: 80}
+
+	t.Run("non-Claude session should show its agent", func(t *testing.T) {
+		s := session.Session{SessionID: "a1", Agent: "aider", Status: "idle", LastPrompt: "add tests"}
+		if out := newSessionRow(s, true, sp, nil, false, false).render(w, false); !strings.Contains(out, "[aider]") {
+			t.Errorf("expected agent badge, got %q", out)
+		}
+	})
+
+	t.Run("Claude session should not show a badge", func(t *testing.T) {
+		s := session.Session{SessionID: "c1", Agent: "claude", Status: "idle", LastPrompt: "add tests"}
+		if out := newSessionRow(s, true, sp, nil, false, false).render(w, false); strings.Contains(out, "[claude]") {
+			t.Errorf("unexpected agent badge in %q", out)
+		}
+	})
+}
+
+func TestSessionStatusDisplay(t *testing.T) {
+	sp := spinner.New()
+	tests := []struct {
+		name      string
+		s         session.Session
+		wantLabel string
+	}{
+		{"thinking", session.Session{Status: session.StatusWorking, Activity: session.ActivityThinking}, "Thinking"},
+		{"running a tool", session.Session{Status: session.StatusWorking, Activity: session.ActivityTool}, "Running"},
+		{"working without an activity", session.Session{Status: session.StatusWorking}, "Working"},
+		{"activity of a stalled session is ignored", session.Session{Status: session.StatusStalled, Activity: session.ActivityTool}, "Stalled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, label := sessionStatusDisplay(tt.s, sp); label != tt.wantLabel {
+				t.Errorf("label = %q, want %q", label, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestHistoryStrip(t *testing.T) {
+	if got := ansi.Strip(historyStrip([]string{"working", "waiting", "working", "idle"})); got != "●◆●○" {
+		t.Errorf("got %q, want ●◆●○", got)
+	}
+	if got := historyStrip([]string{"working"}); got != "" {
+		t.Errorf("a single status should show no strip, got %q", got)
+	}
+}
+
+func TestQueuedBadge(t *testing.T) {
+	sp := spinner.New()
+	w := columnWidths{conn: 2, status: 12, contentWidth: 80}
+
+	t.Run("queued messages should be counted after the status", func(t *testing.T) {
+		s := session.Session{SessionID: "q1", Status: "working", Detail: "Running: go test", Queued: 2}
+		out := ansi.Strip(newSessionRow(s, true, sp, nil, false, false).render(w, false))
+		if !strings.Contains(out, "+2 queued  Running: go test") {
+			t.Errorf("expected queued badge before the detail, got %q", out)
+		}
+	})
+
+	t.Run("nothing queued should show no badge", func(t *testing.T) {
+		s := session.Session{SessionID: "q2", Status: "working", Detail: "Running: go test"}
+		if out := newSessionRow(s, true, sp, nil, false, false).render(w, false); strings.Contains(out, "queued") {
+			t.Errorf("unexpected queued badge in %q", out)
+		}
+	})
+}
+
+func TestContextBar(t *testing.T) {
+	tests := []struct {
+		left int
+		want string
+	}{
+		{100, "ctx ░░░░░ 0%"},
+		{62, "ctx ██░░░ 38%"},
+		{20, "ctx ████░ 80%"},
+		{0, "ctx █████ 100%"},
+		{-3, "ctx █████ 100%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := ansi.Strip(contextBar(&tt.left)); got != tt.want {
+				t.Errorf("contextBar(%d) = %q, want %q", tt.left, got, tt.want)
+			}
+		})
+	}
+	if got := contextBar(nil); got != "" {
+		t.Errorf("contextBar(nil) = %q, want empty", got)
+	}
+}
+
+func TestCompactedLabel(t *testing.T) {
+	now := time.Now()
+
+	t.Run("recent compaction should be highlighted", func(t *testing.T) {
+		got := compactedLabel(now.Add(-5*time.Minute).Format(time.RFC3339), now)
+		if want := waitingStyle.Render("⟲ compacted 5m ago"); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("old compaction should be faint", func(t *testing.T) {
+		got := compactedLabel(now.Add(-2*time.Hour).Format(time.RFC3339), now)
+		if want := faintStyle.Render("⟲ compacted 2h ago"); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("no compaction should render nothing", func(t *testing.T) {
+		if got := compactedLabel("", now); got != "" {
+			t.Errorf("got %q, want empty", got)
+		}
+	})
+}
+
+func TestLimitCountdown(t *testing.T) {
+	now := time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		resetsAt string
+		want     string
+	}{
+		{"hours and minutes", "2026-03-10T15:30:00Z", "Resets in 2h30m"},
+		{"minutes round up", "2026-03-10T13:04:10Z", "Resets in 5m"},
+		{"past reset", "2026-03-10T12:00:00Z", "Limit reset, ready to resume"},
+		{"unknown reset", "", "Usage limit reached"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limitCountdown(tt.resetsAt, now); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusAge(t *testing.T) {
+	sp := spinner.New()
+	w := columnWidths{conn: 2, status: 12, contentWidth: 80}
+	now := time.Now()
+	ago := func(d time.Duration) string { return now.Add(-d).Format(time.RFC3339) }
+
+	t.Run("time in status should follow the status", func(t *testing.T) {
+		s := session.Session{SessionID: "a1", Status: "working", Detail: "Running: go test", StatusSince: ago(12 * time.Minute), LastActivity: ago(5 * time.Second)}
+		out := ansi.Strip(newSessionRow(s, true, sp, nil, false, false).render(w, false))
+		if !strings.Contains(out, "for 12m  Running: go test") {
+			t.Errorf("expected the time in status before the detail, got %q", out)
+		}
+	})
+
+	t.Run("time in status should be left out when it matches the last activity", func(t *testing.T) {
+		s := session.Session{Status: "idle", StatusSince: ago(2 * time.Hour), LastActivity: ago(2 * time.Hour)}
+		if got := statusAgeLabel(s, now); got != "" {
+			t.Errorf("got %q, want nothing", got)
+		}
+	})
+
+	t.Run("statuses the monitor derives should have no time in status", func(t *testing.T) {
+		for _, status := range []string{session.StatusStalled, session.StatusExited} {
+			s := session.Session{Status: status, StatusSince: ago(time.Hour), LastActivity: ago(time.Minute)}
+			if got := statusAgeLabel(s, now); got != "" {
+				t.Errorf("%s: got %q, want nothing", status, got)
+			}
+		}
+	})
+}
+
+func TestToolFailedDetail(t *testing.T) {
+	sp := spinner.New()
+	s := session.Session{SessionID: "f1", Status: "working", Detail: "Bash failed, continuing...", ToolFailed: true}
+	if !newSessionRow(s, true, sp, nil, false, false).toolFailed {
+		t.Error("a failed tool call should be shown in red")
+	}
+	// Once the monitor decides it's stalled, the failure is old news.
+	s.Status = session.StatusStalled
+	if newSessionRow(s, true, sp, nil, false, false).toolFailed {
+		t.Error("only working sessions should show the failed call in red")
+	}
 }
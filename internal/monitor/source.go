@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/demo"
+	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/transcript"
+)
+
+// sessionSource is where the sessions come from: the session files the hooks
+// write to dirs, or instead Claude Code's transcripts (hookless mode) or
+// made-up demo sessions.
+type sessionSource struct {
+	dirs        []string // read in order; notes are written to the first
+	transcripts string   // transcripts directory, when set
+	demo        bool
+}
+
+func (src sessionSource) load(now time.Time) ([]session.Session, error) {
+	switch {
+	case src.demo:
+		return demo.Sessions(now), nil
+	case src.transcripts != "":
+		return transcript.LoadAll(src.transcripts, now)
+	}
+	return session.LoadAll(src.dirs...)
+}
+
+// hooks reports whether the sessions are the hooks' session files, which
+// notes are stored next to and untracked processes are checked against.
+func (src sessionSource) hooks() bool {
+	return src.transcripts == "" && !src.demo
+}
+
+// label is noted in the header when the sessions don't come from the hooks.
+func (src sessionSource) label() string {
+	switch {
+	case src.demo:
+		return "demo data"
+	case src.transcripts != "":
+		return "from transcripts (no hooks)"
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// Exit codes of "ccmonitor status" besides those in StatusExitCodes.
+const (
+	ExitFailed    = 1 // ccmonitor itself failed
+	ExitNoSession = 2 // no session matched
+)
+
+// StatusExitCodes are the exit codes of "ccmonitor status" for each status,
+// so scripts can wait for a session: zero once it is idle, non-zero while it
+// works, needs the user or is gone.
+var StatusExitCodes = map[string]int{
+	session.StatusIdle:       0,
+	session.StatusStarting:   3,
+	session.StatusWorking:    3,
+	session.StatusCompacting: 3,
+	session.StatusStalled:    4,
+	session.StatusWaiting:    5,
+	session.StatusLimited:    6,
+	session.StatusError:      7,
+	session.StatusExited:     8,
+	session.StatusEnded:      8,
+	session.StatusUntracked:  9,
+}
+
+// FindSession finds the session query names: by session ID prefix, project
+// name or alias, or project directory. Of several sessions in a project, the
+// one that needs attention most wins.
+func FindSession(sessions []session.Session, query string, aliases map[string]string) (session.Session, bool) {
+	return findSession(sessions, query, newProjectNames(aliases))
+}
+
+// SessionStatus finds the session query names, like FindSession, and returns
+// a line describing it with its exit code. Liveness and stalls should
+// already be checked.
+func SessionStatus(sessions []session.Session, query string, aliases map[string]string) (string, int) {
+	s, ok := FindSession(sessions, query, aliases)
+	if !ok {
+		return fmt.Sprintf("No session %q", query), ExitNoSession
+	}
+	parts := []string{fmt.Sprintf("%-8s", s.Status), s.Project}
+	if s.Detail != "" {
+		parts = append(parts, s.Detail)
+	}
+	parts = append(parts, "("+s.SessionID+")")
+	code, ok := StatusExitCodes[s.Status]
+	if !ok {
+		code = ExitFailed
+	}
+	return strings.Join(parts, " "), code
+}
+
+// CheckStatuses returns an error for the first of statuses that isn't one
+// the monitor shows.
+func CheckStatuses(statuses []string) error {
+	for _, st := range statuses {
+		if !slices.Contains(knownStatuses, st) {
+			return fmt.Errorf("unknown status %q (want one of %s)", st, strings.Join(knownStatuses, ", "))
+		}
+	}
+	return nil
+}
+
+// FilterSnapshot keeps the sessions --once and --json print: those with one
+// of statuses (nil = all) and activity within since (0 = all). Liveness and
+// stalls should already be checked, so "exited" and "stalled" select.
+func FilterSnapshot(sessions []session.Session, statuses []string, since time.Duration, now time.Time) []session.Session {
+	sessions = filterStatus(sessions, statuses)
+	if since <= 0 {
+		return sessions
+	}
+	var out []session.Session
+	for _, s := range sessions {
+		if !quietFor(s, since, now) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestSessionStatus(t *testing.T) {
+	sessions := []session.Session{
+		{SessionID: "abc123", Project: "/home/me/api", Status: session.StatusIdle},
+		{SessionID: "abd456", Project: "/home/me/api", Status: session.StatusWaiting, Detail: "Permission: Bash"},
+		{SessionID: "fff789", Project: "/home/me/web/app", Status: session.StatusWorking},
+	}
+	tests := []struct {
+		query string
+		sid   string
+		code  int
+	}{
+		{"abc", "abc123", 0},
+		{"api", "abd456", 5},          // the one that needs attention
+		{"front", "fff789", 3},        // alias
+		{"/home/me/web", "fff789", 3}, // directory above the project
+		{"ab", "", ExitNoSession},     // ambiguous prefix, no such project
+		{"nothing", "", ExitNoSession},
+	}
+	for _, tt := range tests {
+		line, code := SessionStatus(sessions, tt.query, map[string]string{"/home/me/web/app": "front"})
+		if code != tt.code {
+			t.Errorf("%q: exit code %d, want %d", tt.query, code, tt.code)
+		}
+		if tt.sid != "" && !strings.Contains(line, "("+tt.sid+")") {
+			t.Errorf("%q: got %q, want session %s", tt.query, line, tt.sid)
+		}
+	}
+}
+
+func TestFilterSnapshot(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) string { return now.Add(-d).Format(time.RFC3339) }
+	sessions := []session.Session{
+		{SessionID: "a", Status: session.StatusWaiting, LastActivity: ago(time.Minute)},
+		{SessionID: "b", Status: session.StatusWaiting, LastActivity: ago(2 * time.Hour)},
+		{SessionID: "c", Status: session.StatusIdle, LastActivity: ago(time.Minute)},
+		{SessionID: "d", Status: session.StatusIdle},
+	}
+	tests := []struct {
+		statuses []string
+		since    time.Duration
+		want     string
+	}{
+		{nil, 0, "abcd"},
+		{[]string{"waiting"}, 0, "ab"},
+		{nil, time.Hour, "ac"}, // no activity time counts as old
+		{[]string{"waiting"}, time.Hour, "a"},
+		{[]string{"working"}, 0, ""},
+	}
+	for _, tt := range tests {
+		var got string
+		for _, s := range FilterSnapshot(sessions, tt.statuses, tt.since, now) {
+			got += s.SessionID
+		}
+		if got != tt.want {
+			t.Errorf("FilterSnapshot(%v, %v) = %q, want %q", tt.statuses, tt.since, got, tt.want)
+		}
+	}
+
+	if err := CheckStatuses([]string{"waiting", "exited"}); err != nil {
+		t.Errorf("CheckStatuses: %v", err)
+	}
+	if err := CheckStatuses([]string{"asleep"}); err == nil {
+		t.Error("CheckStatuses accepted an unknown status")
+	}
+}
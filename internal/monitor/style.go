@@ -1,21 +1,39 @@
 package monitor
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 var (
 	titleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15"))
 	countStyle = lipgloss.NewStyle().Faint(true)
+	hostStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("5")) // magenta
+	agentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("4")) // blue
 
 	projectStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("15"))
 	projectPathStyle = lipgloss.NewStyle().Faint(true)
 
-	workingStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("2")) // green
-	waitingStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("3")) // yellow
-	idleStyle     = lipgloss.NewStyle().Faint(true)
-	startingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6")) // cyan
-	exitedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("1")) // red
+	workingStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("2")) // green
+	compactingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Italic(true)
+	waitingStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("3")) // yellow
+	idleStyle       = lipgloss.NewStyle().Faint(true)
+	startingStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))  // cyan
+	exitedStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))  // red
+	stalledStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("13")) // bright magenta
+	limitedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("4"))  // blue
+	errorStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))  // bright red
+
+	promptStyle   = lipgloss.NewStyle().Faint(true).Italic(true)
+	noteStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Italic(true) // yellow
+	subagentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))              // cyan
+	faintStyle    = lipgloss.NewStyle().Faint(true)
+	boldStyle     = lipgloss.NewStyle().Bold(true)
+	flashStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true) // bright red
 
-	promptStyle = lipgloss.NewStyle().Faint(true).Italic(true)
+	statusMsgStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
 
 	helpStyle = lipgloss.NewStyle().Faint(true).MarginTop(1)
 
@@ -27,3 +45,55 @@ var (
 
 	summaryBarStyle = lipgloss.NewStyle().Faint(true).MarginTop(1)
 )
+
+// Built-in themes, selected with --theme or "theme" in the config file.
+const (
+	ThemeDefault      = "default"
+	ThemeHighContrast = "high-contrast"
+)
+
+// Themes lists the built-in theme names.
+var Themes = []string{ThemeDefault, ThemeHighContrast}
+
+// SetTheme switches the styles to a built-in theme ("" is the default). Call
+// it once, before anything is rendered.
+func SetTheme(name string) error {
+	switch name {
+	case "", ThemeDefault:
+	case ThemeHighContrast:
+		highContrast()
+	default:
+		return fmt.Errorf("unknown theme %q (want one of %s)", name, strings.Join(Themes, ", "))
+	}
+	return nil
+}
+
+// highContrast replaces faint text, which washes out on projectors and HDR
+// screens, with the terminal's own foreground color, and uses bold bright
+// colors for the statuses.
+func highContrast() {
+	plain := lipgloss.NewStyle()
+	countStyle = plain
+	hostStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("13"))
+	agentStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	projectPathStyle = plain
+
+	workingStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("10"))
+	compactingStyle = lipgloss.NewStyle().Bold(true).Italic(true).Foreground(lipgloss.Color("10"))
+	waitingStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+	idleStyle = plain
+	startingStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("14"))
+	exitedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("9"))
+	stalledStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("13"))
+	limitedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	errorStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("9"))
+
+	promptStyle = lipgloss.NewStyle().Italic(true)
+	noteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Italic(true)
+	subagentStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("14"))
+	faintStyle = plain
+	statusMsgStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("14"))
+	helpStyle = plain.MarginTop(1)
+	projectBoxStyle = projectBoxStyle.BorderForeground(lipgloss.Color("15"))
+	summaryBarStyle = plain.MarginTop(1)
+}
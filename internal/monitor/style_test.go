@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestSetTheme(t *testing.T) {
+	styles := []*lipgloss.Style{
+		&titleStyle, &countStyle, &hostStyle, &agentStyle, &projectStyle, &projectPathStyle,
+		&workingStyle, &waitingStyle, &idleStyle, &startingStyle, &exitedStyle, &stalledStyle, &limitedStyle, &errorStyle,
+		&promptStyle, &noteStyle, &subagentStyle, &faintStyle, &boldStyle, &flashStyle,
+		&statusMsgStyle, &helpStyle, &projectBoxStyle, &summaryBarStyle,
+	}
+	saved := make([]lipgloss.Style, len(styles))
+	for i, s := range styles {
+		saved[i] = *s
+	}
+	t.Cleanup(func() {
+		for i, s := range styles {
+			*s = saved[i]
+		}
+	})
+
+	t.Run("unknown theme should be an error", func(t *testing.T) {
+		if err := SetTheme("neon"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("high contrast should leave no faint text", func(t *testing.T) {
+		if err := SetTheme(ThemeHighContrast); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for i, s := range styles {
+			if s.GetFaint() {
+				t.Errorf("style %d is still faint", i)
+			}
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/switcher"
+)
+
+// titleRefreshEvery is how often the monitor looks up the tab titles of the
+// sessions itself.
+const titleRefreshEvery = 15 * time.Second
+
+// titleResultMsg carries the tab titles found by a refresh, by session ID.
+type titleResultMsg struct {
+	titles map[string]string
+	at     time.Time
+}
+
+// tabTitles keeps the sessions' titles current between hook events. The hook
+// only reads the tab or pane title when it runs, so a quiet session would
+// otherwise show whatever Claude had named it at its last event. Lookups run
+// in the background, one batch at a time, and a title found after a
+// session's last activity overrides the summary in its session file.
+type tabTitles struct {
+	titles   map[string]string
+	at       time.Time // when titles were looked up
+	last     time.Time // when the last refresh started
+	inflight bool
+	// lookup finds a session's current title (replaced in tests).
+	lookup func(session.Session) string
+}
+
+func newTabTitles() *tabTitles {
+	return &tabTitles{titles: map[string]string{}, lookup: switcher.Title}
+}
+
+// refresh returns a command that looks up the titles of the sessions with a
+// terminal on this machine, or nil when one is already running or the last
+// was less than titleRefreshEvery ago.
+func (t *tabTitles) refresh(sessions []session.Session, now time.Time) tea.Cmd {
+	if t == nil || t.inflight || now.Sub(t.last) < titleRefreshEvery {
+		return nil
+	}
+	t.last = now
+	var targets []session.Session
+	for _, s := range sessions {
+		if len(s.Terminals) == 0 || s.Status == session.StatusEnded || s.Status == session.StatusExited {
+			continue
+		}
+		if s.OnOtherHost(localHost()) {
+			continue
+		}
+		targets = append(targets, s)
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	t.inflight = true
+	lookup := t.lookup
+	return func() tea.Msg {
+		titles := map[string]string{}
+		for _, s := range targets {
+			if title := lookup(s); title != "" {
+				titles[s.SessionID] = title
+			}
+		}
+		return titleResultMsg{titles: titles, at: time.Now()}
+	}
+}
+
+func (t *tabTitles) store(msg titleResultMsg) {
+	t.inflight = false
+	t.titles, t.at = msg.titles, msg.at
+}
+
+// apply sets the summary of each session to its looked-up title, unless a
+// hook has run since the lookup.
+func (t *tabTitles) apply(sessions []session.Session) {
+	if t == nil {
+		return
+	}
+	for i, s := range sessions {
+		title, ok := t.titles[s.SessionID]
+		if !ok {
+			continue
+		}
+		if last, err := time.Parse(time.RFC3339, s.LastActivity); err == nil && last.After(t.at) {
+			continue
+		}
+		sessions[i].Summary = title
+	}
+}
@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestTabTitles(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	inPane := func(id, status string) session.Session {
+		return session.Session{
+			SessionID:    id,
+			Status:       status,
+			Summary:      "Old title",
+			LastActivity: now.Add(-time.Hour).Format(time.RFC3339),
+			Terminals:    []session.Terminal{{Backend: "tmux", ID: "%" + id}},
+		}
+	}
+	newFake := func() (*tabTitles, *[]string) {
+		var looked []string
+		tt := newTabTitles()
+		tt.lookup = func(s session.Session) string {
+			looked = append(looked, s.SessionID)
+			return "New title"
+		}
+		return tt, &looked
+	}
+
+	t.Run("titles should replace the summaries of quiet sessions", func(t *testing.T) {
+		tt, _ := newFake()
+		sessions := []session.Session{inPane("1", session.StatusIdle)}
+		tt.store(tt.refresh(sessions, now)().(titleResultMsg))
+		tt.apply(sessions)
+		if sessions[0].Summary != "New title" {
+			t.Errorf("summary = %q, want New title", sessions[0].Summary)
+		}
+	})
+
+	t.Run("hook events after the lookup should win", func(t *testing.T) {
+		tt, _ := newFake()
+		sessions := []session.Session{inPane("1", session.StatusIdle)}
+		tt.store(tt.refresh(sessions, now)().(titleResultMsg))
+		sessions[0].LastActivity = time.Now().Add(time.Minute).Format(time.RFC3339)
+		tt.apply(sessions)
+		if sessions[0].Summary != "Old title" {
+			t.Errorf("summary = %q, want Old title", sessions[0].Summary)
+		}
+	})
+
+	t.Run("ended, remote and paneless sessions should be skipped", func(t *testing.T) {
+		tt, looked := newFake()
+		remote := inPane("3", session.StatusIdle)
+		remote.Host = "elsewhere.invalid"
+		paneless := inPane("4", session.StatusIdle)
+		paneless.Terminals = nil
+		sessions := []session.Session{inPane("1", session.StatusWorking), inPane("2", session.StatusExited), remote, paneless}
+		tt.refresh(sessions, now)()
+		if len(*looked) != 1 || (*looked)[0] != "1" {
+			t.Errorf("looked up %v, want only 1", *looked)
+		}
+	})
+
+	t.Run("refreshes should wait for the last one and the interval", func(t *testing.T) {
+		tt, _ := newFake()
+		sessions := []session.Session{inPane("1", session.StatusIdle)}
+		cmd := tt.refresh(sessions, now)
+		if tt.refresh(sessions, now.Add(time.Minute)) != nil {
+			t.Error("refresh should not start while one is running")
+		}
+		tt.store(cmd().(titleResultMsg))
+		if tt.refresh(sessions, now.Add(time.Second)) != nil {
+			t.Error("refresh should not start before the interval")
+		}
+		if tt.refresh(sessions, now.Add(titleRefreshEvery)) == nil {
+			t.Error("refresh should start after the interval")
+		}
+	})
+}
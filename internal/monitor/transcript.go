@@ -0,0 +1,119 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/transcript"
+)
+
+// transcriptMessages is how many messages the transcript overlay loads; the
+// ones that don't fit the window are cut from the top.
+const transcriptMessages = 50
+
+// pagerResultMsg carries the result of reading a transcript in $PAGER.
+type pagerResultMsg struct {
+	err error
+}
+
+// showTranscript shows what was said in the hovered session: in $PAGER when
+// it is set, else in an overlay with the last messages that fit, which
+// follows the conversation until closed.
+func (m Model) showTranscript() (tea.Model, tea.Cmd) {
+	s, ok := m.hovered()
+	if !ok || s.TranscriptPath == "" {
+		m.setStatus("Hover over a session with a transcript to read it")
+		return m, nil
+	}
+	n := transcriptMessages
+	pager := os.Getenv("PAGER")
+	if pager != "" {
+		n = 0
+	}
+	msgs, err := transcript.Messages(s.TranscriptPath, n)
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Reading the transcript failed: %v", err))
+		return m, nil
+	}
+	if pager != "" {
+		cmd := runShell(context.Background(), pager, s)
+		cmd.Stdin = strings.NewReader(transcriptText(msgs))
+		return m, tea.ExecProcess(cmd, func(err error) tea.Msg { return pagerResultMsg{err: err} })
+	}
+	m.transcriptSID, m.transcriptMsgs = s.SessionID, msgs
+	return m, nil
+}
+
+// reloadTranscript rereads the transcript shown in the overlay, keeping
+// what was shown when that fails.
+func (m *Model) reloadTranscript() {
+	s, ok := m.find(m.transcriptSID)
+	if !ok {
+		return
+	}
+	if msgs, err := transcript.Messages(s.TranscriptPath, transcriptMessages); err == nil {
+		m.transcriptMsgs = msgs
+	}
+}
+
+// transcriptText formats msgs as plain text for a pager: prompts after a
+// "> ", tool calls after a "→ ".
+func transcriptText(msgs []transcript.Message) string {
+	var b strings.Builder
+	for i, msg := range msgs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch msg.Role {
+		case transcript.RoleUser:
+			b.WriteString("> " + msg.Text + "\n")
+		case transcript.RoleTools:
+			b.WriteString("→ " + msg.Text + "\n")
+		default:
+			b.WriteString(msg.Text + "\n")
+		}
+	}
+	return b.String()
+}
+
+// renderTranscript renders the transcript overlay: the last of msgs that fit
+// in height lines (0 = no limit), wrapped to width.
+func renderTranscript(s session.Session, msgs []transcript.Message, width, height int, names projectNames) string {
+	if width == 0 {
+		width = 80
+	}
+	wrap := lipgloss.NewStyle().Width(max(width-6, 10))
+	var lines []string
+	for i, msg := range msgs {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		var text string
+		switch msg.Role {
+		case transcript.RoleUser:
+			text = boldStyle.Render("> ") + promptStyle.Render(msg.Text)
+		case transcript.RoleTools:
+			text = faintStyle.Render("→ " + msg.Text)
+		default:
+			text = msg.Text
+		}
+		lines = append(lines, strings.Split(wrap.Render(text), "\n")...)
+	}
+	if len(lines) == 0 {
+		lines = []string{faintStyle.Render("Nothing said yet")}
+	}
+	// The box border, the header and the help line take 6 lines.
+	if room := height - 6; height > 0 && len(lines) > room {
+		lines = lines[len(lines)-max(room, 1):]
+	}
+
+	header := projectStyle.Render(names.name(s.Project)) + "  " + projectPathStyle.Render(s.TranscriptPath)
+	box := projectBoxStyle.Width(width - 4).Render(header + "\n\n" + strings.Join(lines, "\n"))
+	return box + "\n" + helpStyle.Render("any key or click to close")
+}
@@ -0,0 +1,68 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/transcript"
+)
+
+func TestTranscript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s1.jsonl")
+	os.WriteFile(path, []byte(`{"type":"user","message":{"role":"user","content":"fix the tests"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","name":"Bash"}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"All green."}]}}
+`), 0644)
+	sessions := []session.Session{
+		{SessionID: "s1", Project: "/p/api", Status: session.StatusIdle, TranscriptPath: path},
+		{SessionID: "s2", Project: "/p/web", Status: session.StatusIdle},
+	}
+	t.Setenv("PAGER", "")
+
+	t.Run("overlay should show the conversation until a key is pressed", func(t *testing.T) {
+		got, _ := Model{sessions: sessions, hoverSID: "s1", width: 80}.do(actionTranscript)
+		m := got.(Model)
+		view := ansi.Strip(m.View())
+		for _, want := range []string{"> fix the tests", "→ Bash", "All green."} {
+			if !strings.Contains(view, want) {
+				t.Errorf("view is missing %q:\n%s", want, view)
+			}
+		}
+		got, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+		if m = got.(Model); m.transcriptSID != "" {
+			t.Error("a key should close the overlay")
+		}
+	})
+
+	t.Run("sessions without a transcript should say so", func(t *testing.T) {
+		got, _ := Model{sessions: sessions, hoverSID: "s2"}.do(actionTranscript)
+		if m := got.(Model); m.transcriptSID != "" || m.statusMsg == "" {
+			t.Errorf("transcriptSID = %q, status = %q, want a message instead", m.transcriptSID, m.statusMsg)
+		}
+	})
+
+	t.Run("overlay should keep the last lines that fit", func(t *testing.T) {
+		var msgs []transcript.Message
+		for _, text := range []string{"one", "two", "three", "four"} {
+			msgs = append(msgs, transcript.Message{Role: transcript.RoleAssistant, Text: text})
+		}
+		view := ansi.Strip(renderTranscript(sessions[0], msgs, 80, 9, projectNames{}))
+		if strings.Contains(view, "two") || !strings.Contains(view, "three") || !strings.Contains(view, "four") {
+			t.Errorf("want only the last messages:\n%s", view)
+		}
+	})
+
+	t.Run("pager text should mark prompts and tools", func(t *testing.T) {
+		msgs, _ := transcript.Messages(path, 0)
+		want := "> fix the tests\n\n→ Bash\n\nAll green.\n"
+		if got := transcriptText(msgs); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/martinwickman/ccmonitor/internal/proc"
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// untrackedProject stands in for the directory of an untracked process whose
+// working directory can't be read (Windows).
+const untrackedProject = "(unknown directory)"
+
+// FindUntracked scans the process table for Claude Code processes that none
+// of sessions accounts for, and returns a row for each: status untracked, in
+// the process's working directory where it can be read. These are sessions
+// started before the hooks were installed, or with a profile that doesn't
+// have them.
+func FindUntracked(sessions []session.Session) []session.Session {
+	procs, err := proc.FindClaude()
+	if err != nil {
+		return nil
+	}
+	tracked := trackedPIDs(sessions)
+	var rows []session.Session
+	for _, p := range procs {
+		if tracked[p.PID] {
+			continue
+		}
+		project := p.Cwd
+		if project == "" {
+			project = untrackedProject
+		}
+		rows = append(rows, session.Session{
+			SessionID: fmt.Sprintf("untracked-%d", p.PID),
+			Project:   project,
+			Status:    session.StatusUntracked,
+			Detail:    "No session file (hooks not installed?)",
+			PID:       p.PID,
+			PIDStart:  p.StartTime,
+			OS:        runtime.GOOS,
+		})
+	}
+	return rows
+}
+
+// withUntracked appends the rows found by FindUntracked to sessions, except
+// for processes that have since written a session file.
+func withUntracked(sessions, untracked []session.Session) []session.Session {
+	tracked := trackedPIDs(sessions)
+	for _, u := range untracked {
+		if !tracked[u.PID] {
+			sessions = append(sessions, u)
+		}
+	}
+	return sessions
+}
+
+// trackedPIDs returns the PIDs of the sessions' processes on this OS, leaving
+// out untracked rows themselves.
+func trackedPIDs(sessions []session.Session) map[int]bool {
+	tracked := map[int]bool{}
+	for _, s := range sessions {
+		native := (runtime.GOOS == "windows") == (s.OS == "windows")
+		if s.PID > 0 && native && s.Status != session.StatusUntracked {
+			tracked[s.PID] = true
+		}
+	}
+	return tracked
+}
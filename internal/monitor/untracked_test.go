@@ -0,0 +1,41 @@
+package monitor
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestWithUntracked(t *testing.T) {
+	untracked := []session.Session{
+		{SessionID: "untracked-100", Status: session.StatusUntracked, PID: 100, OS: runtime.GOOS},
+		{SessionID: "untracked-200", Status: session.StatusUntracked, PID: 200, OS: runtime.GOOS},
+	}
+
+	t.Run("processes without a session file are added", func(t *testing.T) {
+		sessions := []session.Session{{SessionID: "a", Status: session.StatusIdle, PID: 300, OS: runtime.GOOS}}
+		if got := withUntracked(sessions, untracked); len(got) != 3 {
+			t.Errorf("got %d sessions, want 3", len(got))
+		}
+	})
+
+	t.Run("process that has since written a session file is left out", func(t *testing.T) {
+		sessions := []session.Session{{SessionID: "a", Status: session.StatusWorking, PID: 100, OS: runtime.GOOS}}
+		got := withUntracked(sessions, untracked)
+		if len(got) != 2 || got[1].SessionID != "untracked-200" {
+			t.Errorf("got %+v, want a and untracked-200", got)
+		}
+	})
+
+	t.Run("PIDs of sessions on the other OS do not count", func(t *testing.T) {
+		other := "windows"
+		if runtime.GOOS == "windows" {
+			other = "linux"
+		}
+		sessions := []session.Session{{SessionID: "a", Status: session.StatusWorking, PID: 100, OS: other}}
+		if got := withUntracked(sessions, untracked); len(got) != 3 {
+			t.Errorf("got %d sessions, want 3", len(got))
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package monitor
+
+import "strings"
+
+// scrollStep is how many lines one mouse wheel notch scrolls.
+const scrollStep = 3
+
+// headerLines is how many lines at the top of the view stay pinned while
+// scrolling: the title with the counts and filter, a blank line and the
+// summary bar.
+const headerLines = 3
+
+// viewport cuts the rendered view down to the lines that fit the terminal.
+// The header stays put and the rest scrolls: the lines below it start
+// offset lines further down. Bubble Tea would otherwise drop whatever
+// doesn't fit, leaving sessions below the fold unreachable.
+func viewport(view string, offset, height int) string {
+	if height <= 0 {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+	if len(lines) <= height {
+		return view
+	}
+	offset = clampOffset(offset, len(lines), height)
+	pinned := pinnedLines(height)
+	return strings.Join(append(lines[:pinned:pinned], lines[pinned+offset:offset+height]...), "\n")
+}
+
+// pinnedLines is how many header lines stay pinned in a terminal height
+// lines tall; none when that would leave no room to scroll.
+func pinnedLines(height int) int {
+	if height <= headerLines {
+		return 0
+	}
+	return headerLines
+}
+
+// contentLine maps a screen line to a line of the full view.
+func (m Model) contentLine(y int) int {
+	if y < pinnedLines(m.height) {
+		return y
+	}
+	return y + m.offset
+}
+
+// clampOffset keeps the scroll offset within the content.
+func clampOffset(offset, lines, height int) int {
+	return max(0, min(offset, lines-height))
+}
+
+// scroll moves the viewport by delta lines.
+func (m *Model) scroll(delta int) {
+	if m.height <= 0 {
+		return
+	}
+	lines := strings.Count(m.renderPlain(), "\n") + 1
+	m.offset = clampOffset(m.offset+delta, lines, m.height)
+}
+
+// pageLines is how far a page up or down scrolls: the lines below the
+// pinned header, less one kept in view for context.
+func (m Model) pageLines() int {
+	return max(1, m.height-pinnedLines(m.height)-1)
+}
+
+// ensureVisible scrolls the least amount needed to show every line of
+// session sid, e.g. after the keyboard moved the selection.
+func (m *Model) ensureVisible(sid string) {
+	if m.height <= 0 {
+		return
+	}
+	first, last := -1, -1
+	for _, col := range m.clickMap.columns {
+		for y, id := range col {
+			if id != sid {
+				continue
+			}
+			if first < 0 || y < first {
+				first = y
+			}
+			last = max(last, y)
+		}
+	}
+	pinned := pinnedLines(m.height)
+	switch {
+	case first < 0:
+	case first < m.offset+pinned:
+		m.offset = max(0, first-pinned)
+	case last >= m.offset+m.height:
+		m.offset = last - m.height + 1
+	}
+}
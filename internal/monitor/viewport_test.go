@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"fmt"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestViewport(t *testing.T) {
+	view := "l0\nl1\nl2\nl3\nl4"
+	tall := "h0\nh1\nh2\nl3\nl4\nl5\nl6\nl7"
+	tests := []struct {
+		name           string
+		view           string
+		offset, height int
+		want           string
+	}{
+		{"unknown height shows everything", view, 0, 0, view},
+		{"content that fits is unchanged", view, 3, 10, view},
+		{"offset selects the window", view, 1, 2, "l1\nl2"},
+		{"offset past the end is clamped", view, 9, 2, "l3\nl4"},
+		{"header stays while the rest scrolls", tall, 2, 5, "h0\nh1\nh2\nl5\nl6"},
+		{"offset past the end is clamped below the header", tall, 9, 5, "h0\nh1\nh2\nl6\nl7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := viewport(tt.view, tt.offset, tt.height); got != tt.want {
+				t.Errorf("viewport = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureVisible(t *testing.T) {
+	m := &Model{height: 3, offset: 2, clickMap: clickTargets{columns: []map[int]string{{0: "a", 6: "b", 7: "b"}}}}
+
+	m.ensureVisible("b")
+	if m.offset != 5 {
+		t.Errorf("offset = %d, want 5 so lines 6-7 are visible", m.offset)
+	}
+	m.ensureVisible("a")
+	if m.offset != 0 {
+		t.Errorf("offset = %d, want 0", m.offset)
+	}
+	t.Run("lines under the pinned header should count as hidden", func(t *testing.T) {
+		m := &Model{height: 6, offset: 4, clickMap: clickTargets{columns: []map[int]string{{5: "c"}}}}
+		m.ensureVisible("c")
+		if m.offset != 2 {
+			t.Errorf("offset = %d, want 2 so line 5 shows right below the header", m.offset)
+		}
+	})
+}
+
+func TestMouseOffset(t *testing.T) {
+	m := Model{
+		sessions: []session.Session{{SessionID: "s1"}},
+		height:   5,
+		offset:   4,
+		clickMap: clickTargets{columns: []map[int]string{{1: "header", 7: "s1"}}},
+	}
+	got, _ := m.Update(tea.MouseMsg{X: 1, Y: 3, Action: tea.MouseActionMotion})
+	if got.(Model).hoverSID != "s1" {
+		t.Error("hover should map screen line 3 to content line 7")
+	}
+	got, _ = m.Update(tea.MouseMsg{X: 1, Y: 1, Action: tea.MouseActionMotion})
+	if got.(Model).hoverSID != "header" {
+		t.Error("pinned header lines should not be offset")
+	}
+}
+
+func TestPageKeys(t *testing.T) {
+	var sessions []session.Session
+	for i := range 20 {
+		sessions = append(sessions, session.Session{SessionID: fmt.Sprintf("s%02d", i), Project: "/p", Status: session.StatusIdle})
+	}
+	m := Model{sessions: sessions, width: 80, height: 10}
+	got, _ := m.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	m = got.(Model)
+	if m.offset != 6 {
+		t.Errorf("offset = %d, want 6 after a page down", m.offset)
+	}
+	got, _ = m.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	if m = got.(Model); m.offset != 0 {
+		t.Errorf("offset = %d, want 0 after a page up", m.offset)
+	}
+}
@@ -0,0 +1,224 @@
+// Package otlp exports hook events as OpenTelemetry spans, so agent activity
+// shows up in the same traces and dashboards as the rest of a team's
+// infrastructure. It speaks OTLP over HTTP with JSON encoding, which every
+// OpenTelemetry collector accepts, rather than pulling in the OpenTelemetry
+// SDK for one POST per hook event.
+//
+// Each hook event becomes one span, named after the event (and tool), in a
+// trace per session: the trace ID is derived from the session ID, so a
+// session's events line up in one trace. Hooks are separate processes and
+// keep no state between events, so spans mark points in time rather than
+// measure durations.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timeout bounds an export, which the hook makes while Claude waits for it.
+const Timeout = 300 * time.Millisecond
+
+// DefaultEndpoint is the OTLP/HTTP traces endpoint of a local collector.
+const DefaultEndpoint = "http://localhost:4318/v1/traces"
+
+// Event is one hook event to export.
+type Event struct {
+	Name      string // hook event, e.g. "PreToolUse"
+	Tool      string // tool name, for tool events
+	SessionID string
+	Project   string
+	Status    string // session status after the event
+	Detail    string
+	Error     string // marks the span as failed
+	Time      time.Time
+}
+
+// Exporter sends events to an OTLP/HTTP endpoint.
+type Exporter struct {
+	Endpoint string
+	Headers  map[string]string
+	Service  string // service.name resource attribute
+	Client   *http.Client
+}
+
+// FromEnv returns an exporter configured by the standard OpenTelemetry
+// environment variables (OTEL_EXPORTER_OTLP_ENDPOINT,
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS,
+// OTEL_SERVICE_NAME), or nil unless $CCMONITOR_OTEL is set. The opt-in is
+// separate because Claude Code's own telemetry uses the same variables.
+func FromEnv() *Exporter {
+	if os.Getenv("CCMONITOR_OTEL") == "" {
+		return nil
+	}
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "ccmonitor"
+	}
+	return &Exporter{
+		Endpoint: endpoint,
+		Headers:  parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+		Service:  service,
+		Client:   &http.Client{Timeout: Timeout},
+	}
+}
+
+// parseHeaders parses the "key1=value1,key2=value2" format of
+// OTEL_EXPORTER_OTLP_HEADERS, with URL-encoded values.
+func parseHeaders(s string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = unescaped
+		}
+		headers[strings.TrimSpace(k)] = v
+	}
+	return headers
+}
+
+// Export sends ev as a span.
+func (e *Exporter) Export(ev Event) error {
+	body, err := json.Marshal(e.request(ev))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("exporting span: %s", resp.Status)
+	}
+	return nil
+}
+
+// The OTLP/JSON request, reduced to the fields used here. IDs are hex and
+// 64-bit integers strings, as the OTLP JSON encoding requires.
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []attribute `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope  `json:"scope"`
+		Spans []span `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	span struct {
+		TraceID           string      `json:"traceId"`
+		SpanID            string      `json:"spanId"`
+		Name              string      `json:"name"`
+		Kind              int         `json:"kind"`
+		StartTimeUnixNano string      `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+		Attributes        []attribute `json:"attributes"`
+		Status            *status     `json:"status,omitempty"`
+	}
+	attribute struct {
+		Key   string `json:"key"`
+		Value value  `json:"value"`
+	}
+	value struct {
+		StringValue string `json:"stringValue"`
+	}
+	status struct {
+		Code    int    `json:"code"` // 2 = error
+		Message string `json:"message,omitempty"`
+	}
+)
+
+const spanKindInternal = 1
+
+func (e *Exporter) request(ev Event) exportRequest {
+	name := ev.Name
+	if ev.Tool != "" {
+		name += " " + ev.Tool
+	}
+	var attrs []attribute
+	add := func(k, v string) {
+		if v != "" {
+			attrs = append(attrs, attribute{k, value{v}})
+		}
+	}
+	add("session.id", ev.SessionID)
+	add("hook.event", ev.Name)
+	add("tool.name", ev.Tool)
+	add("project", ev.Project)
+	add("status", ev.Status)
+	add("detail", ev.Detail)
+	ts := strconv.FormatInt(ev.Time.UnixNano(), 10)
+	sp := span{
+		TraceID:           traceID(ev.SessionID),
+		SpanID:            spanID(),
+		Name:              name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: ts,
+		EndTimeUnixNano:   ts,
+		Attributes:        attrs,
+	}
+	if ev.Error != "" {
+		sp.Status = &status{Code: 2, Message: ev.Error}
+	}
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: []attribute{{"service.name", value{e.Service}}}},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: "ccmonitor"}, Spans: []span{sp}}},
+	}}}
+}
+
+// traceID derives a session's trace ID from its session ID.
+func traceID(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// spanID returns a random span ID.
+func spanID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,82 @@
+package otlp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	var got map[string]any
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	e := &Exporter{Endpoint: srv.URL, Headers: map[string]string{"Authorization": "Bearer x"}, Service: "ccmonitor"}
+	err := e.Export(Event{Name: "PostToolUseFailure", Tool: "Bash", SessionID: "abc", Status: "working", Error: "exit 1", Time: time.Unix(1700000000, 0)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth != "Bearer x" {
+		t.Errorf("Authorization = %q, want the configured header", auth)
+	}
+	sp := got["resourceSpans"].([]any)[0].(map[string]any)["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any)
+	if sp["name"] != "PostToolUseFailure Bash" {
+		t.Errorf("name = %v, want PostToolUseFailure Bash", sp["name"])
+	}
+	if sp["traceId"] != traceID("abc") || len(sp["traceId"].(string)) != 32 || len(sp["spanId"].(string)) != 16 {
+		t.Errorf("ids = %v / %v, want the session's trace and a 16 digit span ID", sp["traceId"], sp["spanId"])
+	}
+	if sp["startTimeUnixNano"] != "1700000000000000000" {
+		t.Errorf("start = %v, want the event time in nanoseconds", sp["startTimeUnixNano"])
+	}
+	if st := sp["status"].(map[string]any); st["code"] != 2.0 || st["message"] != "exit 1" {
+		t.Errorf("status = %v, want an error with the message", st)
+	}
+}
+
+func TestExportFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	e := &Exporter{Endpoint: srv.URL}
+	if err := e.Export(Event{Name: "Stop", SessionID: "abc", Time: time.Now()}); err == nil {
+		t.Error("expected an error for a 400 response")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Run("export should be opt-in", func(t *testing.T) {
+		t.Setenv("CCMONITOR_OTEL", "")
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+		if e := FromEnv(); e != nil {
+			t.Errorf("got %+v, want nil", e)
+		}
+	})
+
+	t.Run("the standard variables should configure it", func(t *testing.T) {
+		t.Setenv("CCMONITOR_OTEL", "1")
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+		t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+		t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-api-key=a%20b, tenant=t1")
+		t.Setenv("OTEL_SERVICE_NAME", "")
+		e := FromEnv()
+		if e.Endpoint != "http://collector:4318/v1/traces" {
+			t.Errorf("endpoint = %q", e.Endpoint)
+		}
+		if e.Headers["x-api-key"] != "a b" || e.Headers["tenant"] != "t1" {
+			t.Errorf("headers = %v", e.Headers)
+		}
+		if e.Service != "ccmonitor" {
+			t.Errorf("service = %q, want ccmonitor", e.Service)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+// Package proc provides process identity helpers used by PID liveness checks.
+package proc
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	ps "github.com/mitchellh/go-ps"
+)
+
+// Alive reports whether the process with the given PID is still the one that
+// was recorded. startTime is the token returned by StartTime when the PID was
+// captured; when it is non-empty and the running process reports a different
+// start time, the PID has been reused by an unrelated process and Alive
+// returns false. An empty startTime (legacy session files, unsupported
+// platforms) skips the check. A process whose executable doesn't look like
+// Claude Code (see IsClaude) is also treated as dead.
+func Alive(pid int, startTime string) (bool, error) {
+	return alive(pid, startTime, IsClaude)
+}
+
+// Running is Alive without the Claude Code name check, for sessions reported
+// by other agents through ccmonitor ingest.
+func Running(pid int, startTime string) (bool, error) {
+	return alive(pid, startTime, nil)
+}
+
+// ErrGone is returned by Terminate when the process has already exited or
+// its PID now belongs to another process.
+var ErrGone = errors.New("process is no longer running")
+
+// Terminate asks the process to exit (SIGTERM; on Windows, which has no
+// signals, it is killed). startTime guards against PID reuse the same way as
+// in Alive, so an unrelated process that inherited the PID is never touched.
+func Terminate(pid int, startTime string) error {
+	ok, err := Running(pid, startTime)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrGone
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		return p.Kill()
+	}
+	return p.Signal(syscall.SIGTERM)
+}
+
+// Stop terminates the process like Terminate and, when it is still running
+// after grace, kills it, for processes that ignore SIGTERM. The start time is
+// checked again before the kill.
+func Stop(pid int, startTime string, grace time.Duration) error {
+	if err := Terminate(pid, startTime); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(grace)
+	for {
+		ok, err := Running(pid, startTime)
+		if err != nil || !ok {
+			return err
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(stopPoll)
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+// stopPoll is how often Stop checks whether the process has exited.
+const stopPoll = 100 * time.Millisecond
+
+func alive(pid int, startTime string, nameOK func(string) bool) (bool, error) {
+	p, err := ps.FindProcess(pid)
+	if err != nil {
+		return false, err
+	}
+	if p == nil {
+		return false, nil
+	}
+	if nameOK != nil && !nameOK(p.Executable()) {
+		return false, nil
+	}
+	if startTime != "" {
+		if current := StartTime(pid); current != "" && current != startTime {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// IsClaude reports whether a process executable name plausibly belongs to
+// Claude Code. npm installs run under node; the native installer runs a
+// binary named "claude" or, on Linux, the version file it was launched from
+// (e.g. "2.0.14").
+func IsClaude(name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+	if name == "" {
+		return false
+	}
+	if strings.Contains(name, "claude") || strings.Contains(name, "node") {
+		return true
+	}
+	return isVersion(name)
+}
+
+// isVersion reports whether s looks like a dotted version number ("2.0.14").
+func isVersion(s string) bool {
+	if !strings.Contains(s, ".") {
+		return false
+	}
+	for _, r := range s {
+		if r != '.' && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,218 @@
+package proc
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/proc/proctest"
+)
+
+func TestStartTime(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("start time parsing is only tested on Linux")
+	}
+
+	t.Run("own process should have a stable start time", func(t *testing.T) {
+		first := StartTime(os.Getpid())
+		if first == "" {
+			t.Fatal("got empty start time for own process")
+		}
+		if second := StartTime(os.Getpid()); second != first {
+			t.Errorf("start time changed: %q then %q", first, second)
+		}
+	})
+
+	t.Run("nonexistent PID should return empty", func(t *testing.T) {
+		if got := StartTime(99999999); got != "" {
+			t.Errorf("got %q, want empty", got)
+		}
+	})
+}
+
+func TestAlive(t *testing.T) {
+	t.Run("dead PID should not be alive", func(t *testing.T) {
+		alive, err := Alive(99999999, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if alive {
+			t.Error("got alive, want dead")
+		}
+	})
+
+	t.Run("claude PID with matching start time should be alive", func(t *testing.T) {
+		pid := proctest.StartFakeClaude(t)
+		alive, err := Alive(pid, StartTime(pid))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !alive {
+			t.Error("got dead, want alive")
+		}
+	})
+
+	t.Run("non-Claude process should not be alive", func(t *testing.T) {
+		alive, err := Alive(os.Getpid(), StartTime(os.Getpid()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if alive {
+			t.Error("got alive, want dead (test binary is not Claude Code)")
+		}
+	})
+
+	t.Run("claude PID with different start time should be treated as reused", func(t *testing.T) {
+		pid := proctest.StartFakeClaude(t)
+		if StartTime(pid) == "" {
+			t.Skip("start time not supported on this platform")
+		}
+		alive, err := Alive(pid, "1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if alive {
+			t.Error("got alive, want dead (PID reused)")
+		}
+	})
+}
+
+func TestIsClaude(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"claude", true},
+		{"claude.exe", true},
+		{"node", true},
+		{"node.exe", true},
+		{"Node.EXE", true},
+		{"2.0.14", true},
+		{"bash", false},
+		{"python3", false},
+		{"1234", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsClaude(tt.name); got != tt.want {
+				t.Errorf("IsClaude(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindClaude(t *testing.T) {
+	t.Run("running claude process should be found with its directory", func(t *testing.T) {
+		pid := proctest.StartFakeClaude(t)
+		procs, err := FindClaude()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var found *Process
+		for i := range procs {
+			if procs[i].PID == pid {
+				found = &procs[i]
+			}
+		}
+		if found == nil {
+			t.Fatalf("PID %d not in %+v", pid, procs)
+		}
+		if runtime.GOOS == "linux" {
+			wd, _ := os.Getwd()
+			if found.Cwd != wd {
+				t.Errorf("Cwd = %q, want %q", found.Cwd, wd)
+			}
+		}
+	})
+
+	t.Run("test binary should not be found", func(t *testing.T) {
+		procs, err := FindClaude()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, p := range procs {
+			if p.PID == os.Getpid() {
+				t.Error("found the test process, which is not Claude Code")
+			}
+		}
+	})
+}
+
+func TestRunning(t *testing.T) {
+	t.Run("non-Claude process should be running", func(t *testing.T) {
+		alive, err := Running(os.Getpid(), StartTime(os.Getpid()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !alive {
+			t.Error("test process should be running")
+		}
+	})
+}
+
+func TestTerminate(t *testing.T) {
+	t.Run("running process should be terminated", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("uses sleep")
+		}
+		cmd := exec.Command("sleep", "60")
+		if err := cmd.Start(); err != nil {
+			t.Skipf("starting sleep: %v", err)
+		}
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		if err := Terminate(cmd.Process.Pid, StartTime(cmd.Process.Pid)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			cmd.Process.Kill()
+			t.Fatal("process did not exit")
+		}
+	})
+
+	t.Run("reused PID should not be touched", func(t *testing.T) {
+		if StartTime(os.Getpid()) == "" {
+			t.Skip("start times not supported on this platform")
+		}
+		if err := Terminate(os.Getpid(), "not-the-start-time"); !errors.Is(err, ErrGone) {
+			t.Errorf("err = %v, want ErrGone", err)
+		}
+	})
+}
+
+func TestStop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	t.Run("process ignoring SIGTERM should be killed after the grace period", func(t *testing.T) {
+		cmd := exec.Command("sh", "-c", `trap "" TERM; while :; do sleep 1; done`)
+		if err := cmd.Start(); err != nil {
+			t.Skipf("starting sh: %v", err)
+		}
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		time.Sleep(100 * time.Millisecond) // let sh install the trap
+		if err := Stop(cmd.Process.Pid, StartTime(cmd.Process.Pid), 200*time.Millisecond); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			cmd.Process.Kill()
+			t.Fatal("process did not exit")
+		}
+	})
+
+	t.Run("exited process should be reported gone", func(t *testing.T) {
+		if err := Stop(99999999, "", time.Second); !errors.Is(err, ErrGone) {
+			t.Errorf("err = %v, want ErrGone", err)
+		}
+	})
+}
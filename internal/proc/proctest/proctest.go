@@ -0,0 +1,38 @@
+// Package proctest starts processes for tests of code that looks for Claude
+// Code processes.
+package proctest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// StartFakeClaude starts a copy of sleep(1) named "claude" that runs until
+// the test ends, and returns its PID. The test is skipped where that can't
+// be done.
+func StartFakeClaude(t testing.TB) int {
+	t.Helper()
+	src, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Skipf("reading sleep binary: %v", err)
+	}
+	bin := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(bin, data, 0755); err != nil {
+		t.Fatalf("write fake claude: %v", err)
+	}
+	cmd := exec.Command(bin, "60")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("starting fake claude: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return cmd.Process.Pid
+}
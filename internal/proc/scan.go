@@ -0,0 +1,51 @@
+package proc
+
+import (
+	"strings"
+
+	ps "github.com/mitchellh/go-ps"
+)
+
+// Process is a running Claude Code process found by FindClaude.
+type Process struct {
+	PID       int
+	StartTime string // see StartTime
+	Cwd       string // working directory, "" where it can't be read
+}
+
+// FindClaude returns the Claude Code processes in the native process table.
+// It is stricter than IsClaude, since it looks at every process rather than
+// one recorded by a hook: a node process only counts when its command line
+// mentions claude. Processes started by another Claude Code process (tool
+// calls, subagents) are left out.
+func FindClaude() ([]Process, error) {
+	procs, err := ps.Processes()
+	if err != nil {
+		return nil, err
+	}
+	parent := map[int]int{}
+	for _, p := range procs {
+		if isClaudeProcess(p.Pid(), p.Executable()) {
+			parent[p.Pid()] = p.PPid()
+		}
+	}
+	var found []Process
+	for pid, ppid := range parent {
+		if _, nested := parent[ppid]; nested {
+			continue
+		}
+		found = append(found, Process{PID: pid, StartTime: StartTime(pid), Cwd: cwd(pid)})
+	}
+	return found, nil
+}
+
+func isClaudeProcess(pid int, name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+	switch {
+	case name == "claude" || isVersion(name):
+		return true
+	case strings.Contains(name, "node"):
+		return strings.Contains(strings.ToLower(cmdline(pid)), "claude")
+	}
+	return false
+}
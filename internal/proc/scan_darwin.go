@@ -0,0 +1,30 @@
+package proc
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// cmdline returns the command line of a process as reported by ps(1).
+func cmdline(pid int) string {
+	out, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// cwd returns the working directory of a process as reported by lsof(8).
+func cwd(pid int) string {
+	out, err := exec.Command("lsof", "-a", "-p", strconv.Itoa(pid), "-d", "cwd", "-Fn").Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if dir, ok := strings.CutPrefix(line, "n"); ok {
+			return dir
+		}
+	}
+	return ""
+}
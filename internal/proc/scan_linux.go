@@ -0,0 +1,23 @@
+package proc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// cmdline returns the command line of a process, arguments separated by
+// spaces.
+func cmdline(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.ReplaceAll(string(data), "\x00", " ")
+}
+
+// cwd returns the working directory of a process.
+func cwd(pid int) string {
+	dir, _ := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+	return dir
+}
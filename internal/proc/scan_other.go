@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package proc
+
+// cmdline is not supported on this platform and always returns "", so node
+// processes are never counted as Claude Code.
+func cmdline(pid int) string { return "" }
+
+// cwd is not supported on this platform and always returns "".
+func cwd(pid int) string { return "" }
@@ -0,0 +1,19 @@
+package proc
+
+import (
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// StartTime returns the process start time from the kernel's process table,
+// in microseconds since the Unix epoch. Returns "" if the process doesn't
+// exist.
+func StartTime(pid int) string {
+	kp, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil || kp.Proc.P_pid != int32(pid) {
+		return ""
+	}
+	t := kp.Proc.P_starttime
+	return strconv.FormatInt(t.Sec*1e6+int64(t.Usec), 10)
+}
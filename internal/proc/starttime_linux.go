@@ -0,0 +1,30 @@
+package proc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StartTime returns the process start time from /proc/<pid>/stat, in clock
+// ticks since boot. Returns "" if the process doesn't exist or the file
+// cannot be parsed.
+func StartTime(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// The command name (field 2) is wrapped in parens and may contain spaces,
+	// so split after the last ')'. starttime is field 22 overall, which is
+	// index 19 of the remaining fields (which start at field 3).
+	s := string(data)
+	i := strings.LastIndexByte(s, ')')
+	if i < 0 {
+		return ""
+	}
+	fields := strings.Fields(s[i+1:])
+	if len(fields) < 20 {
+		return ""
+	}
+	return fields[19]
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !windows
+
+package proc
+
+// StartTime is not supported on this platform and always returns "",
+// which disables the PID-reuse check in Alive.
+func StartTime(pid int) string { return "" }
@@ -0,0 +1,23 @@
+package proc
+
+import (
+	"strconv"
+
+	"golang.org/x/sys/windows"
+)
+
+// StartTime returns the process creation time in nanoseconds since the Unix
+// epoch. Returns "" if the process cannot be opened.
+func StartTime(pid int) string {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return ""
+	}
+	defer windows.CloseHandle(h)
+
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return ""
+	}
+	return strconv.FormatInt(creation.Nanoseconds(), 10)
+}
@@ -2,10 +2,15 @@ package session
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,6 +22,26 @@ const (
 	StatusWaiting  = "waiting"
 	StatusEnded    = "ended"
 	StatusExited   = "exited"
+	StatusLimited  = "limited" // usage limit hit; see Session.LimitResetsAt
+	StatusError    = "error"   // the turn ended in an API or network error
+	// StatusCompacting is a session compacting its context (PreCompact),
+	// which can take a minute without any other hook events.
+	StatusCompacting = "compacting"
+	// StatusStalled is never written by the hook. The monitor derives it for
+	// working sessions that have gone quiet for too long.
+	StatusStalled = "stalled"
+	// StatusUntracked is also derived by the monitor, for Claude Code
+	// processes that have no session file (hooks not installed, or started
+	// before they were).
+	StatusUntracked = "untracked"
+)
+
+// What a working session is doing, from the last hook event. There is no
+// hook event between the last tool call and the answer, so thinking includes
+// writing the response.
+const (
+	ActivityThinking = "thinking" // after a prompt or tool call
+	ActivityTool     = "tool"     // a tool call is running
 )
 
 // Terminal identifies a terminal backend and its tab/pane ID.
@@ -25,19 +50,94 @@ type Terminal struct {
 	ID      string `json:"id"`      // pane ID or runtime ID
 }
 
+// Git describes the git working tree a session runs in.
+type Git struct {
+	Repo     string `json:"repo"`             // main working tree, shared by all worktrees
+	Worktree string `json:"worktree"`         // working tree containing the project dir
+	Branch   string `json:"branch,omitempty"` // empty when HEAD is detached
+}
+
+// Env describes the environment a session runs in, for diagnosing terminal
+// backend detection.
+type Env struct {
+	Terminal string `json:"terminal,omitempty"` // emulator, e.g. "vscode 1.95.0", or $TERM
+	Shell    string `json:"shell,omitempty"`
+}
+
 // Session represents the state of a single Claude Code instance.
 type Session struct {
 	SessionID        string     `json:"session_id"`
+	Agent            string     `json:"agent,omitempty"`
 	Project          string     `json:"project"`
 	Status           string     `json:"status"`
+	Activity         string     `json:"activity,omitempty"` // ActivityThinking or ActivityTool, while working
 	Detail           string     `json:"detail"`
 	LastPrompt       string     `json:"last_prompt"`
 	NotificationType *string    `json:"notification_type"`
 	LastActivity     string     `json:"last_activity"`
+	StatusSince      string     `json:"status_since,omitempty"` // when Status last changed (RFC 3339)
 	Terminals        []Terminal `json:"terminals,omitempty"`
 	Summary          string     `json:"summary"`
 	PID              int        `json:"pid,omitempty"`
+	PIDStart         string     `json:"pid_start,omitempty"`
 	OS               string     `json:"os,omitempty"`
+	Git              *Git       `json:"git,omitempty"`
+	Env              *Env       `json:"env,omitempty"`
+	Host             string     `json:"host,omitempty"`
+	Subagents        []Subagent `json:"subagents,omitempty"`
+	LimitResetsAt    string     `json:"limit_resets_at,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`      // last failed tool call, until a tool succeeds
+	ToolFailed       bool       `json:"tool_failed,omitempty"`     // this event was a failed tool call, which Detail names
+	ContextLeft      *int       `json:"context_left,omitempty"`    // percent of the context window left, from the status line
+	Compacted        string     `json:"compacted,omitempty"`       // when the conversation was last compacted (RFC 3339)
+	ResumedFrom      string     `json:"resumed_from,omitempty"`    // session this one took over from, after --resume or /clear
+	WaitingTool      string     `json:"waiting_tool,omitempty"`    // tool a permission prompt asks about
+	WaitingCommand   string     `json:"waiting_command,omitempty"` // what that tool would run or touch, when known
+	Queued           int        `json:"queued,omitempty"`          // messages typed while Claude works, not yet taken
+	History          []string   `json:"history,omitempty"`         // last statuses, oldest first, ending with the current one
+	Usage            *Usage     `json:"usage,omitempty"`           // tokens and estimated cost so far, from the transcript
+	Model            string     `json:"model,omitempty"`           // model ID of the latest answer, e.g. "claude-sonnet-4-5-20250929"
+	TranscriptPath   string     `json:"transcript_path,omitempty"` // Claude Code's JSONL transcript of the conversation
+
+	// Note is free text attached by the user from the monitor. It lives in a
+	// <session_id>.note file next to the session file (which the hook owns)
+	// and is filled in by LoadAll.
+	Note string `json:"-"`
+}
+
+// Subagent is a subagent that is still running, started by a Task tool call
+// or reported by SubagentStart.
+type Subagent struct {
+	ID          string `json:"id"` // tool_use_id of the Task call, or the agent ID without one
+	Description string `json:"description"`
+	Type        string `json:"type,omitempty"`     // subagent_type, e.g. "Explore"
+	AgentID     string `json:"agent_id,omitempty"` // from SubagentStart, once it has run
+	Started     string `json:"started"`
+}
+
+// ModelName shortens a model ID for display: "claude-sonnet-4-5-20250929"
+// becomes "sonnet-4-5".
+func ModelName(id string) string {
+	name := strings.TrimPrefix(id, "claude-")
+	if i := strings.LastIndexByte(name, '-'); i >= 0 && len(name)-i-1 == 8 {
+		if _, err := strconv.Atoi(name[i+1:]); err == nil {
+			name = name[:i] // release date
+		}
+	}
+	return name
+}
+
+// Usage is the token usage of a session's assistant messages, with its
+// estimated cost at list prices.
+type Usage struct {
+	InputTokens         int     `json:"input_tokens"`
+	OutputTokens        int     `json:"output_tokens"`
+	CacheCreationTokens int     `json:"cache_creation_tokens"`
+	CacheReadTokens     int     `json:"cache_read_tokens"`
+	CostUSD             float64 `json:"cost_usd"`
+	// TranscriptOffset is how far the transcript has been counted, so the
+	// next Stop only reads what was added since.
+	TranscriptOffset int64 `json:"transcript_offset"`
 }
 
 // FindTerminalID returns the ID for the given backend name, or "" if not found.
@@ -50,23 +150,272 @@ func (s Session) FindTerminalID(backend string) string {
 	return ""
 }
 
+// OnOtherHost reports whether the session was written on a machine other
+// than host, through a synced or shared sessions directory, so its PID says
+// nothing about processes here. Sessions that don't record a host are local.
+func (s Session) OnOtherHost(host string) bool {
+	return s.Host != "" && !strings.EqualFold(s.Host, host)
+}
+
+// IsClaude reports whether the session was written by the Claude Code hooks
+// rather than reported by another agent through ccmonitor ingest.
+func (s Session) IsClaude() bool {
+	return s.Agent == "" || s.Agent == "claude"
+}
+
+// AwaitingPermission reports whether the session is blocked on a tool
+// permission dialog, which can be answered with a single keystroke.
+func (s Session) AwaitingPermission() bool {
+	return s.Status == StatusWaiting && s.NotificationType != nil && *s.NotificationType == "permission_prompt"
+}
+
+// attentionRank orders statuses by how urgently they need the user:
+// blocked sessions first, finished ones last.
+var attentionRank = map[string]int{
+	StatusWaiting:    0,
+	StatusError:      1,
+	StatusStalled:    2,
+	StatusWorking:    3,
+	StatusCompacting: 4,
+	StatusStarting:   5,
+	StatusLimited:    6,
+	StatusIdle:       7,
+	StatusExited:     8,
+	StatusEnded:      9,
+	StatusUntracked:  10,
+}
+
+// SortByAttention returns the sessions as one list ordered waiting → error
+// → stalled → working → compacting → starting → limited → idle → exited →
+// untracked, most recently active first within a status.
+func SortByAttention(sessions []Session) []Session {
+	sorted := append([]Session(nil), sessions...)
+	rank := func(s Session) int {
+		if r, ok := attentionRank[s.Status]; ok {
+			return r
+		}
+		return len(attentionRank)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if ri, rj := rank(sorted[i]), rank(sorted[j]); ri != rj {
+			return ri < rj
+		}
+		return sorted[i].LastActivity > sorted[j].LastActivity
+	})
+	return sorted
+}
+
+// GroupByStatus groups sessions by status, in SortByAttention order, so
+// that waiting sessions come first. Only statuses with sessions get a group.
+func GroupByStatus(sessions []Session) []ProjectGroup {
+	var groups []ProjectGroup
+	for _, s := range SortByAttention(sessions) {
+		if n := len(groups); n > 0 && groups[n-1].Status == s.Status {
+			groups[n-1].Sessions = append(groups[n-1].Sessions, s)
+			continue
+		}
+		groups = append(groups, ProjectGroup{Status: s.Status, Sessions: []Session{s}})
+	}
+	return groups
+}
+
 // ProjectGroup holds sessions belonging to the same project directory.
+// When sessions from several worktrees of one repository are merged,
+// Project is the main repository dir and Worktrees is true. Groups made by
+// GroupByStatus set Status instead of Project. More counts sessions a display
+// left out of Sessions to save space.
 type ProjectGroup struct {
-	Project  string
-	Sessions []Session
+	Project   string
+	Status    string
+	Sessions  []Session
+	Worktrees bool
+	More      int
 }
 
-// Dir returns the sessions directory, respecting CCMONITOR_SESSIONS_DIR.
+// Dir returns the sessions directory that hooks and the monitor write to:
+// the first of Dirs.
 func Dir() string {
-	if dir := os.Getenv("CCMONITOR_SESSIONS_DIR"); dir != "" {
-		return dir
+	return Dirs()[0]
+}
+
+// Dirs returns the sessions directories to read, never empty.
+// CCMONITOR_SESSIONS_DIR may list several, separated like $PATH (":", or
+// ";" on Windows); writes go to the first and the rest are only read. An
+// entry containing {host} gives each machine its own subdirectory, e.g. of a
+// synced folder: it stands for this machine's directory, followed by those
+// of the other machines that exist now.
+// Without it, there is one directory: inside WSL the Windows-side directory
+// when it exists, so that WSL and Windows instances of ccmonitor share the
+// same sessions, else the platform default.
+func Dirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv("CCMONITOR_SESSIONS_DIR")) {
+		if dir != "" {
+			dirs = append(dirs, expandHost(dir)...)
+		}
 	}
+	if len(dirs) > 0 {
+		return dirs
+	}
+	if dir := wslWindowsDir(); dir != "" {
+		return []string{dir}
+	}
+	return []string{defaultDir()}
+}
+
+// hostPlaceholder is replaced by the hostname in CCMONITOR_SESSIONS_DIR.
+const hostPlaceholder = "{host}"
+
+// expandHost returns dir with {host} replaced by this machine's hostname,
+// followed by the existing directories for other hostnames. Machines that
+// each write only their own directory never conflict in a synced folder.
+func expandHost(dir string) []string {
+	if !strings.Contains(dir, hostPlaceholder) {
+		return []string{dir}
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	own := strings.ReplaceAll(dir, hostPlaceholder, host)
+	dirs := []string{own}
+	matches, _ := filepath.Glob(strings.ReplaceAll(dir, hostPlaceholder, "*"))
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && info.IsDir() && m != own {
+			dirs = append(dirs, m)
+		}
+	}
+	return dirs
+}
+
+// isConflictCopy reports whether a file name is a copy a file sync tool
+// made when two machines changed the file at once: Syncthing's
+// "<name>.sync-conflict-<date>-<device>.json", or Dropbox's and Nextcloud's
+// "<name> (... conflicted copy ...).json". Each is a stale duplicate of a
+// session file.
+func isConflictCopy(name string) bool {
+	return strings.Contains(name, ".sync-conflict-") || strings.Contains(name, "conflicted copy")
+}
+
+// defaultDir returns the platform default sessions directory:
+// %LOCALAPPDATA%\ccmonitor\sessions on Windows (kept off roaming and
+// OneDrive-synced profiles), ~/.ccmonitor/sessions elsewhere.
+func defaultDir() string {
+	if runtime.GOOS == "windows" {
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			return filepath.Join(local, "ccmonitor", "sessions")
+		}
+	}
+	return legacyDir()
+}
+
+// legacyDir returns ~/.ccmonitor/sessions, the default on all platforms
+// before Windows moved to %LOCALAPPDATA%.
+func legacyDir() string {
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".ccmonitor", "sessions")
 }
 
+// wslUsersDir is where the Windows user profiles are mounted inside WSL.
+var wslUsersDir = "/mnt/c/Users"
+
+// wslWindowsDir returns the Windows user's sessions directory as seen from
+// WSL (e.g. /mnt/c/Users/martin/AppData/Local/ccmonitor/sessions, or the
+// legacy .ccmonitor/sessions), or "" when not running under WSL or no Windows
+// profile has one. If several profiles do, the one named after $USER wins;
+// otherwise the choice is ambiguous and "" is returned.
+func wslWindowsDir() string {
+	if os.Getenv("WSL_DISTRO_NAME") == "" {
+		return ""
+	}
+	layouts := [][]string{
+		{"AppData", "Local", "ccmonitor", "sessions"},
+		{".ccmonitor", "sessions"},
+	}
+	for _, layout := range layouts {
+		pattern := filepath.Join(append([]string{wslUsersDir, "*"}, layout...)...)
+		matches, _ := filepath.Glob(pattern)
+		if dir := pickProfileDir(matches, len(layout)); dir != "" {
+			return dir
+		}
+	}
+	return ""
+}
+
+// pickProfileDir chooses among sessions dirs found under several Windows
+// profiles. depth is how many path components the dir sits below the
+// profile directory.
+func pickProfileDir(matches []string, depth int) string {
+	switch len(matches) {
+	case 0:
+		return ""
+	case 1:
+		return matches[0]
+	}
+	user := os.Getenv("USER")
+	for _, m := range matches {
+		profile := m
+		for range depth {
+			profile = filepath.Dir(profile)
+		}
+		if strings.EqualFold(filepath.Base(profile), user) {
+			return m
+		}
+	}
+	return ""
+}
+
+// MigrateLegacy moves session files from ~/.ccmonitor/sessions into the
+// platform default directory when the two differ (currently only on
+// Windows). It is a no-op when CCMONITOR_SESSIONS_DIR is set or there is
+// nothing to migrate. Returns the number of files moved.
+func MigrateLegacy() (int, error) {
+	if os.Getenv("CCMONITOR_SESSIONS_DIR") != "" {
+		return 0, nil
+	}
+	return migrateDir(legacyDir(), defaultDir())
+}
+
+// migrateDir moves .json files from src to dst. Files that already exist in
+// dst are considered newer and the src copy is dropped. src is removed once
+// it is empty.
+func migrateDir(src, dst string) (int, error) {
+	if src == dst {
+		return 0, nil
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return 0, fmt.Errorf("creating sessions dir: %w", err)
+	}
+
+	moved := 0
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		from := filepath.Join(src, e.Name())
+		to := filepath.Join(dst, e.Name())
+		if _, err := os.Stat(to); err == nil {
+			os.Remove(from) // best-effort
+			continue
+		}
+		if err := os.Rename(from, to); err == nil {
+			moved++
+		}
+	}
+	os.Remove(src) // best-effort, only succeeds when empty
+	return moved, nil
+}
+
 // ForEachSessionFile iterates over all valid session files in dir, calling fn
-// with the file path and parsed session for each. Corrupt files are skipped.
+// with the file path and parsed session for each. Corrupt files and the
+// conflict copies of sync tools are skipped.
 // Returns nil (not an error) if the directory does not exist.
 func ForEachSessionFile(dir string, fn func(path string, s *Session)) error {
 	entries, err := os.ReadDir(dir)
@@ -77,7 +426,7 @@ func ForEachSessionFile(dir string, fn func(path string, s *Session)) error {
 		return err
 	}
 	for _, e := range entries {
-		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" || isConflictCopy(e.Name()) {
 			continue
 		}
 		path := filepath.Join(dir, e.Name())
@@ -90,31 +439,208 @@ func ForEachSessionFile(dir string, fn func(path string, s *Session)) error {
 	return nil
 }
 
-// LoadAll reads all session JSON files from dir and returns the parsed sessions.
-// Corrupt or unreadable files are skipped silently. PID liveness checking is the
-// caller's responsibility (see monitor package).
-func LoadAll(dir string) ([]Session, error) {
+// LoadAll reads all session JSON files from dirs and returns the parsed
+// sessions, with any attached notes. Corrupt or unreadable files are skipped
+// silently. A session found in several dirs is reported once, from the most
+// recently active file. Notes are written to the first dir (see SaveNote), so
+// a note there wins over one next to a session file in another dir. An
+// unreadable dir doesn't stop the others from loading; its error is returned
+// along with the sessions.
+// PID liveness checking is the caller's responsibility (see monitor package).
+func LoadAll(dirs ...string) ([]Session, error) {
 	var sessions []Session
-	err := ForEachSessionFile(dir, func(_ string, s *Session) {
-		sessions = append(sessions, *s)
-	})
-	return sessions, err
+	index := map[string]int{}
+	var errs []error
+	for i, dir := range dirs {
+		err := ForEachSessionFile(dir, func(path string, s *Session) {
+			s.Note = loadNote(notePath(path))
+			if i > 0 {
+				if note := loadNote(notePath(filepath.Join(dirs[0], s.SessionID+".json"))); note != "" {
+					s.Note = note
+				}
+			}
+			if j, ok := index[s.SessionID]; ok {
+				if s.LastActivity > sessions[j].LastActivity {
+					sessions[j] = *s
+				}
+				return
+			}
+			index[s.SessionID] = len(sessions)
+			sessions = append(sessions, *s)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return sessions, errors.Join(errs...)
+}
+
+// notePath returns the note file that belongs to a session file.
+func notePath(sessionPath string) string {
+	return strings.TrimSuffix(sessionPath, ".json") + ".note"
+}
+
+func loadNote(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// SaveNote attaches a note to the session with the given ID. An empty note
+// removes it.
+func SaveNote(dir, sessionID, note string) error {
+	path := notePath(filepath.Join(dir, sessionID+".json"))
+	note = strings.TrimSpace(note)
+	if note == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(path, []byte(note+"\n"), 0644)
+}
+
+// Remove deletes a session file together with its note, if any.
+// Errors are ignored (best-effort), matching how callers treat cleanup.
+func Remove(sessionPath string) {
+	os.Remove(sessionPath)
+	os.Remove(notePath(sessionPath))
+}
+
+// FilterProject returns the sessions whose project directory is dir or one of
+// its subdirectories. Both forward and backslash separators are accepted so
+// Windows paths from WSL sessions match too. An empty dir returns sessions
+// unchanged.
+func FilterProject(sessions []Session, dir string) []Session {
+	if dir == "" {
+		return sessions
+	}
+	root := normalizePath(dir)
+	var out []Session
+	for _, s := range sessions {
+		p := normalizePath(s.Project)
+		if p == root || strings.HasPrefix(p, strings.TrimSuffix(root, "/")+"/") {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// FilterIgnored drops the sessions whose project directory, or a directory
+// above it, matches one of patterns. Patterns use path.Match syntax over
+// forward-slash paths, and a leading "~/" stands for the home directory, so
+// both "~/scratch" and "~/scratch/*" hide every session under ~/scratch.
+func FilterIgnored(sessions []Session, patterns []string) []Session {
+	if len(patterns) == 0 {
+		return sessions
+	}
+	home, _ := os.UserHomeDir()
+	var clean []string
+	for _, p := range patterns {
+		if rest, ok := strings.CutPrefix(p, "~/"); ok && home != "" {
+			p = home + "/" + rest
+		}
+		clean = append(clean, normalizePath(p))
+	}
+	var out []Session
+	for _, s := range sessions {
+		if !ignored(normalizePath(s.Project), clean) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// ignored reports whether dir or one of its parents matches a pattern.
+func ignored(dir string, patterns []string) bool {
+	for {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, dir); ok {
+				return true
+			}
+		}
+		parent := path.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// normalizePath converts backslashes to forward slashes and cleans the path.
+func normalizePath(p string) string {
+	return path.Clean(strings.ReplaceAll(p, "\\", "/"))
+}
+
+// SortOrder is the order of the sessions within a project group.
+type SortOrder int
+
+const (
+	OrderByID       SortOrder = iota // project, then session ID: a stable order
+	OrderByActivity                  // most recently active first
+	OrderByStatus                    // as SortByAttention, waiting first
+)
+
+// SortOrders are the sort orders, in the order the monitor cycles through them.
+var SortOrders = []SortOrder{OrderByID, OrderByActivity, OrderByStatus}
+
+func (o SortOrder) String() string {
+	switch o {
+	case OrderByActivity:
+		return "activity"
+	case OrderByStatus:
+		return "status"
+	}
+	return "ID"
 }
 
 // GroupByProject groups sessions by their project directory, sorted by project name.
-// Sessions within each group are sorted by session ID (stable order).
-func GroupByProject(sessions []Session) []ProjectGroup {
+// Sessions running in different worktrees of the same git repository are
+// merged into one group keyed by the main repository dir.
+// Sessions within each group are sorted by order.
+func GroupByProject(sessions []Session, order SortOrder) []ProjectGroup {
+	// Find repositories with sessions in more than one worktree.
+	worktrees := make(map[string]map[string]bool)
+	for _, s := range sessions {
+		if s.Git == nil || s.Git.Repo == "" {
+			continue
+		}
+		if worktrees[s.Git.Repo] == nil {
+			worktrees[s.Git.Repo] = make(map[string]bool)
+		}
+		worktrees[s.Git.Repo][s.Git.Worktree] = true
+	}
+
 	grouped := make(map[string][]Session)
+	merged := make(map[string]bool)
 	for _, s := range sessions {
-		grouped[s.Project] = append(grouped[s.Project], s)
+		key := s.Project
+		if s.Git != nil && len(worktrees[s.Git.Repo]) > 1 {
+			key = s.Git.Repo
+			merged[key] = true
+		}
+		grouped[key] = append(grouped[key], s)
 	}
 
 	var groups []ProjectGroup
 	for project, sess := range grouped {
 		sort.Slice(sess, func(i, j int) bool {
+			if sess[i].Project != sess[j].Project {
+				return sess[i].Project < sess[j].Project
+			}
 			return sess[i].SessionID < sess[j].SessionID
 		})
-		groups = append(groups, ProjectGroup{Project: project, Sessions: sess})
+		switch order {
+		case OrderByActivity:
+			sort.SliceStable(sess, func(i, j int) bool {
+				return sess[i].LastActivity > sess[j].LastActivity
+			})
+		case OrderByStatus:
+			sess = SortByAttention(sess)
+		}
+		groups = append(groups, ProjectGroup{Project: project, Sessions: sess, Worktrees: merged[project]})
 	}
 
 	sort.Slice(groups, func(i, j int) bool {
@@ -124,6 +650,49 @@ func GroupByProject(sessions []Session) []ProjectGroup {
 	return groups
 }
 
+// TimeFormat is how times are shown. The zero value is the default: 12-hour
+// clock, local time, short durations ("5m ago").
+type TimeFormat struct {
+	Clock24   bool           // "15:04" instead of "3:04 PM"
+	Location  *time.Location // time zone of absolute times, shown with them (nil = local, not shown)
+	LongUnits bool           // "5 minutes ago" instead of "5m ago"
+}
+
+var timeFormat TimeFormat
+
+// SetTimeFormat changes how TimeSince and FormatTime format times. Call it
+// once, at startup.
+func SetTimeFormat(f TimeFormat) {
+	timeFormat = f
+}
+
+// In returns t in the configured time zone, or unchanged when none is set.
+func In(t time.Time) time.Time {
+	if timeFormat.Location != nil {
+		return t.In(timeFormat.Location)
+	}
+	return t
+}
+
+// FormatTime formats t as a clock time in now's time zone (or the configured
+// one), with the date when it isn't on the same day as now and the zone when
+// one is configured: "3:04 PM", "Mar 9 3:04 PM", "15:04 UTC".
+func FormatTime(t, now time.Time) string {
+	now = In(now)
+	t = t.In(now.Location())
+	layout := "3:04 PM"
+	if timeFormat.Clock24 {
+		layout = "15:04"
+	}
+	if y, m, d := t.Date(); y != now.Year() || m != now.Month() || d != now.Day() {
+		layout = "Jan 2 " + layout
+	}
+	if timeFormat.Location != nil {
+		layout += " MST"
+	}
+	return t.Format(layout)
+}
+
 // TimeSince returns a human-readable duration since the given RFC3339 timestamp.
 func TimeSince(timestamp string) string {
 	t, err := time.Parse(time.RFC3339, timestamp)
@@ -132,21 +701,57 @@ func TimeSince(timestamp string) string {
 	}
 
 	d := time.Since(t)
-	switch {
-	case d < time.Second:
+	if d < time.Second {
 		return "now"
+	}
+	return Duration(d) + " ago"
+}
+
+// Duration formats d in its largest whole unit, the way TimeSince does:
+// "4m", or "4 minutes" with long units.
+func Duration(d time.Duration) string {
+	var n int
+	var unit string
+	switch {
 	case d < time.Minute:
-		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+		n, unit = int(d.Seconds()), "s"
 	case d < time.Hour:
-		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+		n, unit = int(d.Minutes()), "m"
 	case d < 24*time.Hour:
-		return fmt.Sprintf("%dh ago", int(d.Hours()))
+		n, unit = int(d.Hours()), "h"
 	default:
-		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+		n, unit = int(d.Hours()/24), "d"
+	}
+	if !timeFormat.LongUnits {
+		return fmt.Sprintf("%d%s", n, unit)
 	}
+	word := longUnits[unit]
+	if n != 1 {
+		word += "s"
+	}
+	return fmt.Sprintf("%d %s", n, word)
 }
 
-// CleanAll removes all .json session files from dir and returns the count removed.
+var longUnits = map[string]string{"s": "second", "m": "minute", "h": "hour", "d": "day"}
+
+// Truncate shortens s to at most n runes, never splitting a multi-byte
+// character. Returns s unchanged if it already fits.
+func Truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos]
+		}
+		i++
+	}
+	return s
+}
+
+// CleanAll removes all .json session files (and their notes) from dir and
+// returns the count of session files removed.
 func CleanAll(dir string) (int, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -158,11 +763,16 @@ func CleanAll(dir string) (int, error) {
 
 	removed := 0
 	for _, e := range entries {
-		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+		if e.IsDir() {
 			continue
 		}
-		if err := os.Remove(filepath.Join(dir, e.Name())); err == nil {
-			removed++
+		switch filepath.Ext(e.Name()) {
+		case ".json":
+			if err := os.Remove(filepath.Join(dir, e.Name())); err == nil {
+				removed++
+			}
+		case ".note":
+			os.Remove(filepath.Join(dir, e.Name())) // best-effort
 		}
 	}
 	return removed, nil
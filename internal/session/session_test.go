@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -160,11 +161,190 @@ func TestLoadAll(t *testing.T) {
 			t.Errorf("got PID %d, want 12345", sessions[0].PID)
 		}
 	})
+	t.Run("several directories should be merged", func(t *testing.T) {
+		local, remote := t.TempDir(), t.TempDir()
+		writeSessionFile(t, local, Session{SessionID: "a", Status: "idle", LastActivity: "2026-01-01T10:00:00Z"})
+		writeSessionFile(t, remote, Session{SessionID: "a", Status: "working", LastActivity: "2026-01-01T11:00:00Z"})
+		writeSessionFile(t, remote, Session{SessionID: "b", Status: "waiting"})
+		if err := SaveNote(local, "b", "check the remote one"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		sessions, err := LoadAll(local, "/nonexistent/path", remote)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Fatalf("got %d sessions, want 2", len(sessions))
+		}
+		if sessions[0].SessionID != "a" || sessions[0].Status != "working" {
+			t.Errorf("duplicate session should come from the most recently active file, got %+v", sessions[0])
+		}
+		if sessions[1].Note != "check the remote one" {
+			t.Errorf("note in the first dir should apply to sessions in the others, got %q", sessions[1].Note)
+		}
+	})
+
+	t.Run("conflict copies from sync tools should be ignored", func(t *testing.T) {
+		dir := t.TempDir()
+		writeSessionFile(t, dir, Session{SessionID: "a", Status: "idle", LastActivity: "2026-01-01T10:00:00Z"})
+		stale, _ := json.Marshal(Session{SessionID: "a", Status: "working", LastActivity: "2026-01-01T11:00:00Z"})
+		os.WriteFile(filepath.Join(dir, "a.sync-conflict-20260101-110000-ABCDEFG.json"), stale, 0644)
+		os.WriteFile(filepath.Join(dir, "a (laptop's conflicted copy 2026-01-01).json"), stale, 0644)
+
+		sessions, err := LoadAll(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].Status != "idle" {
+			t.Errorf("got %+v, want only the session file", sessions)
+		}
+	})
+}
+
+func TestAwaitingPermission(t *testing.T) {
+	perm, other := "permission_prompt", "elicitation_dialog"
+	tests := []struct {
+		name string
+		s    Session
+		want bool
+	}{
+		{"waiting on permission prompt should be true", Session{Status: StatusWaiting, NotificationType: &perm}, true},
+		{"waiting on other notification should be false", Session{Status: StatusWaiting, NotificationType: &other}, false},
+		{"working session should be false", Session{Status: StatusWorking, NotificationType: &perm}, false},
+		{"waiting without notification type should be false", Session{Status: StatusWaiting}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.AwaitingPermission(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortByAttention(t *testing.T) {
+	sessions := []Session{
+		{SessionID: "idle", Status: StatusIdle},
+		{SessionID: "work-old", Status: StatusWorking, LastActivity: "2026-01-01T10:00:00Z"},
+		{SessionID: "exited", Status: StatusExited},
+		{SessionID: "wait", Status: StatusWaiting},
+		{SessionID: "work-new", Status: StatusWorking, LastActivity: "2026-01-01T11:00:00Z"},
+		{SessionID: "start", Status: StatusStarting},
+	}
+
+	got := SortByAttention(sessions)
+
+	want := []string{"wait", "work-new", "work-old", "start", "idle", "exited"}
+	for i, sid := range want {
+		if got[i].SessionID != sid {
+			t.Errorf("position %d: got %s, want %s", i, got[i].SessionID, sid)
+		}
+	}
+	if sessions[0].SessionID != "idle" {
+		t.Error("input slice should not be reordered")
+	}
+}
+
+func TestGroupByStatus(t *testing.T) {
+	sessions := []Session{
+		{SessionID: "idle", Status: StatusIdle},
+		{SessionID: "work-old", Status: StatusWorking, LastActivity: "2026-01-01T10:00:00Z"},
+		{SessionID: "wait", Status: StatusWaiting},
+		{SessionID: "work-new", Status: StatusWorking, LastActivity: "2026-01-01T11:00:00Z"},
+	}
+
+	groups := GroupByStatus(sessions)
+
+	want := []struct {
+		status string
+		ids    []string
+	}{
+		{StatusWaiting, []string{"wait"}},
+		{StatusWorking, []string{"work-new", "work-old"}},
+		{StatusIdle, []string{"idle"}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("expected %d groups, got %d", len(want), len(groups))
+	}
+	for i, w := range want {
+		if groups[i].Status != w.status {
+			t.Errorf("group %d: got status %s, want %s", i, groups[i].Status, w.status)
+		}
+		var ids []string
+		for _, s := range groups[i].Sessions {
+			ids = append(ids, s.SessionID)
+		}
+		if strings.Join(ids, ",") != strings.Join(w.ids, ",") {
+			t.Errorf("group %d: got %v, want %v", i, ids, w.ids)
+		}
+	}
+}
+
+func TestNotes(t *testing.T) {
+	t.Run("saved note should be attached by LoadAll", func(t *testing.T) {
+		dir := t.TempDir()
+		writeSessionFile(t, dir, Session{SessionID: "s1", Project: "/p"})
+		if err := SaveNote(dir, "s1", "  waiting on DB migration \n"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		sessions, _ := LoadAll(dir)
+		if len(sessions) != 1 {
+			t.Fatalf("got %d sessions, want 1 (note file must not count as a session)", len(sessions))
+		}
+		if sessions[0].Note != "waiting on DB migration" {
+			t.Errorf("note = %q, want %q", sessions[0].Note, "waiting on DB migration")
+		}
+	})
+
+	t.Run("empty note should remove the note file", func(t *testing.T) {
+		dir := t.TempDir()
+		writeSessionFile(t, dir, Session{SessionID: "s1"})
+		SaveNote(dir, "s1", "temp")
+		if err := SaveNote(dir, "s1", ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "s1.note")); !os.IsNotExist(err) {
+			t.Error("note file should have been removed")
+		}
+	})
+
+	t.Run("Remove should delete the session file and its note", func(t *testing.T) {
+		dir := t.TempDir()
+		writeSessionFile(t, dir, Session{SessionID: "s1"})
+		SaveNote(dir, "s1", "note")
+
+		Remove(filepath.Join(dir, "s1.json"))
+
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 0 {
+			t.Errorf("got %d files, want 0", len(entries))
+		}
+	})
+
+	t.Run("CleanAll should remove notes but only count sessions", func(t *testing.T) {
+		dir := t.TempDir()
+		writeSessionFile(t, dir, Session{SessionID: "s1"})
+		SaveNote(dir, "s1", "note")
+
+		removed, err := CleanAll(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if removed != 1 {
+			t.Errorf("removed = %d, want 1", removed)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 0 {
+			t.Errorf("got %d files left, want 0", len(entries))
+		}
+	})
 }
 
 func TestGroupByProject(t *testing.T) {
 	t.Run("empty input should return no groups", func(t *testing.T) {
-		groups := GroupByProject(nil)
+		groups := GroupByProject(nil, OrderByID)
 		if len(groups) != 0 {
 			t.Errorf("got %d groups, want 0", len(groups))
 		}
@@ -177,7 +357,7 @@ func TestGroupByProject(t *testing.T) {
 			{SessionID: "s3", Project: "/b-project", LastActivity: "2026-01-02T00:00:00Z"},
 		}
 
-		groups := GroupByProject(sessions)
+		groups := GroupByProject(sessions, OrderByID)
 		if len(groups) != 2 {
 			t.Fatalf("got %d groups, want 2", len(groups))
 		}
@@ -195,7 +375,7 @@ func TestGroupByProject(t *testing.T) {
 			{SessionID: "aaa", Project: "/proj", LastActivity: "2026-01-01T00:00:00Z"},
 		}
 
-		groups := GroupByProject(sessions)
+		groups := GroupByProject(sessions, OrderByID)
 		if len(groups) != 1 {
 			t.Fatalf("got %d groups, want 1", len(groups))
 		}
@@ -204,6 +384,159 @@ func TestGroupByProject(t *testing.T) {
 		}
 	})
 
+	t.Run("sessions in different worktrees of one repo should be merged", func(t *testing.T) {
+		sessions := []Session{
+			{SessionID: "s1", Project: "/src/repo", Git: &Git{Repo: "/src/repo", Worktree: "/src/repo", Branch: "main"}},
+			{SessionID: "s2", Project: "/src/repo-feature", Git: &Git{Repo: "/src/repo", Worktree: "/src/repo-feature", Branch: "feature"}},
+			{SessionID: "s3", Project: "/src/other", Git: &Git{Repo: "/src/other", Worktree: "/src/other"}},
+		}
+
+		groups := GroupByProject(sessions, OrderByID)
+		if len(groups) != 2 {
+			t.Fatalf("got %d groups, want 2", len(groups))
+		}
+		if groups[1].Project != "/src/repo" || !groups[1].Worktrees {
+			t.Errorf("got group %q (worktrees=%v), want merged /src/repo", groups[1].Project, groups[1].Worktrees)
+		}
+		if len(groups[1].Sessions) != 2 {
+			t.Errorf("got %d sessions in merged group, want 2", len(groups[1].Sessions))
+		}
+		if groups[0].Worktrees {
+			t.Error("single-worktree repo should not be marked as merged")
+		}
+	})
+
+	t.Run("subdirectories of a single worktree should stay separate", func(t *testing.T) {
+		git := &Git{Repo: "/src/repo", Worktree: "/src/repo"}
+		sessions := []Session{
+			{SessionID: "s1", Project: "/src/repo", Git: git},
+			{SessionID: "s2", Project: "/src/repo/web", Git: git},
+		}
+
+		if groups := GroupByProject(sessions, OrderByID); len(groups) != 2 {
+			t.Errorf("got %d groups, want 2", len(groups))
+		}
+	})
+
+	t.Run("sessions should follow the sort order", func(t *testing.T) {
+		sessions := []Session{
+			{SessionID: "a", Project: "/p", Status: StatusIdle, LastActivity: "2026-01-01T10:00:00Z"},
+			{SessionID: "b", Project: "/p", Status: StatusWaiting, LastActivity: "2026-01-01T09:00:00Z"},
+			{SessionID: "c", Project: "/p", Status: StatusWorking, LastActivity: "2026-01-01T11:00:00Z"},
+		}
+		for _, tt := range []struct {
+			order SortOrder
+			want  string
+		}{
+			{OrderByID, "abc"},
+			{OrderByActivity, "cab"},
+			{OrderByStatus, "bca"},
+		} {
+			var got string
+			for _, s := range GroupByProject(sessions, tt.order)[0].Sessions {
+				got += s.SessionID
+			}
+			if got != tt.want {
+				t.Errorf("by %v: got %s, want %s", tt.order, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestFilterProject(t *testing.T) {
+	sessions := []Session{
+		{SessionID: "s1", Project: "/home/user/repo"},
+		{SessionID: "s2", Project: "/home/user/repo/sub/dir"},
+		{SessionID: "s3", Project: "/home/user/repo-other"},
+		{SessionID: "s4", Project: "/home/user/elsewhere"},
+		{SessionID: "s5", Project: `C:\Users\me\repo\pkg`},
+	}
+
+	ids := func(ss []Session) []string {
+		var out []string
+		for _, s := range ss {
+			out = append(out, s.SessionID)
+		}
+		return out
+	}
+
+	t.Run("empty dir should return all sessions", func(t *testing.T) {
+		if got := FilterProject(sessions, ""); len(got) != len(sessions) {
+			t.Errorf("got %d sessions, want %d", len(got), len(sessions))
+		}
+	})
+
+	t.Run("dir should match itself and subdirectories but not siblings", func(t *testing.T) {
+		got := ids(FilterProject(sessions, "/home/user/repo/"))
+		if len(got) != 2 || got[0] != "s1" || got[1] != "s2" {
+			t.Errorf("got %v, want [s1 s2]", got)
+		}
+	})
+
+	t.Run("Windows paths should match with backslashes", func(t *testing.T) {
+		got := ids(FilterProject(sessions, `C:\Users\me\repo`))
+		if len(got) != 1 || got[0] != "s5" {
+			t.Errorf("got %v, want [s5]", got)
+		}
+	})
+
+	t.Run("root dir should match everything under it", func(t *testing.T) {
+		got := FilterProject(sessions, "/home")
+		if len(got) != 4 {
+			t.Errorf("got %d sessions, want 4", len(got))
+		}
+	})
+}
+
+func TestFilterIgnored(t *testing.T) {
+	sessions := []Session{
+		{SessionID: "s1", Project: "/home/user/repo"},
+		{SessionID: "s2", Project: "/home/user/scratch/try-1"},
+		{SessionID: "s3", Project: "/home/user/scratch/try-2/sub"},
+		{SessionID: "s4", Project: "/tmp/demo"},
+		{SessionID: "s5", Project: `C:\Users\me\scratch`},
+	}
+
+	ids := func(ss []Session) string {
+		var out []string
+		for _, s := range ss {
+			out = append(out, s.SessionID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     string
+	}{
+		{"no patterns should keep everything", nil, "s1,s2,s3,s4,s5"},
+		{"directory should hide everything below it", []string{"/home/user/scratch"}, "s1,s4,s5"},
+		{"glob should hide matching dirs and their subdirectories", []string{"/home/user/scratch/try-*"}, "s1,s4,s5"},
+		{"glob should not match across path elements", []string{"/tmp/*/x"}, "s1,s2,s3,s4,s5"},
+		{"Windows patterns should match with backslashes", []string{`C:\Users\*\scratch`}, "s1,s2,s3,s4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(FilterIgnored(sessions, tt.patterns)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                            "0s",
+		45 * time.Second:             "45s",
+		12 * time.Minute:             "12m",
+		3*time.Hour + 59*time.Minute: "3h",
+		50 * time.Hour:               "2d",
+	} {
+		if got := Duration(d); got != want {
+			t.Errorf("Duration(%v) = %q, want %q", d, got, want)
+		}
+	}
 }
 
 func TestTimeSince(t *testing.T) {
@@ -251,4 +584,274 @@ func TestTimeSince(t *testing.T) {
 			t.Errorf("got %q, want %q", got, "2d ago")
 		}
 	})
+
+	t.Run("long units should be spelled out", func(t *testing.T) {
+		SetTimeFormat(TimeFormat{LongUnits: true})
+		t.Cleanup(func() { SetTimeFormat(TimeFormat{}) })
+		if got := TimeSince(time.Now().Add(-5 * time.Minute).Format(time.RFC3339)); got != "5 minutes ago" {
+			t.Errorf("got %q, want %q", got, "5 minutes ago")
+		}
+		if got := TimeSince(time.Now().Add(-25 * time.Hour).Format(time.RFC3339)); got != "1 day ago" {
+			t.Errorf("got %q, want %q", got, "1 day ago")
+		}
+	})
+}
+
+func TestFormatTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 18, 0, 0, 0, time.UTC)
+	t.Cleanup(func() { SetTimeFormat(TimeFormat{}) })
+
+	tests := []struct {
+		name   string
+		format TimeFormat
+		t      time.Time
+		want   string
+	}{
+		{"default is a 12-hour clock in now's zone", TimeFormat{}, now.Add(-3 * time.Hour), "3:00 PM"},
+		{"other days should show the date", TimeFormat{}, now.Add(-24 * time.Hour), "Mar 9 6:00 PM"},
+		{"24-hour clock", TimeFormat{Clock24: true}, now.Add(-3 * time.Hour), "15:00"},
+		{"configured zone should be converted to and shown", TimeFormat{Clock24: true, Location: time.FixedZone("CET", 3600)}, now.Add(-3 * time.Hour), "16:00 CET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTimeFormat(tt.format)
+			if got := FormatTime(tt.t, now); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDir(t *testing.T) {
+	mkProfile := func(t *testing.T, users, name string) string {
+		t.Helper()
+		dir := filepath.Join(users, name, ".ccmonitor", "sessions")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		return dir
+	}
+	withUsersDir := func(t *testing.T, dir string) {
+		t.Helper()
+		old := wslUsersDir
+		wslUsersDir = dir
+		t.Cleanup(func() { wslUsersDir = old })
+	}
+
+	t.Run("CCMONITOR_SESSIONS_DIR should take precedence", func(t *testing.T) {
+		t.Setenv("CCMONITOR_SESSIONS_DIR", "/custom/dir")
+		t.Setenv("WSL_DISTRO_NAME", "Ubuntu")
+		if got := Dir(); got != "/custom/dir" {
+			t.Errorf("got %q, want %q", got, "/custom/dir")
+		}
+	})
+
+	t.Run("CCMONITOR_SESSIONS_DIR may list several directories", func(t *testing.T) {
+		list := strings.Join([]string{"/first", "", "/second"}, string(os.PathListSeparator))
+		t.Setenv("CCMONITOR_SESSIONS_DIR", list)
+		if got := Dirs(); strings.Join(got, ",") != "/first,/second" {
+			t.Errorf("Dirs() = %v, want [/first /second]", got)
+		}
+		if got := Dir(); got != "/first" {
+			t.Errorf("Dir() = %q, want the first directory", got)
+		}
+	})
+
+	t.Run("the host placeholder should expand to this machine's dir, then the others'", func(t *testing.T) {
+		host, err := os.Hostname()
+		if err != nil {
+			t.Skip("no hostname")
+		}
+		synced := t.TempDir()
+		os.MkdirAll(filepath.Join(synced, "other-box"), 0755)
+		os.WriteFile(filepath.Join(synced, "stray.json"), nil, 0644)
+		t.Setenv("CCMONITOR_SESSIONS_DIR", filepath.Join(synced, "{host}"))
+
+		want := []string{filepath.Join(synced, host), filepath.Join(synced, "other-box")}
+		if got := Dirs(); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Dirs() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("outside WSL should use the home directory", func(t *testing.T) {
+		t.Setenv("CCMONITOR_SESSIONS_DIR", "")
+		t.Setenv("WSL_DISTRO_NAME", "")
+		users := t.TempDir()
+		withUsersDir(t, users)
+		mkProfile(t, users, "martin")
+
+		home, _ := os.UserHomeDir()
+		want := filepath.Join(home, ".ccmonitor", "sessions")
+		if got := Dir(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("inside WSL should use the Windows sessions dir when it exists", func(t *testing.T) {
+		t.Setenv("CCMONITOR_SESSIONS_DIR", "")
+		t.Setenv("WSL_DISTRO_NAME", "Ubuntu")
+		users := t.TempDir()
+		withUsersDir(t, users)
+		want := mkProfile(t, users, "Martin")
+
+		if got := Dir(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("inside WSL with several profiles should pick the one matching USER", func(t *testing.T) {
+		t.Setenv("CCMONITOR_SESSIONS_DIR", "")
+		t.Setenv("WSL_DISTRO_NAME", "Ubuntu")
+		t.Setenv("USER", "martin")
+		users := t.TempDir()
+		withUsersDir(t, users)
+		mkProfile(t, users, "Admin")
+		want := mkProfile(t, users, "Martin")
+
+		if got := Dir(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("inside WSL with ambiguous profiles should fall back to home", func(t *testing.T) {
+		t.Setenv("CCMONITOR_SESSIONS_DIR", "")
+		t.Setenv("WSL_DISTRO_NAME", "Ubuntu")
+		t.Setenv("USER", "nobody")
+		users := t.TempDir()
+		withUsersDir(t, users)
+		mkProfile(t, users, "Admin")
+		mkProfile(t, users, "Martin")
+
+		home, _ := os.UserHomeDir()
+		want := filepath.Join(home, ".ccmonitor", "sessions")
+		if got := Dir(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestWSLWindowsDirPrefersLocalAppData(t *testing.T) {
+	t.Setenv("WSL_DISTRO_NAME", "Ubuntu")
+	users := t.TempDir()
+	old := wslUsersDir
+	wslUsersDir = users
+	t.Cleanup(func() { wslUsersDir = old })
+
+	legacy := filepath.Join(users, "martin", ".ccmonitor", "sessions")
+	local := filepath.Join(users, "martin", "AppData", "Local", "ccmonitor", "sessions")
+	for _, d := range []string{legacy, local} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	if got := wslWindowsDir(); got != local {
+		t.Errorf("got %q, want %q", got, local)
+	}
+}
+
+func TestMigrateDir(t *testing.T) {
+	t.Run("session files should be moved and the old dir removed", func(t *testing.T) {
+		src := filepath.Join(t.TempDir(), "old")
+		dst := filepath.Join(t.TempDir(), "new")
+		os.MkdirAll(src, 0755)
+		writeSessionFile(t, src, Session{SessionID: "s1"})
+		writeSessionFile(t, src, Session{SessionID: "s2"})
+
+		moved, err := migrateDir(src, dst)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if moved != 2 {
+			t.Errorf("moved %d files, want 2", moved)
+		}
+		sessions, _ := LoadAll(dst)
+		if len(sessions) != 2 {
+			t.Errorf("got %d sessions in new dir, want 2", len(sessions))
+		}
+		if _, err := os.Stat(src); !os.IsNotExist(err) {
+			t.Error("old dir should have been removed")
+		}
+	})
+
+	t.Run("existing file in destination should win", func(t *testing.T) {
+		src := t.TempDir()
+		dst := t.TempDir()
+		writeSessionFile(t, src, Session{SessionID: "s1", Status: "idle"})
+		writeSessionFile(t, dst, Session{SessionID: "s1", Status: "working"})
+
+		moved, err := migrateDir(src, dst)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if moved != 0 {
+			t.Errorf("moved %d files, want 0", moved)
+		}
+		s, err := LoadFile(filepath.Join(dst, "s1.json"))
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if s.Status != "working" {
+			t.Errorf("status = %q, want %q (destination copy should be kept)", s.Status, "working")
+		}
+	})
+
+	t.Run("missing source should be a no-op", func(t *testing.T) {
+		moved, err := migrateDir("/nonexistent/path", t.TempDir())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if moved != 0 {
+			t.Errorf("moved %d files, want 0", moved)
+		}
+	})
+
+	t.Run("same source and destination should be a no-op", func(t *testing.T) {
+		dir := t.TempDir()
+		writeSessionFile(t, dir, Session{SessionID: "s1"})
+		if moved, _ := migrateDir(dir, dir); moved != 0 {
+			t.Errorf("moved %d files, want 0", moved)
+		}
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"short string is unchanged", "hello", 10, "hello"},
+		{"exact length is unchanged", "hello", 5, "hello"},
+		{"ascii is cut at n", "hello world", 5, "hello"},
+		{"multi-byte runes are not split", "héllo wörld", 7, "héllo w"},
+		{"emoji counts as one rune", "🚀🚀🚀", 2, "🚀🚀"},
+		{"CJK is cut on rune boundary", "修复这个错误", 3, "修复这"},
+		{"zero length returns empty", "hello", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Truncate(tt.s, tt.n); got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelName(t *testing.T) {
+	tests := []struct{ id, want string }{
+		{"claude-sonnet-4-5-20250929", "sonnet-4-5"},
+		{"claude-opus-4-1-20250805", "opus-4-1"},
+		{"claude-3-5-haiku-20241022", "3-5-haiku"},
+		{"claude-opus-4-5", "opus-4-5"},
+		{"gpt-5-codex", "gpt-5-codex"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ModelName(tt.id); got != tt.want {
+			t.Errorf("ModelName(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
 }
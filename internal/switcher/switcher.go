@@ -2,6 +2,9 @@ package switcher
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/martinwickman/ccmonitor/internal/session"
 	"github.com/martinwickman/ccmonitor/internal/terminal"
@@ -32,3 +35,134 @@ func Switch(s session.Session) error {
 	}
 	return nil
 }
+
+// inputBackend returns the backend and ID of the innermost terminal of a
+// session, which is where its keyboard input goes. The hook adds WT first and
+// tmux second, so for tmux inside WT this is the tmux pane, which can be
+// written to without touching focus.
+func inputBackend(s session.Session) (terminal.Backend, string, error) {
+	for i := len(s.Terminals) - 1; i >= 0; i-- {
+		if b, ok := backends[s.Terminals[i].Backend]; ok {
+			return b, s.Terminals[i].ID, nil
+		}
+	}
+	return nil, "", fmt.Errorf("no terminal info available")
+}
+
+// Title looks up the current title of the session's tab or pane, preferring
+// the innermost terminal like the hook does. Returns "" when none has one.
+func Title(s session.Session) string {
+	for i := len(s.Terminals) - 1; i >= 0; i-- {
+		b, ok := backends[s.Terminals[i].Backend]
+		if !ok {
+			continue
+		}
+		if title := b.Title(s.Terminals[i].ID); title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+// SendText types text into the session's terminal and presses Enter,
+// as if the user had typed a prompt there.
+func SendText(s session.Session, text string) error {
+	b, id, err := inputBackend(s)
+	if err != nil {
+		return err
+	}
+	if err := b.SendText(id, text); err != nil {
+		return err
+	}
+	return b.SendKey(id, terminal.KeyEnter)
+}
+
+// Type types text into the session's terminal without pressing Enter,
+// e.g. to pick an option in a dialog.
+func Type(s session.Session, text string) error {
+	b, id, err := inputBackend(s)
+	if err != nil {
+		return err
+	}
+	return b.SendText(id, text)
+}
+
+// SendKey presses a single special key in the session's terminal.
+func SendKey(s session.Session, key terminal.Key) error {
+	b, id, err := inputBackend(s)
+	if err != nil {
+		return err
+	}
+	return b.SendKey(id, key)
+}
+
+// DefaultLaunchCommand is the command run by Launch when none is configured.
+const DefaultLaunchCommand = "claude"
+
+// launchers lists the backends Launch can open a new tab/window with, in
+// order of preference. Tmux comes first so that, when the monitor runs in
+// tmux inside WT, the new session lands next to it in the same tmux server.
+var launchers = []terminal.Backend{tmux.Backend{}, wt.Backend{}}
+
+// ExpandCommand fills in the placeholders of a launch command template:
+// {dir} is the full directory path and {project} its last element, each
+// quoted with quote as one word for the shell that runs the command, so
+// spaces, quotes, ";" or "$(...)" in a directory name are never run.
+func ExpandCommand(tmpl, dir string, quote func(string) string) string {
+	return strings.NewReplacer(
+		"{dir}", quote(dir),
+		"{project}", quote(filepath.Base(dir)),
+	).Replace(tmpl)
+}
+
+// shellQuote quotes s as one word for a POSIX shell (and fish), which tmux
+// runs commands with.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// powerShellQuote quotes s as one string for PowerShell, which runs the
+// commands of new WT tabs.
+func powerShellQuote(s string) string {
+	return "'" + powerShellQuotes.Replace(s) + "'"
+}
+
+// powerShellQuotes doubles the single quotes in a PowerShell string,
+// including the typographic ones PowerShell takes for quotes too.
+var powerShellQuotes = strings.NewReplacer(
+	"'", "''",
+	"\u2018", "\u2018\u2018",
+	"\u2019", "\u2019\u2019",
+	"\u201a", "\u201a\u201a",
+	"\u201b", "\u201b\u201b",
+)
+
+// launchQuote returns the quoting for the shell b runs launch commands with.
+func launchQuote(b terminal.Backend) func(string) string {
+	if _, ok := b.(wt.Backend); ok {
+		return powerShellQuote
+	}
+	return shellQuote
+}
+
+// Launch opens a new tab/window in dir, using the terminal the monitor itself
+// is running in, and starts the expanded command template there. The new
+// session shows up in the monitor once its SessionStart hook fires.
+func Launch(dir, tmpl string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if tmpl == "" {
+		tmpl = DefaultLaunchCommand
+	}
+	for _, b := range launchers {
+		if b.Available() {
+			return b.Launch(dir, ExpandCommand(tmpl, dir, launchQuote(b)))
+		}
+	}
+	return fmt.Errorf("no supported terminal (tmux or Windows Terminal) detected")
+}
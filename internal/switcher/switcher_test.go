@@ -1,6 +1,9 @@
 package switcher
 
 import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/martinwickman/ccmonitor/internal/session"
@@ -14,3 +17,95 @@ func TestSwitch(t *testing.T) {
 		}
 	})
 }
+
+func TestInputBackend(t *testing.T) {
+	t.Run("innermost terminal should receive input", func(t *testing.T) {
+		s := session.Session{Terminals: []session.Terminal{
+			{Backend: "wt", ID: "42,1,4,2"},
+			{Backend: "tmux", ID: "%3"},
+		}}
+		b, id, err := inputBackend(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if b.Name() != "tmux" || id != "%3" {
+			t.Errorf("got %s %q, want tmux %q", b.Name(), id, "%3")
+		}
+	})
+
+	t.Run("unknown backends should be skipped", func(t *testing.T) {
+		s := session.Session{Terminals: []session.Terminal{
+			{Backend: "wt", ID: "42,1,4,2"},
+			{Backend: "kitty", ID: "7"},
+		}}
+		b, _, err := inputBackend(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if b.Name() != "wt" {
+			t.Errorf("got %s, want wt", b.Name())
+		}
+	})
+
+	t.Run("session without terminals should return an error", func(t *testing.T) {
+		if err := SendText(session.Session{}, "hi"); err == nil {
+			t.Error("expected error for empty session, got nil")
+		}
+	})
+}
+
+func TestExpandCommand(t *testing.T) {
+	dir := filepath.Join("home", "me", "src", "api")
+	tests := []struct {
+		name string
+		tmpl string
+		dir  string
+		want string
+	}{
+		{"no placeholders should be left unchanged", "claude", dir, "claude"},
+		{"dir placeholder should expand to the quoted path", "claude --add-dir {dir}", dir, "claude --add-dir '" + dir + "'"},
+		{"project placeholder should expand to the quoted base name", "claude -n {project}", dir, "claude -n 'api'"},
+		{"quotes in the directory should be escaped", "claude -n {project}", "it's", `claude -n 'it'\''s'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandCommand(tt.tmpl, tt.dir, shellQuote); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("a directory name should not run in a shell", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("needs sh")
+		}
+		for _, name := range []string{"a b", "x; touch pwned", "$(touch pwned)", "`touch pwned`", "it's", `"q"`} {
+			out, err := exec.Command("sh", "-c", ExpandCommand("printf %s {project}", name, shellQuote)).Output()
+			if err != nil || string(out) != name {
+				t.Errorf("%q: got %q, %v, want it printed as it is", name, out, err)
+			}
+		}
+	})
+
+	t.Run("PowerShell quotes should be doubled", func(t *testing.T) {
+		if got := ExpandCommand("claude -n {project}", "it's \u2019x", powerShellQuote); got != "claude -n 'it''s \u2019\u2019x'" {
+			t.Errorf("got %q", got)
+		}
+	})
+}
+
+func TestLaunch(t *testing.T) {
+	t.Run("missing directory should return an error", func(t *testing.T) {
+		if err := Launch(filepath.Join(t.TempDir(), "nope"), "claude"); err == nil {
+			t.Error("expected error for missing directory, got nil")
+		}
+	})
+
+	t.Run("no terminal backend should return an error", func(t *testing.T) {
+		t.Setenv("TMUX_PANE", "")
+		t.Setenv("WT_SESSION", "")
+		if err := Launch(t.TempDir(), "claude"); err == nil {
+			t.Error("expected error without tmux or WT, got nil")
+		}
+	})
+}
@@ -13,8 +13,20 @@ type Backend interface {
 	Info() (id, title string)  // Discover current tab/pane
 	Title(id string) string    // Refresh title for known ID
 	Select(id string) error    // Switch focus to tab/pane
+	Launch(dir, command string) error // Open a new tab/window in dir running command
+	SendText(id, text string) error   // Type literal text into tab/pane
+	SendKey(id string, key Key) error // Press a special key in tab/pane
 }
 
+// Key names a special (non-text) key that can be sent to a tab/pane.
+// Values are tmux key names; other backends map them to their own syntax.
+type Key string
+
+const (
+	KeyEnter  Key = "Enter"
+	KeyEscape Key = "Escape"
+)
+
 // StripTitlePrefix removes leading non-alphanumeric characters from a tab/pane
 // title. Claude Code prefixes titles with status indicators like "✳ " but the
 // exact character varies by platform and encoding.
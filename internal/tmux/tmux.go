@@ -2,10 +2,13 @@
 package tmux
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/martinwickman/ccmonitor/internal/terminal"
 )
@@ -28,13 +31,7 @@ func (Backend) Info() (paneID, title string) {
 	if paneID == "" {
 		return "", ""
 	}
-	out, err := exec.Command("tmux", "display-message", "-p", "-t", paneID, "#{pane_title}").Output()
-	if err != nil {
-		return paneID, ""
-	}
-	title = strings.TrimSpace(string(out))
-	title = terminal.StripTitlePrefix(title)
-	return paneID, title
+	return paneID, paneTitle(paneID)
 }
 
 // Title refreshes the title for a known tmux pane ID.
@@ -43,19 +40,90 @@ func (Backend) Title(paneID string) string {
 	if paneID == "" {
 		return ""
 	}
-	out, err := exec.Command("tmux", "display-message", "-p", "-t", paneID, "#{pane_title}").Output()
+	return paneTitle(paneID)
+}
+
+// queryTimeout bounds title queries, which the hook makes while Claude waits
+// for it; a wedged tmux server must not hold up the session.
+const queryTimeout = 300 * time.Millisecond
+
+// paneTitle asks tmux for a pane's title, with its prefix stripped. Returns
+// empty string on error or timeout.
+func paneTitle(paneID string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "tmux", "display-message", "-p", "-t", paneID, "#{pane_title}").Output()
 	if err != nil {
 		return ""
 	}
-	title := strings.TrimSpace(string(out))
-	return terminal.StripTitlePrefix(title)
+	return terminal.StripTitlePrefix(strings.TrimSpace(string(out)))
 }
 
-// Select switches focus to the given tmux pane.
+// command builds a tmux command for acting on a pane.
 // On Windows, tmux is accessed via WSL.
-func (Backend) Select(paneID string) error {
+func command(args ...string) *exec.Cmd {
 	if runtime.GOOS == "windows" {
-		return exec.Command("wsl", "tmux", "select-pane", "-t", paneID).Run()
+		return exec.Command("wsl", append([]string{"tmux"}, args...)...)
+	}
+	return exec.Command("tmux", args...)
+}
+
+// Select switches focus to the given tmux pane.
+func (Backend) Select(paneID string) error {
+	return command("select-pane", "-t", paneID).Run()
+}
+
+// SendText types text into the given tmux pane. The text is sent literally,
+// so words like "Enter" are not interpreted as key names, and after "--", so
+// text starting with a dash is not taken for options.
+func (Backend) SendText(paneID, text string) error {
+	return sendKeys(paneID, "-l", "--", text)
+}
+
+// SendKey presses a special key in the given tmux pane.
+func (Backend) SendKey(paneID string, key terminal.Key) error {
+	return sendKeys(paneID, string(key))
+}
+
+func sendKeys(paneID string, args ...string) error {
+	out, err := command(append([]string{"send-keys", "-t", paneID}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("sending keys to tmux pane: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// borderOptions are the pane options set by SetBorder.
+var borderOptions = []string{"pane-border-style", "pane-active-border-style"}
+
+// SetBorder sets the border style of a single pane (e.g. "fg=green"),
+// overriding the window's style. Pane options need tmux 3.1 or later.
+func SetBorder(paneID, style string) error {
+	for _, opt := range borderOptions {
+		if out, err := command("set-option", "-p", "-t", paneID, opt, style).CombinedOutput(); err != nil {
+			return fmt.Errorf("setting tmux %s: %s: %w", opt, strings.TrimSpace(string(out)), err)
+		}
+	}
+	return nil
+}
+
+// ResetBorder removes the pane's own border style so it inherits the
+// window's again.
+func ResetBorder(paneID string) error {
+	for _, opt := range borderOptions {
+		if out, err := command("set-option", "-p", "-u", "-t", paneID, opt).CombinedOutput(); err != nil {
+			return fmt.Errorf("resetting tmux %s: %s: %w", opt, strings.TrimSpace(string(out)), err)
+		}
+	}
+	return nil
+}
+
+// Launch opens a new tmux window in dir and runs cmdline in it. tmux hands
+// the command to the user's shell, so pipes and quoting work as typed.
+func (Backend) Launch(dir, cmdline string) error {
+	out, err := command("new-window", "-c", dir, cmdline).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("opening tmux window: %s: %w", strings.TrimSpace(string(out)), err)
 	}
-	return exec.Command("tmux", "select-pane", "-t", paneID).Run()
+	return nil
 }
@@ -0,0 +1,53 @@
+package tmux
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startPane starts a detached tmux session on a private server, echoing what
+// is typed into its pane, and returns the pane ID.
+func startPane(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+	t.Setenv("TMUX_TMPDIR", t.TempDir())
+	t.Setenv("TMUX", "")
+	out, err := exec.Command("tmux", "new-session", "-d", "-P", "-F", "#{pane_id}", "cat").Output()
+	if err != nil {
+		t.Skipf("starting tmux: %v", err)
+	}
+	t.Cleanup(func() { exec.Command("tmux", "kill-server").Run() })
+	return strings.TrimSpace(string(out))
+}
+
+// paneText returns what the pane shows once it contains want, or after a
+// second.
+func paneText(t *testing.T, paneID, want string) string {
+	t.Helper()
+	var text string
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		out, _ := exec.Command("tmux", "capture-pane", "-p", "-t", paneID).Output()
+		if text = string(out); strings.Contains(text, want) {
+			break
+		}
+	}
+	return text
+}
+
+func TestSendText(t *testing.T) {
+	for _, text := range []string{"fix the tests", "-v", "--help", "Enter"} {
+		t.Run(text, func(t *testing.T) {
+			pane := startPane(t)
+			if err := (Backend{}).SendText(pane, text); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := paneText(t, pane, text); !strings.Contains(got, text) {
+				t.Errorf("pane shows %q, want %q typed", got, text)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package transcript
+
+import "strings"
+
+// Message is one entry of the conversation in a transcript, for reading it
+// from the monitor.
+type Message struct {
+	Role string // RoleUser, RoleAssistant or RoleTools
+	Text string
+}
+
+// Message roles. RoleTools lists the tools Claude ran in a row, e.g.
+// "Read, Bash".
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTools     = "tools"
+)
+
+// Messages returns the last n messages of the conversation in the transcript
+// at path, oldest first: what the user typed, what Claude answered and the
+// tools it ran in between. Subagents and Claude Code's own messages are left
+// out. Only the end of long transcripts is read; n <= 0 returns all of that.
+func Messages(path string, n int) ([]Message, error) {
+	entries, err := readTail(path)
+	if err != nil {
+		return nil, err
+	}
+	msgs := messages(entries)
+	if n > 0 && len(msgs) > n {
+		msgs = msgs[len(msgs)-n:]
+	}
+	return msgs, nil
+}
+
+func messages(entries []entry) []Message {
+	var msgs []Message
+	add := func(role, text string) {
+		if last := len(msgs) - 1; role == RoleTools && last >= 0 && msgs[last].Role == RoleTools {
+			msgs[last].Text += ", " + text
+			return
+		}
+		msgs = append(msgs, Message{Role: role, Text: text})
+	}
+	for _, e := range entries {
+		if e.IsSidechain {
+			continue
+		}
+		switch e.Type {
+		case "user":
+			if prompt := promptText(e.Message.Content); prompt != "" && !e.IsMeta {
+				add(RoleUser, prompt)
+			}
+		case "assistant":
+			// A streamed answer is logged as one entry per content block.
+			for _, b := range blocks(e.Message.Content) {
+				switch {
+				case b.Type == "text" && strings.TrimSpace(b.Text) != "":
+					add(RoleAssistant, strings.TrimSpace(b.Text))
+				case b.Type == "tool_use":
+					add(RoleTools, b.Name)
+				}
+			}
+		}
+	}
+	return msgs
+}
@@ -0,0 +1,39 @@
+package transcript
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMessages(t *testing.T) {
+	const readLine = `{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","name":"Read"}]}}`
+	dir := t.TempDir()
+	writeTranscript(t, dir, "abc123", time.Now(),
+		summaryLine, metaLine, userLine, toolUseLine, toolResult, readLine, sidechainLine, toolResult, answerLine)
+	path := filepath.Join(dir, "-home-user-project", "abc123.jsonl")
+
+	got, err := Messages(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Message{
+		{Role: RoleUser, Text: "fix the tests"},
+		{Role: RoleAssistant, Text: "Running them."},
+		{Role: RoleTools, Text: "Bash, Read"},
+		{Role: RoleAssistant, Text: "All green."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got, _ = Messages(path, 2)
+	if !reflect.DeepEqual(got, want[2:]) {
+		t.Errorf("last 2: got %+v, want %+v", got, want[2:])
+	}
+
+	if _, err := Messages(filepath.Join(dir, "missing.jsonl"), 0); err == nil {
+		t.Error("a missing transcript should be an error")
+	}
+}
@@ -0,0 +1,297 @@
+// Package transcript reconstructs approximate session state from the
+// transcripts Claude Code writes under ~/.claude/projects, for when the
+// hooks aren't installed. Without hooks there is no PID, no terminal and no
+// notification, so a session is only ever starting, working, idle or error.
+// The hook reads transcripts too, for what hook events don't report.
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/gitinfo"
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// Recent is how long after its last write a transcript still counts as a
+// session. Older ones are assumed to belong to sessions that have ended.
+const Recent = 6 * time.Hour
+
+// activeWindow is how recently a transcript must have been written for a
+// session without a final answer to count as working.
+const activeWindow = 2 * time.Minute
+
+// tailSize is how much of the end of a transcript is read. Transcripts grow
+// to many megabytes; the state is decided by the last few entries.
+const tailSize = 256 * 1024
+
+// Dir returns the directory Claude Code keeps its transcripts in:
+// $CLAUDE_CONFIG_DIR/projects, or ~/.claude/projects.
+func Dir() string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "projects")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".claude", "projects")
+}
+
+// LoadAll returns a session for every transcript in dir (one subdirectory
+// per project) written within Recent of now. Unreadable transcripts are
+// skipped; a missing dir yields no sessions.
+func LoadAll(dir string, now time.Time) ([]session.Session, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	var sessions []session.Session
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || now.Sub(info.ModTime()) > Recent {
+			continue
+		}
+		s, err := load(path, info.ModTime(), now)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// entry is the part of a transcript line that state is derived from.
+type entry struct {
+	Type        string `json:"type"`      // "user", "assistant", or bookkeeping such as "summary"
+	Subtype     string `json:"subtype"`   // for "system": e.g. "compact_boundary"
+	Operation   string `json:"operation"` // for "queue-operation": "enqueue", "dequeue", "remove", "popAll"
+	Timestamp   string `json:"timestamp"`
+	Cwd         string `json:"cwd"`
+	IsSidechain bool   `json:"isSidechain"`       // subagent traffic
+	IsMeta      bool   `json:"isMeta"`            // injected by Claude Code, not typed by the user
+	IsAPIError  bool   `json:"isApiErrorMessage"` // assistant entry that is an API error, not an answer
+	Message     struct {
+		ID      string          `json:"id"` // for "assistant"; shared by the entries of a streamed message
+		Content json.RawMessage `json:"content"`
+		Model   string          `json:"model"` // for "assistant"
+		Usage   *tokenCounts    `json:"usage"` // for "assistant"
+	} `json:"message"`
+}
+
+// block is one element of a message's content array.
+type block struct {
+	Type string `json:"type"` // "text", "tool_use", "tool_result", ...
+	Text string `json:"text"`
+	Name string `json:"name"` // tool name, for tool_use
+}
+
+func load(path string, modTime, now time.Time) (session.Session, error) {
+	entries, err := readTail(path)
+	if err != nil {
+		return session.Session{}, err
+	}
+	s := session.Session{
+		SessionID:      strings.TrimSuffix(filepath.Base(path), ".jsonl"),
+		Status:         session.StatusStarting,
+		Detail:         "Session started",
+		LastActivity:   modTime.UTC().Format(time.RFC3339),
+		TranscriptPath: path,
+	}
+	for _, e := range entries {
+		if e.Cwd != "" {
+			s.Project = e.Cwd
+		}
+		if e.Type == "system" && e.Subtype == "compact_boundary" {
+			if t, err := time.Parse(time.RFC3339, e.Timestamp); err == nil {
+				s.Compacted = t.UTC().Format(time.RFC3339)
+			}
+		}
+		if e.Type == "user" && !e.IsMeta && !e.IsSidechain {
+			if prompt := promptText(e.Message.Content); prompt != "" {
+				s.LastPrompt = prompt
+			}
+		}
+	}
+	if s.Project == "" {
+		return session.Session{}, os.ErrNotExist // nothing but bookkeeping yet
+	}
+	if info, ok := gitinfo.Lookup(s.Project); ok {
+		s.Git = &session.Git{Repo: info.Repo, Worktree: info.Worktree, Branch: info.Branch}
+	}
+	s.Model = lastModel(entries)
+	s.Status, s.Detail = state(entries, now.Sub(modTime) <= activeWindow)
+	if s.Status == session.StatusWorking {
+		s.Queued = queued(entries)
+	}
+	return s, nil
+}
+
+// state derives the status from the last conversation entry: a final
+// assistant answer means idle; anything else means Claude is still at it,
+// as long as the transcript is still being written.
+func state(entries []entry, active bool) (status, detail string) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if !conversation(e) {
+			continue
+		}
+		var tool string
+		for _, b := range blocks(e.Message.Content) {
+			if b.Type == "tool_use" {
+				tool = b.Name
+			}
+		}
+		switch {
+		case e.IsAPIError:
+			return session.StatusError, errorText(e.Message.Content)
+		case e.Type == "assistant" && tool == "":
+			return session.StatusIdle, "Finished responding"
+		case !active:
+			return session.StatusIdle, "No recent activity"
+		case tool != "":
+			return session.StatusWorking, "Running " + tool
+		default:
+			return session.StatusWorking, "Processing prompt..."
+		}
+	}
+	return session.StatusStarting, "Session started"
+}
+
+// lastModel returns the model of the last answer in entries, or "".
+func lastModel(entries []entry) string {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if m := answerModel(entries[i]); m != "" {
+			return m
+		}
+	}
+	return ""
+}
+
+// answerModel returns the model of e when it is an answer of the main
+// agent: subagents may run on another model, and the "<synthetic>" messages
+// Claude Code writes itself are no answer.
+func answerModel(e entry) string {
+	if e.Type == "assistant" && !e.IsSidechain && e.Message.Model != "<synthetic>" {
+		return e.Message.Model
+	}
+	return ""
+}
+
+// conversation reports whether e is part of the main conversation, as
+// opposed to subagent traffic and bookkeeping.
+func conversation(e entry) bool {
+	return !e.IsSidechain && (e.Type == "user" || e.Type == "assistant")
+}
+
+// Queued returns how many messages the user typed while Claude was working
+// are waiting for it, or 0 when the transcript can't be read.
+func Queued(path string) int {
+	if path == "" {
+		return 0
+	}
+	entries, err := readTail(path)
+	if err != nil {
+		return 0
+	}
+	return queued(entries)
+}
+
+// queued replays the queue operations Claude Code logs for messages typed
+// while it works: each is enqueued, then dequeued when Claude takes it, or
+// removed (popAll) when the user pulls it back into the prompt to edit.
+func queued(entries []entry) int {
+	n := 0
+	for _, e := range entries {
+		if e.Type != "queue-operation" {
+			continue
+		}
+		switch e.Operation {
+		case "enqueue":
+			n++
+		case "dequeue", "remove":
+			n = max(0, n-1) // enqueued before the tail that was read
+		case "popAll":
+			n = 0
+		}
+	}
+	return n
+}
+
+// errorText returns the first line of an API error message, e.g.
+// "API Error: 529 Overloaded".
+func errorText(content json.RawMessage) string {
+	var text string
+	for _, b := range blocks(content) {
+		if b.Type == "text" {
+			text = b.Text
+			break
+		}
+	}
+	text, _, _ = strings.Cut(strings.TrimSpace(text), "\n")
+	if text == "" {
+		return "API error"
+	}
+	return session.Truncate(text, 120)
+}
+
+// promptText returns what the user typed, or "" for tool results and
+// Claude Code's own command and reminder messages.
+func promptText(content json.RawMessage) string {
+	var text string
+	if json.Unmarshal(content, &text) != nil {
+		for _, b := range blocks(content) {
+			if b.Type == "tool_result" {
+				return ""
+			}
+			if b.Type == "text" {
+				text = b.Text
+			}
+		}
+	}
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "<") {
+		return ""
+	}
+	return text
+}
+
+func blocks(content json.RawMessage) []block {
+	var bs []block
+	json.Unmarshal(content, &bs)
+	return bs
+}
+
+// readTail parses the entries in the last tailSize bytes of a transcript,
+// dropping the first line when it was cut off.
+func readTail(path string) ([]entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	start := max(0, info.Size()-tailSize)
+	data, err := io.ReadAll(io.NewSectionReader(f, start, info.Size()-start))
+	if err != nil {
+		return nil, err
+	}
+	if start > 0 {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	var entries []entry
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var e entry
+		if json.Unmarshal(line, &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
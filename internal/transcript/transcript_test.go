@@ -0,0 +1,229 @@
+package transcript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	userLine      = `{"type":"user","cwd":"/home/user/project","message":{"role":"user","content":"fix the tests"}}`
+	metaLine      = `{"type":"user","cwd":"/home/user/project","isMeta":true,"message":{"role":"user","content":"<command-name>/clear</command-name>"}}`
+	toolUseLine   = `{"type":"assistant","cwd":"/home/user/project","message":{"role":"assistant","content":[{"type":"text","text":"Running them."},{"type":"tool_use","name":"Bash"}]}}`
+	toolResult    = `{"type":"user","cwd":"/home/user/project","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}`
+	answerLine    = `{"type":"assistant","cwd":"/home/user/project","message":{"role":"assistant","content":[{"type":"text","text":"All green."}]}}`
+	sidechainLine = `{"type":"assistant","cwd":"/home/user/project","isSidechain":true,"message":{"role":"assistant","content":[{"type":"tool_use","name":"Grep"}]}}`
+	summaryLine   = `{"type":"summary","summary":"Fixing tests"}`
+	enqueueLine   = `{"type":"queue-operation","operation":"enqueue","content":"and the lint"}`
+	dequeueLine   = `{"type":"queue-operation","operation":"dequeue"}`
+	popAllLine    = `{"type":"queue-operation","operation":"popAll"}`
+	apiErrorLine  = `{"type":"assistant","cwd":"/home/user/project","isApiErrorMessage":true,"message":{"role":"assistant","content":[{"type":"text","text":"API Error: 529 Overloaded\n{\"type\":\"error\"}"}]}}`
+)
+
+// writeTranscript writes lines as a transcript last modified at modTime.
+func writeTranscript(t *testing.T, dir, id string, modTime time.Time, lines ...string) {
+	t.Helper()
+	project := filepath.Join(dir, "-home-user-project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(project, id+".jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		age        time.Duration
+		lines      []string
+		wantStatus string
+		wantDetail string
+	}{
+		{"final answer is idle", time.Second, []string{userLine, toolUseLine, toolResult, answerLine}, "idle", "Finished responding"},
+		{"pending tool is working", time.Second, []string{userLine, toolUseLine}, "working", "Running Bash"},
+		{"tool result is working", time.Second, []string{userLine, toolUseLine, toolResult}, "working", "Processing prompt..."},
+		{"prompt is working", time.Second, []string{answerLine, userLine}, "working", "Processing prompt..."},
+		{"subagent traffic is skipped", time.Second, []string{userLine, answerLine, sidechainLine, summaryLine}, "idle", "Finished responding"},
+		{"quiet transcript is idle", 10 * time.Minute, []string{userLine, toolUseLine}, "idle", "No recent activity"},
+		{"api error is an error", 10 * time.Minute, []string{userLine, apiErrorLine}, "error", "API Error: 529 Overloaded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTranscript(t, dir, "abc123", now.Add(-tt.age), tt.lines...)
+
+			sessions, err := LoadAll(dir, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(sessions) != 1 {
+				t.Fatalf("got %d sessions, want 1", len(sessions))
+			}
+			s := sessions[0]
+			if s.Status != tt.wantStatus || s.Detail != tt.wantDetail {
+				t.Errorf("got %s %q, want %s %q", s.Status, s.Detail, tt.wantStatus, tt.wantDetail)
+			}
+		})
+	}
+
+	t.Run("session fields come from the filename and entries", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTranscript(t, dir, "abc123", now, userLine, metaLine, toolResult, answerLine)
+
+		sessions, _ := LoadAll(dir, now)
+		if len(sessions) != 1 {
+			t.Fatalf("got %d sessions, want 1", len(sessions))
+		}
+		s := sessions[0]
+		if s.SessionID != "abc123" {
+			t.Errorf("SessionID = %q, want abc123", s.SessionID)
+		}
+		if s.Project != "/home/user/project" {
+			t.Errorf("Project = %q, want /home/user/project", s.Project)
+		}
+		if s.LastPrompt != "fix the tests" {
+			t.Errorf("LastPrompt = %q, want the typed prompt", s.LastPrompt)
+		}
+	})
+
+	t.Run("compact boundary should set when the conversation was compacted", func(t *testing.T) {
+		dir := t.TempDir()
+		boundary := `{"type":"system","subtype":"compact_boundary","timestamp":"2026-03-10T13:00:00.123Z"}`
+		writeTranscript(t, dir, "abc123", now, userLine, boundary, answerLine)
+
+		sessions, _ := LoadAll(dir, now)
+		if len(sessions) != 1 || sessions[0].Compacted != "2026-03-10T13:00:00Z" {
+			t.Errorf("got %+v, want compacted at 2026-03-10T13:00:00Z", sessions)
+		}
+	})
+
+	t.Run("old transcripts and ones without a cwd are skipped", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTranscript(t, dir, "old", now.Add(-Recent-time.Minute), userLine, answerLine)
+		writeTranscript(t, dir, "empty", now, summaryLine)
+
+		sessions, err := LoadAll(dir, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sessions) != 0 {
+			t.Errorf("got %d sessions, want 0", len(sessions))
+		}
+	})
+
+	t.Run("missing directory yields no sessions", func(t *testing.T) {
+		sessions, err := LoadAll(filepath.Join(t.TempDir(), "missing"), now)
+		if err != nil || len(sessions) != 0 {
+			t.Errorf("got %v, %v; want no sessions and no error", sessions, err)
+		}
+	})
+}
+
+func TestReadTurnAPIError(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{"turn ended in an api error", []string{userLine, apiErrorLine, summaryLine}, "API Error: 529 Overloaded"},
+		{"retried after the error", []string{userLine, apiErrorLine, userLine, answerLine}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTranscript(t, dir, "abc123", time.Now(), tt.lines...)
+			path := filepath.Join(dir, "-home-user-project", "abc123.jsonl")
+			if got := ReadTurn(path, nil).APIError; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("an error in an earlier turn is not read again", func(t *testing.T) {
+		writeTranscript(t, dir, "abc123", time.Now(), userLine, apiErrorLine)
+		path := filepath.Join(dir, "-home-user-project", "abc123.jsonl")
+		u := ReadTurn(path, nil).Usage
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString(summaryLine + "\n")
+		f.Close()
+		if got := ReadTurn(path, u).APIError; got != "" {
+			t.Errorf("got %q, want none", got)
+		}
+	})
+
+	t.Run("missing transcript has no error", func(t *testing.T) {
+		if got := ReadTurn(filepath.Join(dir, "missing.jsonl"), nil).APIError; got != "" {
+			t.Errorf("got %q, want none", got)
+		}
+	})
+}
+
+func TestReadTurnModel(t *testing.T) {
+	const (
+		sonnet    = `{"type":"assistant","cwd":"/home/user/project","message":{"model":"claude-sonnet-4-5-20250929","content":[{"type":"text","text":"Done."}]}}`
+		haikuSide = `{"type":"assistant","cwd":"/home/user/project","isSidechain":true,"message":{"model":"claude-haiku-4-5","content":[]}}`
+		synthetic = `{"type":"assistant","cwd":"/home/user/project","message":{"model":"<synthetic>","content":[]}}`
+	)
+	dir := t.TempDir()
+	writeTranscript(t, dir, "abc123", time.Now(), userLine, sonnet, haikuSide, synthetic)
+	path := filepath.Join(dir, "-home-user-project", "abc123.jsonl")
+	if got := ReadTurn(path, nil).Model; got != "claude-sonnet-4-5-20250929" {
+		t.Errorf("got %q, want the main agent's model", got)
+	}
+	if got := ReadTurn(filepath.Join(dir, "missing.jsonl"), nil).Model; got != "" {
+		t.Errorf("missing transcript: got %q, want none", got)
+	}
+}
+
+func TestQueued(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name  string
+		lines []string
+		want  int
+	}{
+		{"nothing queued", []string{userLine, toolUseLine}, 0},
+		{"two messages typed while working", []string{userLine, toolUseLine, enqueueLine, toolResult, enqueueLine}, 2},
+		{"taken by Claude", []string{userLine, enqueueLine, enqueueLine, dequeueLine}, 1},
+		{"pulled back to edit", []string{userLine, enqueueLine, enqueueLine, popAllLine}, 0},
+		{"dequeue of a message before the tail", []string{dequeueLine, enqueueLine}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTranscript(t, dir, "abc123", time.Now(), tt.lines...)
+			path := filepath.Join(dir, "-home-user-project", "abc123.jsonl")
+			if got := Queued(path); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadTail(t *testing.T) {
+	t.Run("cut-off first line is dropped", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "long.jsonl")
+		padding := `{"type":"user","message":{"content":"` + strings.Repeat("x", tailSize) + `"}}`
+		if err := os.WriteFile(path, []byte(padding+"\n"+answerLine+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := readTail(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(entries) != 1 || entries[0].Type != "assistant" {
+			t.Errorf("got %+v, want only the final answer", entries)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+type tokenCounts struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// Turn is what the hook reads from the transcript at the end of a turn.
+type Turn struct {
+	Usage    *session.Usage // with the turn's usage added
+	APIError string         // when the turn ended in an API error (overloaded, network failure)
+	Model    string         // of the turn's last answer, or ""
+}
+
+// ReadTurn reads what was written to the transcript at path since u was
+// last updated, in one pass: u plus the usage of the new assistant messages
+// with their estimated cost, the text of the last conversation entry when it
+// is an API error, and the model of the last answer. Only complete lines are
+// read; a line still being written is left for the next call. When the
+// transcript can't be read the usage is u and the rest is empty.
+func ReadTurn(path string, u *session.Usage) Turn {
+	turn := Turn{Usage: u}
+	if path == "" {
+		return turn
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return turn
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return turn
+	}
+	var out session.Usage
+	if u != nil {
+		out = *u
+	}
+	if info.Size() < out.TranscriptOffset {
+		out = session.Usage{} // a different transcript: count it from the start
+	}
+	if _, err := f.Seek(out.TranscriptOffset, io.SeekStart); err != nil {
+		return turn
+	}
+
+	// A streamed message is logged as one entry per content block, each
+	// with the message's usage; the last one counts.
+	type counted struct {
+		model  string
+		counts tokenCounts
+	}
+	messages := map[string]counted{}
+	var order []string
+	var last entry // the last conversation entry
+	r := bufio.NewReader(f)
+	for n := 0; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			break // EOF, possibly in the middle of a line
+		}
+		out.TranscriptOffset += int64(len(line))
+		var e entry
+		if json.Unmarshal(line, &e) != nil {
+			continue
+		}
+		if conversation(e) {
+			last = e
+		}
+		if m := answerModel(e); m != "" {
+			turn.Model = m
+		}
+		if e.Type != "assistant" || e.Message.Usage == nil {
+			continue
+		}
+		id := e.Message.ID
+		if id == "" {
+			id = "line " + strconv.Itoa(n) // can't be matched up, so counts on its own
+		}
+		if _, ok := messages[id]; !ok {
+			order = append(order, id)
+		}
+		messages[id] = counted{e.Message.Model, *e.Message.Usage}
+	}
+	for _, id := range order {
+		m := messages[id]
+		c := m.counts
+		out.InputTokens += c.InputTokens
+		out.OutputTokens += c.OutputTokens
+		out.CacheCreationTokens += c.CacheCreationInputTokens
+		out.CacheReadTokens += c.CacheReadInputTokens
+		out.CostUSD += cost(m.model, c)
+	}
+	if last.IsAPIError {
+		turn.APIError = errorText(last.Message.Content)
+	}
+	turn.Usage = &out
+	return turn
+}
+
+// cost estimates what c costs on model at list prices, per million tokens.
+// Cache writes cost 1.25 times the input price and cache reads a tenth.
+// Unknown models, like the "<synthetic>" entries Claude Code writes itself,
+// cost nothing.
+func cost(model string, c tokenCounts) float64 {
+	input, output := price(model)
+	return (float64(c.InputTokens)*input +
+		float64(c.CacheCreationInputTokens)*input*1.25 +
+		float64(c.CacheReadInputTokens)*input*0.1 +
+		float64(c.OutputTokens)*output) / 1e6
+}
+
+// price returns the input and output price of model in dollars per million
+// tokens.
+func price(model string) (input, output float64) {
+	switch {
+	case strings.Contains(model, "opus-4-1"), strings.HasPrefix(model, "claude-opus-4-2"), strings.Contains(model, "3-opus"):
+		return 15, 75
+	case strings.Contains(model, "opus"):
+		return 5, 25
+	case strings.Contains(model, "sonnet"):
+		return 3, 15
+	case strings.Contains(model, "3-5-haiku"):
+		return 0.8, 4
+	case strings.Contains(model, "3-haiku"):
+		return 0.25, 1.25
+	case strings.Contains(model, "haiku"):
+		return 1, 5
+	}
+	return 0, 0
+}
@@ -0,0 +1,63 @@
+package transcript
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadTurn(t *testing.T) {
+	const (
+		// One streamed message logged as two entries, then a second message.
+		first  = `{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5-20250929","usage":{"input_tokens":100,"output_tokens":10,"cache_read_input_tokens":1000}}}`
+		again  = `{"type":"assistant","message":{"id":"msg_1","model":"claude-sonnet-4-5-20250929","usage":{"input_tokens":100,"output_tokens":50,"cache_read_input_tokens":1000}}}`
+		second = `{"type":"assistant","message":{"id":"msg_2","model":"claude-opus-4-1-20250805","usage":{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":400}}}`
+		synth  = `{"type":"assistant","message":{"id":"msg_3","model":"<synthetic>","usage":{"input_tokens":0,"output_tokens":0}}}`
+	)
+	dir := t.TempDir()
+	writeTranscript(t, dir, "abc123", time.Now(), userLine, first, again, toolResult)
+	path := filepath.Join(dir, "-home-user-project", "abc123.jsonl")
+
+	u := ReadTurn(path, nil).Usage
+	if u == nil || u.InputTokens != 100 || u.OutputTokens != 50 || u.CacheReadTokens != 1000 {
+		t.Fatalf("got %+v, want msg_1 counted once with its last usage", u)
+	}
+	// Sonnet: 100×$3 + 1000×$0.30 + 50×$15 per million.
+	if want := (300 + 300 + 750) / 1e6; math.Abs(u.CostUSD-want) > 1e-9 {
+		t.Errorf("cost = %v, want %v", u.CostUSD, want)
+	}
+
+	// The next turn only adds what was written since; a partial last line
+	// waits for the next call.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(second + "\n" + synth + "\n" + `{"type":"assist`)
+	f.Close()
+	turn := ReadTurn(path, u)
+	if turn.Model != "claude-opus-4-1-20250805" {
+		t.Errorf("model = %q, want the last answer's", turn.Model)
+	}
+	next := turn.Usage
+	if next.InputTokens != 110 || next.OutputTokens != 70 || next.CacheCreationTokens != 400 {
+		t.Errorf("got %+v, want msg_2 added", next)
+	}
+	// Opus 4.1: 10×$15 + 400×$18.75 + 20×$75 per million.
+	if want := u.CostUSD + (150+7500+1500)/1e6; math.Abs(next.CostUSD-want) > 1e-9 {
+		t.Errorf("cost = %v, want %v", next.CostUSD, want)
+	}
+	info, _ := os.Stat(path)
+	if next.TranscriptOffset != info.Size()-int64(len(`{"type":"assist`)) {
+		t.Errorf("offset = %d, want the end of the last complete line", next.TranscriptOffset)
+	}
+	if u.InputTokens != 100 {
+		t.Error("ReadTurn should not modify its argument")
+	}
+
+	if got := ReadTurn(filepath.Join(dir, "missing.jsonl"), u); got != (Turn{Usage: u}) {
+		t.Errorf("unreadable transcript: got %+v, want the usage unchanged", got)
+	}
+}
@@ -0,0 +1,49 @@
+package tray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+)
+
+// iconSize is the width and height of the generated icon in pixels.
+const iconSize = 32
+
+// icon draws a filled circle in c: a PNG, wrapped in an ICO container on
+// Windows, which only takes icons in that format.
+func icon(c color.RGBA) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, iconSize, iconSize))
+	r := float64(iconSize)/2 - 1
+	for y := 0; y < iconSize; y++ {
+		for x := 0; x < iconSize; x++ {
+			dx, dy := float64(x)+0.5-iconSize/2, float64(y)+0.5-iconSize/2
+			if dx*dx+dy*dy <= r*r {
+				img.Set(x, y, c)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	if runtime.GOOS == "windows" {
+		return wrapICO(buf.Bytes())
+	}
+	return buf.Bytes()
+}
+
+// wrapICO puts a PNG image into a single-image ICO file.
+func wrapICO(pngData []byte) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, struct {
+		Reserved, Type, Count uint16
+	}{0, 1, 1})
+	binary.Write(&buf, binary.LittleEndian, struct {
+		Width, Height, Colors, Reserved uint8
+		Planes, BitCount                uint16
+		Size, Offset                    uint32
+	}{iconSize, iconSize, 0, 0, 1, 32, uint32(len(pngData)), 6 + 16})
+	buf.Write(pngData)
+	return buf.Bytes()
+}
@@ -0,0 +1,137 @@
+// Package tray implements "ccmonitor tray": a system tray icon whose color
+// shows the status of the session that needs attention most, with a menu of
+// all sessions to switch to.
+package tray
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/monitor"
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+// Options configures the tray.
+type Options struct {
+	Project     string        // only show sessions in this directory tree ("" = all)
+	Ignore      []string      // project directories or patterns to hide
+	StallAfter  time.Duration // quiet period before a working session counts as stalled (0 = never)
+	Transcripts string        // read instead of the sessions directories when set
+}
+
+// poller loads the sessions the way the monitor does: see monitor.Loader.
+type poller struct {
+	loader     *monitor.Loader
+	stallAfter time.Duration
+	loadErr    string // last load error, logged once
+}
+
+func newPoller(dirs []string, opts Options) *poller {
+	return &poller{
+		loader: monitor.NewLoader(dirs, monitor.LoaderOptions{
+			Project:     opts.Project,
+			Ignore:      opts.Ignore,
+			Transcripts: opts.Transcripts,
+			Untracked:   true,
+		}),
+		stallAfter: opts.StallAfter,
+	}
+}
+
+func (p *poller) load(now time.Time) []session.Session {
+	sessions, err := p.loader.Load(now)
+	if err != nil && err.Error() != p.loadErr {
+		slog.Warn("loading sessions", "err", err)
+	}
+	p.loadErr = ""
+	if err != nil {
+		p.loadErr = err.Error()
+	}
+	monitor.MarkStalled(sessions, p.stallAfter, now)
+	return session.SortByAttention(sessions)
+}
+
+// statusColors are the icon colors per status, matching the monitor's.
+var statusColors = map[string]color.RGBA{
+	session.StatusWaiting:    {0xe0, 0xb0, 0x00, 0xff}, // yellow
+	session.StatusError:      {0xf0, 0x50, 0x50, 0xff}, // bright red
+	session.StatusStalled:    {0xd0, 0x40, 0xd0, 0xff}, // magenta
+	session.StatusWorking:    {0x2e, 0xa0, 0x43, 0xff}, // green
+	session.StatusCompacting: {0x2e, 0xa0, 0x43, 0xff}, // green
+	session.StatusStarting:   {0x00, 0xa0, 0xc0, 0xff}, // cyan
+	session.StatusLimited:    {0x30, 0x70, 0xe0, 0xff}, // blue
+	session.StatusExited:     {0xd0, 0x30, 0x30, 0xff}, // red
+}
+
+// idleColor is used for idle and untracked sessions, and when there are none.
+var idleColor = color.RGBA{0x88, 0x88, 0x88, 0xff}
+
+// worstColor returns the icon color for sessions sorted by attention: the
+// color of the first one's status.
+func worstColor(sorted []session.Session) color.RGBA {
+	if len(sorted) == 0 {
+		return idleColor
+	}
+	if c, ok := statusColors[sorted[0].Status]; ok {
+		return c
+	}
+	return idleColor
+}
+
+// statusGlyphs are the glyphs shown before each session in the menu.
+var statusGlyphs = map[string]string{
+	session.StatusWaiting:    "◆",
+	session.StatusError:      "⚠",
+	session.StatusStalled:    "⧖",
+	session.StatusWorking:    "●",
+	session.StatusCompacting: "◎",
+	session.StatusStarting:   "◌",
+	session.StatusLimited:    "◷",
+	session.StatusIdle:       "○",
+	session.StatusExited:     "✕",
+	session.StatusUntracked:  "◇",
+}
+
+// menuLabel is a session's menu entry: glyph, project, status and detail.
+func menuLabel(s session.Session) string {
+	glyph, ok := statusGlyphs[s.Status]
+	if !ok {
+		glyph = "?"
+	}
+	label := fmt.Sprintf("%s %s — %s", glyph, filepath.Base(s.Project), s.Status)
+	if s.Detail != "" {
+		label += ": " + session.Truncate(s.Detail, 40)
+	}
+	return label
+}
+
+// tooltip counts the sessions per status, most urgent first, e.g.
+// "ccmonitor: 1 waiting, 2 working".
+func tooltip(sorted []session.Session) string {
+	if len(sorted) == 0 {
+		return "ccmonitor: no sessions"
+	}
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j < len(sorted) && sorted[j].Status == sorted[i].Status {
+			j++
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", j-i, sorted[i].Status))
+		i = j
+	}
+	return "ccmonitor: " + strings.Join(parts, ", ")
+}
+
+// fingerprint changes whenever the menu or icon would.
+func fingerprint(sorted []session.Session) string {
+	var b strings.Builder
+	for _, s := range sorted {
+		fmt.Fprintf(&b, "%s|%s|%s|%s|%d\n", s.SessionID, s.Project, s.Status, s.Detail, len(s.Terminals))
+	}
+	return b.String()
+}
@@ -0,0 +1,76 @@
+//go:build !darwin || cgo
+
+package tray
+
+import (
+	"time"
+
+	"fyne.io/systray"
+
+	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/switcher"
+)
+
+// Run shows the tray icon and keeps it up to date until Quit is chosen from
+// its menu. Clicking a session switches to its tmux pane or terminal tab.
+func Run(dirs []string, opts Options) error {
+	p := newPoller(dirs, opts)
+	systray.Run(func() { go p.run() }, nil)
+	return nil
+}
+
+// run polls once a second and rebuilds the icon and menu when anything
+// shown in them changed.
+func (p *poller) run() {
+	var last string
+	var done chan struct{}
+	for {
+		sorted := p.load(time.Now())
+		if key := fingerprint(sorted); key != last || done == nil {
+			if done != nil {
+				close(done)
+			}
+			done = make(chan struct{})
+			update(sorted, done)
+			last = key
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// update redraws the icon and replaces the menu. The click handlers of the
+// menu items stop when done is closed.
+func update(sorted []session.Session, done chan struct{}) {
+	systray.SetIcon(icon(worstColor(sorted)))
+	systray.SetTooltip(tooltip(sorted))
+	systray.ResetMenu()
+	for _, s := range sorted {
+		item := systray.AddMenuItem(menuLabel(s), s.LastPrompt)
+		if len(s.Terminals) == 0 {
+			item.Disable()
+			continue
+		}
+		go func() {
+			for {
+				select {
+				case <-item.ClickedCh:
+					switcher.Switch(s)
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	if len(sorted) == 0 {
+		systray.AddMenuItem("No sessions", "").Disable()
+	}
+	systray.AddSeparator()
+	quit := systray.AddMenuItem("Quit", "Close the tray icon")
+	go func() {
+		select {
+		case <-quit.ClickedCh:
+			systray.Quit()
+		case <-done:
+		}
+	}()
+}
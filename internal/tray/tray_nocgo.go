@@ -0,0 +1,10 @@
+//go:build darwin && !cgo
+
+package tray
+
+import "errors"
+
+// Run fails: the macOS tray needs cgo, and this binary was built without it.
+func Run(dirs []string, opts Options) error {
+	return errors.New("the tray needs a build with cgo on macOS (CGO_ENABLED=1 go install ...)")
+}
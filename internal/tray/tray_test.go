@@ -0,0 +1,117 @@
+package tray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/proc"
+	"github.com/martinwickman/ccmonitor/internal/proc/proctest"
+	"github.com/martinwickman/ccmonitor/internal/session"
+)
+
+func TestWorstColor(t *testing.T) {
+	t.Run("most urgent session should pick the color", func(t *testing.T) {
+		sorted := session.SortByAttention([]session.Session{
+			{SessionID: "a", Status: session.StatusIdle},
+			{SessionID: "b", Status: session.StatusWaiting},
+			{SessionID: "c", Status: session.StatusWorking},
+		})
+		if got, want := worstColor(sorted), statusColors[session.StatusWaiting]; got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("no sessions should be grey", func(t *testing.T) {
+		if got := worstColor(nil); got != idleColor {
+			t.Errorf("got %v, want %v", got, idleColor)
+		}
+	})
+}
+
+func TestTooltip(t *testing.T) {
+	sorted := session.SortByAttention([]session.Session{
+		{SessionID: "a", Status: session.StatusWorking},
+		{SessionID: "b", Status: session.StatusWaiting},
+		{SessionID: "c", Status: session.StatusWorking},
+	})
+	if got, want := tooltip(sorted), "ccmonitor: 1 waiting, 2 working"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := tooltip(nil), "ccmonitor: no sessions"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMenuLabel(t *testing.T) {
+	s := session.Session{Project: "/home/user/api", Status: session.StatusWaiting, Detail: "Permission: Bash"}
+	if got, want := menuLabel(s), "◆ api — waiting: Permission: Bash"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIcon(t *testing.T) {
+	t.Run("icon should be a PNG of the icon size", func(t *testing.T) {
+		img, err := png.Decode(bytes.NewReader(icon(idleColor)))
+		if err != nil {
+			t.Skipf("not a PNG on this platform: %v", err)
+		}
+		if b := img.Bounds(); b.Dx() != iconSize || b.Dy() != iconSize {
+			t.Errorf("size %v, want %dx%d", b, iconSize, iconSize)
+		}
+	})
+
+	t.Run("ICO should point at the embedded PNG", func(t *testing.T) {
+		data := wrapICO([]byte("png"))
+		if binary.LittleEndian.Uint16(data[4:]) != 1 {
+			t.Errorf("image count = %d, want 1", binary.LittleEndian.Uint16(data[4:]))
+		}
+		size, offset := binary.LittleEndian.Uint32(data[14:]), binary.LittleEndian.Uint32(data[18:])
+		if size != 3 || string(data[offset:]) != "png" {
+			t.Errorf("size %d offset %d, want the PNG at the end", size, offset)
+		}
+	})
+}
+
+func TestPoller(t *testing.T) {
+	writeSession := func(t *testing.T, dir string, s session.Session) {
+		t.Helper()
+		data, _ := json.Marshal(s)
+		if err := os.WriteFile(filepath.Join(dir, s.SessionID+".json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("exited sessions should stay exited between PID checks", func(t *testing.T) {
+		dir := t.TempDir()
+		writeSession(t, dir, session.Session{SessionID: "dead", Project: "/home/user/api", Status: session.StatusIdle, PID: 99999999, OS: runtime.GOOS})
+		p := newPoller([]string{dir}, Options{Project: "/home/user"})
+		now := time.Now()
+		for _, at := range []time.Duration{0, time.Second} {
+			if got := p.load(now.Add(at)); len(got) != 1 || got[0].Status != session.StatusExited {
+				t.Errorf("after %v: got %+v, want the session exited", at, got)
+			}
+		}
+	})
+
+	t.Run("a process that writes its session file should not be listed twice", func(t *testing.T) {
+		dir := t.TempDir()
+		wd, _ := os.Getwd()
+		pid := proctest.StartFakeClaude(t)
+		p := newPoller([]string{dir}, Options{Project: wd})
+		now := time.Now()
+		if got := p.load(now); len(got) != 1 || got[0].Status != session.StatusUntracked {
+			t.Skipf("fake Claude process not found as untracked: %+v", got)
+		}
+		writeSession(t, dir, session.Session{SessionID: "new", Project: wd, Status: session.StatusWorking, PID: pid, PIDStart: proc.StartTime(pid), OS: runtime.GOOS})
+		if got := p.load(now.Add(time.Second)); len(got) != 1 || got[0].SessionID != "new" {
+			t.Errorf("got %+v, want only the session", got)
+		}
+	})
+}
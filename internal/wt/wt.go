@@ -2,10 +2,12 @@
 package wt
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/martinwickman/ccmonitor/internal/terminal"
 )
@@ -30,8 +32,22 @@ $wtCond = New-Object System.Windows.Automation.PropertyCondition([System.Windows
 $wtWindows = $root.FindAll([System.Windows.Automation.TreeScope]::Children, $wtCond)
 `
 
-func runPowerShell(script string) (string, error) {
-	out, err := exec.Command("powershell.exe", "-NoProfile", "-Command", script).Output()
+// Timeouts for the lookups the hook makes. PowerShell with UI Automation
+// takes a second or more to start, so these are upper bounds for a hung
+// script rather than a budget; the hook doesn't wait for title lookups.
+const (
+	infoTimeout  = 5 * time.Second
+	titleTimeout = 3 * time.Second
+)
+
+// runPowerShell runs script and returns its trimmed output, killing it after
+// timeout.
+func runPowerShell(timeout time.Duration, script string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "powershell.exe", "-NoProfile", "-Command", script)
+	cmd.WaitDelay = time.Second
+	out, err := cmd.Output()
 	if err != nil {
 		return "", err
 	}
@@ -70,7 +86,7 @@ foreach ($w in $wtWindows) {
     }
 }`
 
-	out, err := runPowerShell(script)
+	out, err := runPowerShell(infoTimeout, script)
 	if err != nil {
 		return "", ""
 	}
@@ -104,16 +120,17 @@ foreach ($w in $wtWindows) {
     }
 }`, runtimeID)
 
-	out, err := runPowerShell(script)
+	out, err := runPowerShell(titleTimeout, script)
 	if err != nil {
 		return ""
 	}
 	return terminal.StripTitlePrefix(out)
 }
 
-// Select switches to a Windows Terminal tab identified by its RuntimeId.
-func (Backend) Select(runtimeID string) error {
-	script := preamble + fmt.Sprintf(`
+// selectScript finds the tab with the given RuntimeId, selects it and focuses
+// its window, then runs then. Errors with "Tab not found" if there is none.
+func selectScript(runtimeID, then string) string {
+	return preamble + fmt.Sprintf(`
 $targetRid = @(%s)
 foreach ($w in $wtWindows) {
     $tabCond = New-Object System.Windows.Automation.PropertyCondition([System.Windows.Automation.AutomationElement]::ControlTypeProperty, [System.Windows.Automation.ControlType]::TabItem)
@@ -124,17 +141,83 @@ foreach ($w in $wtWindows) {
             $sel = $tab.GetCurrentPattern([System.Windows.Automation.SelectionItemPattern]::Pattern)
             $sel.Select()
             $w.SetFocus()
+%s
             exit
         }
     }
 }
 Write-Error 'Tab not found'
-exit 1`, runtimeID)
+exit 1`, runtimeID, then)
+}
 
-	cmd := exec.Command("powershell.exe", "-NoProfile", "-Command", script)
+// Select switches to a Windows Terminal tab identified by its RuntimeId.
+func (Backend) Select(runtimeID string) error {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-Command", selectScript(runtimeID, ""))
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("switching WT tab: %s: %w", strings.TrimSpace(string(out)), err)
 	}
 	return nil
 }
+
+// SendText switches to the tab and types text into it. WT has no API for
+// writing to a tab's input, so this goes through SendKeys on the focused
+// window and only works while nothing steals focus in between.
+func (Backend) SendText(runtimeID, text string) error {
+	return sendKeys(runtimeID, escapeSendKeys(text))
+}
+
+// SendKey switches to the tab and presses a special key in it.
+func (Backend) SendKey(runtimeID string, key terminal.Key) error {
+	k, ok := sendKeysNames[key]
+	if !ok {
+		return fmt.Errorf("key %q not supported in WT", key)
+	}
+	return sendKeys(runtimeID, k)
+}
+
+// sendKeysNames maps terminal keys to SendKeys syntax.
+var sendKeysNames = map[terminal.Key]string{
+	terminal.KeyEnter:  "{ENTER}",
+	terminal.KeyEscape: "{ESC}",
+}
+
+func sendKeys(runtimeID, keys string) error {
+	then := fmt.Sprintf(`            Add-Type -AssemblyName System.Windows.Forms
+            Start-Sleep -Milliseconds 100
+            [System.Windows.Forms.SendKeys]::SendWait('%s')`, strings.ReplaceAll(keys, "'", "''"))
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-Command", selectScript(runtimeID, then))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("sending keys to WT tab: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// escapeSendKeys wraps characters that SendKeys treats as special in braces
+// so text is typed literally.
+func escapeSendKeys(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch r {
+		case '+', '^', '%', '~', '(', ')', '{', '}', '[', ']':
+			b.WriteString("{" + string(r) + "}")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Launch opens a new tab in the current Windows Terminal window, starting in
+// dir, and runs command through PowerShell. wt.exe treats ";" as a
+// subcommand separator, so it is escaped.
+func (Backend) Launch(dir, command string) error {
+	command = strings.ReplaceAll(command, ";", `\;`)
+	out, err := exec.Command("wt.exe", "-w", "0", "new-tab", "-d", dir,
+		"powershell.exe", "-NoExit", "-Command", command).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("opening WT tab: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
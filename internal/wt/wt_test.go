@@ -0,0 +1,22 @@
+package wt
+
+import "testing"
+
+func TestEscapeSendKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text should be unchanged", "run the tests", "run the tests"},
+		{"special characters should be wrapped in braces", "fix a+b (50%)", "fix a{+}b {(}50{%}{)}"},
+		{"braces should be escaped too", "map{}", "map{{}{}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escapeSendKeys(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Package ccmonitor is the Go API of ccmonitor, for tools that want the state
+// of the running Claude Code sessions without shelling out to the CLI: status
+// bars, tmux plugins, chat bots. It reads the session files the hooks write
+// and derives exited and stalled sessions the same way the monitor does.
+//
+// The types and functions here are kept stable; everything under internal/
+// may change between releases.
+package ccmonitor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/martinwickman/ccmonitor/internal/monitor"
+	"github.com/martinwickman/ccmonitor/internal/session"
+	"github.com/martinwickman/ccmonitor/internal/switcher"
+	"github.com/martinwickman/ccmonitor/internal/terminal"
+)
+
+// Session is the state of one Claude Code session, as written by the hooks.
+// See the session file schema in ARCHITECTURE.md for its fields.
+type Session = session.Session
+
+// Terminal identifies the terminal tab or pane a session runs in.
+type Terminal = session.Terminal
+
+// Git describes the git working tree a session runs in.
+type Git = session.Git
+
+// Subagent is a subagent a session is still running.
+type Subagent = session.Subagent
+
+// Usage is a session's token usage and estimated cost so far.
+type Usage = session.Usage
+
+// Session statuses. Stalled and exited are derived by Load and Watch; the
+// rest come from the hooks.
+const (
+	StatusStarting   = session.StatusStarting
+	StatusWorking    = session.StatusWorking
+	StatusCompacting = session.StatusCompacting // PreCompact: compacting the context
+	StatusIdle       = session.StatusIdle
+	StatusWaiting    = session.StatusWaiting // blocked on the user, e.g. a permission prompt
+	StatusLimited    = session.StatusLimited // usage limit hit, see Session.LimitResetsAt
+	StatusError      = session.StatusError   // the turn ended in an API or network error
+	StatusStalled    = session.StatusStalled // working, but no hook events for Options.StallAfter
+	StatusExited     = session.StatusExited  // the process is gone without an end hook
+)
+
+// What a working session is doing, in Session.Activity. Thinking includes
+// writing the answer, which no hook event marks.
+const (
+	ActivityThinking = session.ActivityThinking
+	ActivityTool     = session.ActivityTool
+)
+
+// DefaultStallAfter is the StallAfter the monitor uses by default.
+const DefaultStallAfter = monitor.DefaultStallAfter
+
+// Options configures Load and Watch. The zero value reads all sessions from
+// the default sessions directories and never marks sessions stalled.
+type Options struct {
+	Dirs       []string      // sessions directories (nil = Dirs())
+	Project    string        // only sessions in this directory tree ("" = all)
+	Ignore     []string      // project directories or patterns to leave out
+	StallAfter time.Duration // quiet period before a working session counts as stalled (0 = never)
+}
+
+// Dirs returns the sessions directories the hooks write to: those listed in
+// $CCMONITOR_SESSIONS_DIR, else the platform default (under WSL, the
+// Windows-side one when it exists).
+func Dirs() []string {
+	return session.Dirs()
+}
+
+// Load returns the current sessions, most urgent first (waiting, error,
+// stalled, working, ..., exited). Sessions whose process has died are marked
+// exited.
+// A directory that can't be read doesn't stop the others from loading; its
+// error is returned along with the sessions.
+func Load(opts Options) ([]Session, error) {
+	return load(newLoader(opts), opts, time.Now())
+}
+
+// Watch calls fn with the sessions, as returned by Load, once right away and
+// then each time they change. Sessions are polled once a second, like the
+// monitor does; PIDs are checked every 10 seconds. Unreadable directories
+// are retried on the next poll. Watch returns when ctx is done or fn returns
+// an error, with that error.
+func Watch(ctx context.Context, opts Options, fn func([]Session) error) error {
+	loader := newLoader(opts)
+	var last []Session
+	first := true
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		sessions, _ := load(loader, opts, time.Now())
+		if first || !reflect.DeepEqual(sessions, last) {
+			if err := fn(sessions); err != nil {
+				return err
+			}
+			first, last = false, sessions
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// newLoader returns the loader for the sessions opts selects, which
+// remembers the sessions that have exited between PID checks.
+func newLoader(opts Options) *monitor.Loader {
+	dirs := opts.Dirs
+	if dirs == nil {
+		dirs = session.Dirs()
+	}
+	return monitor.NewLoader(dirs, monitor.LoaderOptions{Project: opts.Project, Ignore: opts.Ignore})
+}
+
+func load(loader *monitor.Loader, opts Options, now time.Time) ([]Session, error) {
+	sessions, err := loader.Load(now)
+	monitor.MarkStalled(sessions, opts.StallAfter, now)
+	return session.SortByAttention(sessions), err
+}
+
+// ErrNotWaiting is returned by Approve and Deny for a session that isn't
+// showing a permission prompt.
+var ErrNotWaiting = errors.New("session is not waiting for permission")
+
+// Switch focuses the terminal tab or pane the session runs in.
+func Switch(s Session) error {
+	return switcher.Switch(s)
+}
+
+// SendPrompt types text into the session's terminal and presses Enter.
+func SendPrompt(s Session, text string) error {
+	return switcher.SendText(s, text)
+}
+
+// Approve allows the tool call a session is asking permission for, once.
+func Approve(s Session) error {
+	return answerPermission(s, "1")
+}
+
+// Deny refuses the tool call a session is asking permission for.
+func Deny(s Session) error {
+	return answerPermission(s, "3")
+}
+
+// answerPermission picks option in Claude Code's permission dialog.
+func answerPermission(s Session, option string) error {
+	if !s.AwaitingPermission() {
+		return ErrNotWaiting
+	}
+	return switcher.Type(s, option)
+}
+
+// Interrupt presses Escape in the session's terminal, stopping what Claude is
+// doing.
+func Interrupt(s Session) error {
+	return switcher.SendKey(s, terminal.KeyEscape)
+}
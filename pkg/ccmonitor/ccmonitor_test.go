@@ -0,0 +1,95 @@
+package ccmonitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func writeSessionFile(t *testing.T, dir string, s Session) {
+	t.Helper()
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("marshal session: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, s.SessionID+".json"), data, 0644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	now := time.Now().UTC()
+	dir := t.TempDir()
+	writeSessionFile(t, dir, Session{SessionID: "idle", Project: "/home/user/api", Status: StatusIdle, LastActivity: now.Format(time.RFC3339)})
+	writeSessionFile(t, dir, Session{SessionID: "quiet", Project: "/home/user/api", Status: StatusWorking, LastActivity: now.Add(-time.Hour).Format(time.RFC3339)})
+	writeSessionFile(t, dir, Session{SessionID: "dead", Project: "/home/user/web", Status: StatusWorking, LastActivity: now.Format(time.RFC3339), PID: 99999999, OS: runtime.GOOS})
+
+	t.Run("sessions should come most urgent first with derived statuses", func(t *testing.T) {
+		sessions, err := Load(Options{Dirs: []string{dir}, StallAfter: DefaultStallAfter})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got []string
+		for _, s := range sessions {
+			got = append(got, s.SessionID+" "+s.Status)
+		}
+		want := []string{"quiet stalled", "idle idle", "dead exited"}
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("got %v, want %v", got, want)
+				break
+			}
+		}
+	})
+
+	t.Run("project filter should keep only that tree", func(t *testing.T) {
+		sessions, _ := Load(Options{Dirs: []string{dir}, Project: "/home/user/web"})
+		if len(sessions) != 1 || sessions[0].SessionID != "dead" {
+			t.Errorf("got %+v, want only the web session", sessions)
+		}
+	})
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeSessionFile(t, dir, Session{SessionID: "a", Project: "/home/user/api", Status: StatusIdle})
+
+	t.Run("current sessions should be reported right away", func(t *testing.T) {
+		stop := errors.New("stop")
+		var got []Session
+		err := Watch(context.Background(), Options{Dirs: []string{dir}}, func(sessions []Session) error {
+			got = sessions
+			return stop
+		})
+		if err != stop {
+			t.Errorf("got error %v, want the one fn returned", err)
+		}
+		if len(got) != 1 || got[0].SessionID != "a" {
+			t.Errorf("got %+v, want session a", got)
+		}
+	})
+
+	t.Run("cancelled context should end the watch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := Watch(ctx, Options{Dirs: []string{dir}}, func([]Session) error { return nil })
+		if err != context.Canceled {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	})
+}
+
+func TestApprove(t *testing.T) {
+	s := Session{SessionID: "a", Status: StatusWorking}
+	if err := Approve(s); err != ErrNotWaiting {
+		t.Errorf("got %v, want ErrNotWaiting", err)
+	}
+}